@@ -0,0 +1,354 @@
+package dyc
+
+import (
+	"context"
+	"encoding/base64"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Sum returns the sum of the numeric attribute at path of all items matching the built query.
+// path may reference nested attributes e.g stats.views or tags[0], items without a numeric value at path are skipped.
+//
+// like Count, a query is performed if a key condition was set and a parallel scan otherwise.
+// only the attribute at path is retrieved, items are aggregated page by page and the limit is ignored
+func (s *Builder) Sum(ctx context.Context, path string) (sum float64, err error) {
+	err = s.aggregate(ctx, "Sum", path, func(av *dynamodb.AttributeValue) {
+		if n, ok := numberValue(av); ok {
+			sum += n
+		}
+	})
+
+	return sum, err
+}
+
+// Avg returns the average of the numeric attribute at path of all items matching the built query.
+// items without a numeric value at path are skipped, ErrNoValues is returned if no item has one
+func (s *Builder) Avg(ctx context.Context, path string) (float64, error) {
+	var sum float64
+	var count int64
+	err := s.aggregate(ctx, "Avg", path, func(av *dynamodb.AttributeValue) {
+		if n, ok := numberValue(av); ok {
+			sum += n
+			count++
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, ErrNoValues
+	}
+
+	return sum / float64(count), nil
+}
+
+// Min returns the lowest numeric value of the attribute at path of all items matching the built query.
+// items without a numeric value at path are skipped, ErrNoValues is returned if no item has one
+func (s *Builder) Min(ctx context.Context, path string) (float64, error) {
+	return s.extreme(ctx, "Min", path, func(n, current float64) bool { return n < current })
+}
+
+// Max returns the highest numeric value of the attribute at path of all items matching the built query.
+// items without a numeric value at path are skipped, ErrNoValues is returned if no item has one
+func (s *Builder) Max(ctx context.Context, path string) (float64, error) {
+	return s.extreme(ctx, "Max", path, func(n, current float64) bool { return n > current })
+}
+
+func (s *Builder) extreme(ctx context.Context, method, path string, replaces func(n, current float64) bool) (float64, error) {
+	var result float64
+	found := false
+	err := s.aggregate(ctx, method, path, func(av *dynamodb.AttributeValue) {
+		if n, ok := numberValue(av); ok && (!found || replaces(n, result)) {
+			result, found = n, true
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, ErrNoValues
+	}
+
+	return result, nil
+}
+
+// Distinct returns the distinct values of the attribute at path of all items matching the built query sorted ascending.
+// values are prefixed with their attribute type so e.g the string "1" and the number 1 stay distinct:
+// strings and numbers are returned as S:value and N:value, other values are rendered e.g BOOL:true or L:["a", "b"],
+// binary values are base64 encoded e.g B:AQI= and set members are sorted.
+// items without a value at path are skipped
+func (s *Builder) Distinct(ctx context.Context, path string) ([]string, error) {
+	seen := make(map[string]struct{})
+	err := s.aggregate(ctx, "Distinct", path, func(av *dynamodb.AttributeValue) {
+		if av != nil {
+			seen[groupKey(av)] = struct{}{}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0, len(seen))
+	for val := range seen {
+		result = append(result, val)
+	}
+	sort.Strings(result)
+
+	return result, nil
+}
+
+// GroupMissing is the group key of items without a value at the grouped path.
+// keys of values always contain their attribute type so it can't collide with a value, not even an empty string
+const GroupMissing = "MISSING"
+
+// Grouping aggregates the items matching a builder per value of an attribute
+type Grouping struct {
+	builder *Builder
+	path    string
+}
+
+// GroupBy groups the items matching the built query by the value of the attribute at path.
+// values are keyed like Distinct, items without a value at path are grouped under GroupMissing
+func (s *Builder) GroupBy(path string) *Grouping {
+	return &Grouping{builder: s, path: path}
+}
+
+// Count returns the amount of items per value of the grouped attribute
+func (g *Grouping) Count(ctx context.Context) (map[string]int64, error) {
+	result := make(map[string]int64)
+	err := g.builder.aggregate(ctx, "GroupByCount", g.path, func(av *dynamodb.AttributeValue) {
+		key := GroupMissing
+		if av != nil {
+			key = groupKey(av)
+		}
+		result[key]++
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// aggregate calls fn with the value at path of every item matching the built query, nil if an item has no value.
+// fn is never called concurrently
+func (s *Builder) aggregate(ctx context.Context, method, path string, fn func(av *dynamodb.AttributeValue)) (err error) {
+	ctx, done := s.start(ctx, method)
+	defer done(&err)
+	if s.err != nil {
+		return s.err
+	}
+	if s.client == nil {
+		return ErrClientNotSet
+	}
+	parsed, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+
+	projection, projected := parsed.projection()
+	names := func(expressions ...*string) map[string]*string {
+		result := usedNames(s.cols, expressions...)
+		if result == nil {
+			result = make(map[string]*string, len(projected))
+		}
+		for placeholder, name := range projected {
+			result[placeholder] = name
+		}
+		return result
+	}
+	each := func(items Maps) {
+		for _, item := range items {
+			fn(parsed.value(item))
+		}
+	}
+
+	if s.keyExpression != "" {
+		query, _ := s.ToQuery()
+		query.Limit = nil
+		query.Select = aws.String(dynamodb.SelectSpecificAttributes)
+		query.ProjectionExpression = aws.String(projection)
+		query.ExpressionAttributeNames = names(query.KeyConditionExpression, query.FilterExpression)
+
+		return s.client.QueryIteratorV2(ctx, &query, nil, func(output *dynamodb.QueryOutput) error {
+			each(output.Items)
+			return nil
+		})
+	}
+
+	scan, _ := s.ToScan()
+	scan.Limit = nil
+	scan.Select = aws.String(dynamodb.SelectSpecificAttributes)
+	scan.ProjectionExpression = aws.String(projection)
+	scan.ExpressionAttributeNames = names(scan.FilterExpression)
+
+	return s.client.ParallelScanIterator(ctx, &scan, s.scanSegments(), func(output *dynamodb.ScanOutput) error {
+		each(output.Items)
+		return nil
+	}, false)
+}
+
+// pathElement is a single element of an attribute path, either an attribute name or a list index
+type pathElement struct {
+	name  string
+	index int
+}
+
+type attributePath []pathElement
+
+// parsePath parses a document path such as stats.views or tags[0].name
+func parsePath(path string) (attributePath, error) {
+	var result attributePath
+	for _, part := range strings.Split(path, ".") {
+		name := part
+		var indexes []int
+		if open := strings.IndexByte(part, '['); open >= 0 {
+			name = part[:open]
+			rest := part[open:]
+			for rest != "" {
+				end := strings.IndexByte(rest, ']')
+				if rest[0] != '[' || end < 0 {
+					return nil, ErrInvalidPath
+				}
+				idx, err := strconv.Atoi(rest[1:end])
+				if err != nil || idx < 0 {
+					return nil, ErrInvalidPath
+				}
+				indexes = append(indexes, idx)
+				rest = rest[end+1:]
+			}
+		}
+		if name == "" {
+			return nil, ErrInvalidPath
+		}
+
+		result = append(result, pathElement{name: name, index: -1})
+		for _, idx := range indexes {
+			result = append(result, pathElement{index: idx})
+		}
+	}
+
+	return result, nil
+}
+
+// projection renders the path as a projection expression with every attribute name aliased
+func (p attributePath) projection() (string, map[string]*string) {
+	var sb strings.Builder
+	names := make(map[string]*string)
+	for idx, elem := range p {
+		if elem.index >= 0 {
+			sb.WriteString("[" + strconv.Itoa(elem.index) + "]")
+			continue
+		}
+		if idx > 0 {
+			sb.WriteByte('.')
+		}
+		placeholder := "#agg" + strconv.Itoa(len(names))
+		names[placeholder] = aws.String(elem.name)
+		sb.WriteString(placeholder)
+	}
+
+	return sb.String(), names
+}
+
+// value returns the value at the path of the item or nil if the item doesn't have one
+func (p attributePath) value(item Map) *dynamodb.AttributeValue {
+	av := &dynamodb.AttributeValue{M: item}
+	for _, elem := range p {
+		switch {
+		case av == nil:
+			return nil
+		case elem.index >= 0:
+			if elem.index >= len(av.L) {
+				return nil
+			}
+			av = av.L[elem.index]
+		default:
+			av = av.M[elem.name]
+		}
+	}
+
+	return av
+}
+
+func numberValue(av *dynamodb.AttributeValue) (float64, bool) {
+	if av == nil || av.N == nil {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(*av.N, 64)
+
+	return n, err == nil
+}
+
+// groupKey prefixes values with their attribute type so values of different types never share a key
+// e.g S:1 and N:1. strings and numbers are kept as is, any other value is rendered by keyValue e.g BOOL:true or L:["a"]
+func groupKey(av *dynamodb.AttributeValue) string {
+	switch {
+	case av.S != nil:
+		return "S:" + *av.S
+	case av.N != nil:
+		return "N:" + *av.N
+	case av.B != nil:
+		return "B:" + keyValue(av)
+	case av.BOOL != nil:
+		return "BOOL:" + keyValue(av)
+	case av.SS != nil:
+		return "SS:" + keyValue(av)
+	case av.NS != nil:
+		return "NS:" + keyValue(av)
+	case av.BS != nil:
+		return "BS:" + keyValue(av)
+	case av.L != nil:
+		return "L:" + keyValue(av)
+	case av.M != nil:
+		return "M:" + keyValue(av)
+	}
+
+	return "NULL:null"
+}
+
+// keyValue renders a value like renderValue but without losing information so equal keys mean equal values.
+// binary values are base64 encoded and set members are sorted since sets are unordered
+func keyValue(av *dynamodb.AttributeValue) string {
+	switch {
+	case av == nil:
+		return "null"
+	case av.B != nil:
+		return base64.StdEncoding.EncodeToString(av.B)
+	case av.SS != nil:
+		parts := make([]string, 0, len(av.SS))
+		for _, s := range av.SS {
+			parts = append(parts, strconv.Quote(aws.StringValue(s)))
+		}
+		return sortedSet(parts)
+	case av.NS != nil:
+		return sortedSet(aws.StringValueSlice(av.NS))
+	case av.BS != nil:
+		parts := make([]string, 0, len(av.BS))
+		for _, b := range av.BS {
+			parts = append(parts, base64.StdEncoding.EncodeToString(b))
+		}
+		return sortedSet(parts)
+	case av.L != nil:
+		parts := make([]string, 0, len(av.L))
+		for _, v := range av.L {
+			parts = append(parts, keyValue(v))
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case av.M != nil:
+		return renderMap(av.M, keyValue)
+	}
+
+	return renderValue(av)
+}
+
+func sortedSet(members []string) string {
+	sort.Strings(members)
+
+	return "<<" + strings.Join(members, ", ") + ">>"
+}
@@ -0,0 +1,200 @@
+//go:build unit
+// +build unit
+
+package dyc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder_Aggregations(t *testing.T) {
+	pages := []Maps{
+		{
+			{"stats": &dynamodb.AttributeValue{M: Map{"views": Int(3)}}, "status": String("open")},
+			{"stats": &dynamodb.AttributeValue{M: Map{"views": Int(10)}}, "status": String("closed")},
+		},
+		{
+			{"stats": &dynamodb.AttributeValue{M: Map{"views": String("n/a")}}, "status": String("open")},
+			{"stats": &dynamodb.AttributeValue{M: Map{"views": &dynamodb.AttributeValue{N: aws.String("-1.5")}}}},
+		},
+	}
+
+	queryClient := func(t *testing.T) *Client {
+		return stubClient(t, func(op *Operation) error {
+			input := op.Input.(*dynamodb.QueryInput)
+			require.Nil(t, input.Limit)
+			require.Equal(t, dynamodb.SelectSpecificAttributes, aws.StringValue(input.Select))
+
+			page := 0
+			if input.ExclusiveStartKey != nil {
+				page = 1
+			}
+			output := op.Output.(*dynamodb.QueryOutput)
+			output.Items = pages[page]
+			if page == 0 {
+				output.LastEvaluatedKey = Map{"PK": String("a")}
+			}
+			return nil
+		})
+	}
+	builder := func(cli *Client) *Builder {
+		return cli.Builder().Table("MyTable").WhereKey("PK = ?", "a").Limit(1)
+	}
+
+	t.Run("should restrict the projection to the aggregated path", func(t *testing.T) {
+		cli := stubClient(t, func(op *Operation) error {
+			input := op.Input.(*dynamodb.QueryInput)
+			require.Equal(t, "#agg0.#agg1[2]", aws.StringValue(input.ProjectionExpression))
+			require.Equal(t, map[string]*string{
+				"#1":    aws.String("status"),
+				"#agg0": aws.String("stats"),
+				"#agg1": aws.String("views"),
+			}, input.ExpressionAttributeNames)
+			return nil
+		})
+
+		_, err := cli.Builder().Table("MyTable").
+			WhereKey("PK = ?", "a").
+			Where("status = ?", "open").
+			SelectFields("name").
+			Sum(context.Background(), "stats.views[2]")
+		require.NoError(t, err)
+	})
+
+	t.Run("should aggregate numeric values across pages", func(t *testing.T) {
+		ctx := context.Background()
+		cli := queryClient(t)
+
+		sum, err := builder(cli).Sum(ctx, "stats.views")
+		require.NoError(t, err)
+		require.Equal(t, 11.5, sum)
+
+		min, err := builder(cli).Min(ctx, "stats.views")
+		require.NoError(t, err)
+		require.Equal(t, -1.5, min)
+
+		max, err := builder(cli).Max(ctx, "stats.views")
+		require.NoError(t, err)
+		require.Equal(t, 10.0, max)
+
+		avg, err := builder(cli).Avg(ctx, "stats.views")
+		require.NoError(t, err)
+		require.InDelta(t, 11.5/3, avg, 0.0001)
+	})
+
+	t.Run("should group and dedupe values", func(t *testing.T) {
+		ctx := context.Background()
+		cli := queryClient(t)
+
+		groups, err := builder(cli).GroupBy("status").Count(ctx)
+		require.NoError(t, err)
+		require.Equal(t, map[string]int64{"S:open": 2, "S:closed": 1, GroupMissing: 1}, groups)
+
+		distinct, err := builder(cli).Distinct(ctx, "status")
+		require.NoError(t, err)
+		require.Equal(t, []string{"S:closed", "S:open"}, distinct)
+	})
+
+	t.Run("should key values by type", func(t *testing.T) {
+		cli := stubClient(t, func(op *Operation) error {
+			op.Output.(*dynamodb.QueryOutput).Items = Maps{
+				{"v": String("1")},
+				{"v": Int(1)},
+				{"v": String("")},
+				{},
+				{"v": {BOOL: aws.Bool(true)}},
+				{"v": {NULL: aws.Bool(true)}},
+				{"v": {L: []*dynamodb.AttributeValue{String("a")}}},
+				{"v": StringSet("a")},
+			}
+			return nil
+		})
+
+		groups, err := builder(cli).GroupBy("v").Count(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, map[string]int64{
+			"S:1":        1,
+			"N:1":        1,
+			"S:":         1,
+			GroupMissing: 1,
+			"BOOL:true":  1,
+			"NULL:null":  1,
+			`L:["a"]`:    1,
+			`SS:<<"a">>`: 1,
+		}, groups)
+
+		distinct, err := builder(cli).Distinct(context.Background(), "v")
+		require.NoError(t, err)
+		require.Equal(t, []string{"BOOL:true", `L:["a"]`, "N:1", "NULL:null", "S:", "S:1", `SS:<<"a">>`}, distinct)
+	})
+
+	t.Run("should key binary values and sets by their content", func(t *testing.T) {
+		cli := stubClient(t, func(op *Operation) error {
+			op.Output.(*dynamodb.QueryOutput).Items = Maps{
+				{"v": {B: []byte{1, 2}}},
+				{"v": {B: []byte{3, 4}}},
+				{"v": {B: []byte{3, 4}}},
+				{"v": {BS: [][]byte{{3, 4}, {1, 2}}}},
+				{"v": {BS: [][]byte{{1, 2}, {3, 4}}}},
+				{"v": {BS: [][]byte{{5, 6}, {1, 2}}}},
+				{"v": StringSet("b", "a")},
+				{"v": StringSet("a", "b")},
+				{"v": {NS: aws.StringSlice([]string{"2", "1"})}},
+				{"v": {NS: aws.StringSlice([]string{"1", "2"})}},
+			}
+			return nil
+		})
+
+		groups, err := builder(cli).GroupBy("v").Count(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, map[string]int64{
+			"B:AQI=":            1,
+			"B:AwQ=":            2,
+			"BS:<<AQI=, AwQ=>>": 2,
+			"BS:<<AQI=, BQY=>>": 1,
+			`SS:<<"a", "b">>`:   2,
+			"NS:<<1, 2>>":       2,
+		}, groups)
+
+		distinct, err := builder(cli).Distinct(context.Background(), "v")
+		require.NoError(t, err)
+		require.Len(t, distinct, 6)
+	})
+
+	t.Run("should return ErrNoValues without numeric values", func(t *testing.T) {
+		cli := queryClient(t)
+		_, err := builder(cli).Max(context.Background(), "status")
+		require.ErrorIs(t, err, ErrNoValues)
+	})
+
+	t.Run("should scan segments in parallel", func(t *testing.T) {
+		cli := stubClient(t, func(op *Operation) error {
+			input := op.Input.(*dynamodb.ScanInput)
+			require.Equal(t, int64(2), aws.Int64Value(input.TotalSegments))
+			require.Equal(t, "#agg0", aws.StringValue(input.ProjectionExpression))
+			op.Output.(*dynamodb.ScanOutput).Items = pages[aws.Int64Value(input.Segment)]
+			return nil
+		})
+
+		sum, err := cli.Builder().Table("MyTable").Segments(2).Sum(context.Background(), "count")
+		require.NoError(t, err)
+		require.Equal(t, 0.0, sum)
+
+		groups, err := cli.Builder().Table("MyTable").Segments(2).GroupBy("status").Count(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, map[string]int64{"S:open": 2, "S:closed": 1, GroupMissing: 1}, groups)
+	})
+
+	t.Run("should reject invalid paths", func(t *testing.T) {
+		cli := queryClient(t)
+		for _, path := range []string{"", "a..b", "a[", "a[x]", "[0]", "a[0]b"} {
+			_, err := builder(cli).Sum(context.Background(), path)
+			require.ErrorIs(t, err, ErrInvalidPath, path)
+		}
+	})
+}
@@ -6,12 +6,17 @@ import (
 	"strconv"
 	"strings"
 	"text/scanner"
+	"unicode"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/pkg/errors"
 )
 
+// DefaultScanSegments is the amount of segments scanned in parallel by Builder.Count and the aggregations unless set via Builder.Segments
+const DefaultScanSegments = 4
+
 // Builder allows you to build dynamo queries in a more convenient fashion
 type Builder struct {
 	colsIdx             int
@@ -36,7 +41,14 @@ type Builder struct {
 	pageToken           Map
 	keyFn               KeyExtractor
 	primaryKeys         []string
+	customKeys          bool
+	indexKeys           []string
 	lastEvaluatedKey    Map
+	partitionKey        string
+	sortKey             string
+	untrackedKeys       bool
+	capacity            *CapacityReport
+	segments            int
 }
 
 // NewBuilder creates a new builder
@@ -62,18 +74,83 @@ func (s *Builder) Builder() *Builder {
 		b.Table(s.table)
 	}
 	b.WithPrimaryKeys(s.primaryKeys...)
+	b.customKeys = s.customKeys
 
 	return b
 }
 
-// WithPrimaryKeys allows you to set alternate primary keys
-// on default we expect PK,SK as primary keys
+// WithPrimaryKeys allows you to set alternate primary keys.
+// on default the keys are discovered from the table schema via the client,
+// falling back to PK,SK if schema discovery is disabled or DescribeTable is denied
 func (s *Builder) WithPrimaryKeys(primaryKeys ...string) *Builder {
 	s.primaryKeys = primaryKeys
 	s.keyFn = FieldsExtractor(primaryKeys...)
+	s.customKeys = true
 	return s
 }
 
+// WithIndexKeys allows you to set the keys of the index being queried.
+// this is only needed when schema discovery is disabled, index keys are used to build page tokens for indexes
+func (s *Builder) WithIndexKeys(indexKeys ...string) *Builder {
+	s.indexKeys = indexKeys
+	return s
+}
+
+// cursorKeys returns the keys needed to build a page token for the configured table and index
+func (s *Builder) cursorKeys(ctx context.Context) ([]string, error) {
+	keys, err := s.tableKeys(ctx)
+	if err != nil || s.index == "" {
+		return keys, err
+	}
+
+	return mergeKeys(keys, s.indexKeys), nil
+}
+
+// tableKeys returns the primary keys of the configured table.
+// the configured keys are used if the table schema can't be discovered
+func (s *Builder) tableKeys(ctx context.Context) ([]string, error) {
+	if s.customKeys || s.table == "" || s.client == nil {
+		return s.primaryKeys, nil
+	}
+
+	return s.client.schemaKeys(ctx, s.table, s.primaryKeys)
+}
+
+// ReturnConsumedCapacity requests consumed capacity for every operation made with the builder
+// (dynamodb.ReturnConsumedCapacityTotal or dynamodb.ReturnConsumedCapacityIndexes).
+// the capacity is accumulated into the report returned by CapacityReport
+func (s *Builder) ReturnConsumedCapacity(mode string) *Builder {
+	return s.update(func() {
+		s.capacity = NewCapacityReport(mode)
+	})
+}
+
+// CapacityReport returns the capacity consumed by operations made with the builder.
+// nil is returned unless ReturnConsumedCapacity was called
+func (s *Builder) CapacityReport() *CapacityReport {
+	return s.capacity
+}
+
+// capacityContext attaches the capacity report of the builder to the context
+func (s *Builder) capacityContext(ctx context.Context) context.Context {
+	if s.capacity == nil {
+		return ctx
+	}
+
+	return WithCapacityReport(ctx, s.capacity)
+}
+
+// start prepares the context of a terminal method and notifies the call hooks of the client.
+// the returned function must be deferred with the address of the error returned by the method
+func (s *Builder) start(ctx context.Context, method string) (context.Context, func(err *error)) {
+	ctx = s.capacityContext(ctx)
+	if s.client == nil {
+		return ctx, func(*error) {}
+	}
+
+	return s.client.startCall(ctx, Call{Method: method, Table: s.table, Index: s.index})
+}
+
 // PageToken returns token that can be used to fetch the next page of results
 func (s *Builder) PageToken() Map {
 	return s.lastEvaluatedKey
@@ -133,9 +210,21 @@ func (s *Builder) OrCondition(query string, vals ...interface{}) *Builder {
 
 // WhereKey allows you do make a key expression
 // e.g WhereKey("'MyKey' = ?", "yourKey")
-// note: calling this multiple times combines conditions with an AND
+// note: calling this multiple times combines conditions with an AND.
+// queries containing operators dynamodb doesn't support in key conditions (e.g OR, NOT, IN) are rejected.
+// the equality condition on the known partition key of the table or index sets the partition key
+// (if the keys aren't known it's only set if the query has a single equality condition),
+// any other condition is a sort key condition and is rejected if the partition key isn't set
+// or a sort key condition already exists
 func (s *Builder) WhereKey(query string, vals ...interface{}) *Builder {
 	return s.update(func() {
+		var conditions []keyCondition
+		if conditions, s.err = parseKeyCondition(query); s.err != nil {
+			return
+		}
+		if s.err = s.trackKeyConditions(conditions); s.err != nil {
+			return
+		}
 		s.addExpression(&s.keyExpression, "AND", query, vals...)
 	})
 }
@@ -306,7 +395,9 @@ func (s *Builder) ConsistentRead(consistent bool) *Builder {
 }
 
 // GetItem builds and runs a query using info in key and table
-func (s *Builder) GetItem(ctx context.Context) (*dynamodb.GetItemOutput, error) {
+func (s *Builder) GetItem(ctx context.Context) (output *dynamodb.GetItemOutput, err error) {
+	ctx, done := s.start(ctx, "GetItem")
+	defer done(&err)
 	if s.err != nil {
 		return nil, s.err
 	}
@@ -315,7 +406,7 @@ func (s *Builder) GetItem(ctx context.Context) (*dynamodb.GetItemOutput, error)
 	}
 
 	input, _ := s.ToGet()
-	output, err := s.client.GetItemWithContext(ctx, &input)
+	output, err = s.client.getItem(ctx, &input)
 
 	return output, s.parseResult(output.Item, err)
 }
@@ -356,7 +447,9 @@ func (s *Builder) parseResult(result interface{}, errs ...error) error {
 }
 
 // PutItem inserts the provided data and marshal maps it using the aws sdk
-func (s *Builder) PutItem(ctx context.Context, data interface{}) (*dynamodb.PutItemOutput, error) {
+func (s *Builder) PutItem(ctx context.Context, data interface{}) (output *dynamodb.PutItemOutput, err error) {
+	ctx, done := s.start(ctx, "PutItem")
+	defer done(&err)
 	if s.err != nil {
 		return nil, s.err
 	}
@@ -368,7 +461,18 @@ func (s *Builder) PutItem(ctx context.Context, data interface{}) (*dynamodb.PutI
 	if err != nil {
 		return nil, err
 	}
-	output, err := s.client.PutItemWithContext(ctx, &input)
+	if s.client.cache != nil {
+		keys, err := s.tableKeys(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer s.client.cache.invalidate(s.table, extractFields(input.Item, keys...))
+	}
+	input.ReturnConsumedCapacity = s.client.capacityMode(ctx, input.ReturnConsumedCapacity)
+	output, err = s.client.putItem(ctx, &input)
+	if err == nil {
+		recordWrites(ctx, output.ConsumedCapacity)
+	}
 	if s.returnVal != nil {
 		err = s.parseResult(output.Attributes, err)
 	}
@@ -402,7 +506,9 @@ func (s *Builder) Return(val string) *Builder {
 }
 
 // UpdateItem builds and runs an update query
-func (s *Builder) UpdateItem(ctx context.Context) (*dynamodb.UpdateItemOutput, error) {
+func (s *Builder) UpdateItem(ctx context.Context) (output *dynamodb.UpdateItemOutput, err error) {
+	ctx, done := s.start(ctx, "UpdateItem")
+	defer done(&err)
 	if s.err != nil {
 		return nil, s.err
 	}
@@ -411,7 +517,14 @@ func (s *Builder) UpdateItem(ctx context.Context) (*dynamodb.UpdateItemOutput, e
 	}
 
 	input, _ := s.ToUpdate()
-	output, err := s.client.UpdateItemWithContext(ctx, &input)
+	input.ReturnConsumedCapacity = s.client.capacityMode(ctx, input.ReturnConsumedCapacity)
+	output, err = s.client.updateItem(ctx, &input)
+	if err == nil {
+		recordWrites(ctx, output.ConsumedCapacity)
+	}
+	if s.client.cache != nil {
+		s.client.cache.invalidate(s.table, input.Key)
+	}
 	if s.returnVal != nil {
 		err = s.parseResult(output.Attributes, err)
 	}
@@ -420,7 +533,9 @@ func (s *Builder) UpdateItem(ctx context.Context) (*dynamodb.UpdateItemOutput, e
 }
 
 // DeleteItem deletes a single item utilizing data set via Table, Keys and Condition method calls
-func (s *Builder) DeleteItem(ctx context.Context) (*dynamodb.DeleteItemOutput, error) {
+func (s *Builder) DeleteItem(ctx context.Context) (output *dynamodb.DeleteItemOutput, err error) {
+	ctx, done := s.start(ctx, "DeleteItem")
+	defer done(&err)
 	input, err := s.ToDelete()
 	if err != nil {
 		return nil, err
@@ -430,7 +545,14 @@ func (s *Builder) DeleteItem(ctx context.Context) (*dynamodb.DeleteItemOutput, e
 		return nil, ErrClientNotSet
 	}
 
-	output, err := s.client.DeleteItemWithContext(ctx, &input)
+	input.ReturnConsumedCapacity = s.client.capacityMode(ctx, input.ReturnConsumedCapacity)
+	output, err = s.client.deleteItem(ctx, &input)
+	if err == nil {
+		recordWrites(ctx, output.ConsumedCapacity)
+	}
+	if s.client.cache != nil {
+		s.client.cache.invalidate(s.table, input.Key)
+	}
 	if s.returnVal != nil {
 		err = s.parseResult(output.Attributes, err)
 	}
@@ -440,7 +562,9 @@ func (s *Builder) DeleteItem(ctx context.Context) (*dynamodb.DeleteItemOutput, e
 
 // QueryIterate allows you to query dynamo based on the built object.
 // the fn parameter will be called as often as needed to retrieve all results
-func (s *Builder) QueryIterate(ctx context.Context, fn func(output *dynamodb.QueryOutput) error) error {
+func (s *Builder) QueryIterate(ctx context.Context, fn func(output *dynamodb.QueryOutput) error) (err error) {
+	ctx, done := s.start(ctx, "QueryIterate")
+	defer done(&err)
 	if s.err != nil {
 		return s.err
 	}
@@ -451,12 +575,18 @@ func (s *Builder) QueryIterate(ctx context.Context, fn func(output *dynamodb.Que
 	if err != nil {
 		return err
 	}
+	keys, err := s.cursorKeys(ctx)
+	if err != nil {
+		return err
+	}
 
-	return s.client.QueryIteratorV2(ctx, &query, s.primaryKeys, fn)
+	return s.client.QueryIteratorV2(ctx, &query, keys, fn)
 }
 
 // QueryAll returns an all results matching the built query
-func (s *Builder) QueryAll(ctx context.Context) ([]map[string]*dynamodb.AttributeValue, error) {
+func (s *Builder) QueryAll(ctx context.Context) (_ []map[string]*dynamodb.AttributeValue, err error) {
+	ctx, done := s.start(ctx, "QueryAll")
+	defer done(&err)
 	if s.err != nil {
 		return nil, s.err
 	}
@@ -464,10 +594,14 @@ func (s *Builder) QueryAll(ctx context.Context) ([]map[string]*dynamodb.Attribut
 		return nil, ErrClientNotSet
 	}
 	query, _ := s.ToQuery()
+	keys, err := s.cursorKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	s.lastEvaluatedKey = nil
 	var results Maps
-	err := s.client.QueryIteratorV2(ctx, &query, s.primaryKeys, func(output *dynamodb.QueryOutput) error {
+	err = s.client.QueryIteratorV2(ctx, &query, keys, func(output *dynamodb.QueryOutput) error {
 		results = append(results, output.Items...)
 		s.lastEvaluatedKey = output.LastEvaluatedKey
 
@@ -478,7 +612,9 @@ func (s *Builder) QueryAll(ctx context.Context) ([]map[string]*dynamodb.Attribut
 }
 
 // QuerySingle returns a single result matching the built query
-func (s *Builder) QuerySingle(ctx context.Context) (map[string]*dynamodb.AttributeValue, error) {
+func (s *Builder) QuerySingle(ctx context.Context) (_ map[string]*dynamodb.AttributeValue, err error) {
+	ctx, done := s.start(ctx, "QuerySingle")
+	defer done(&err)
 	if s.err != nil {
 		return nil, s.err
 	}
@@ -487,10 +623,14 @@ func (s *Builder) QuerySingle(ctx context.Context) (map[string]*dynamodb.Attribu
 	}
 	query, _ := s.ToQuery()
 	query.Limit = aws.Int64(1)
+	keys, err := s.cursorKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	s.lastEvaluatedKey = nil
 	var result Map
-	err := s.client.QueryIteratorV2(ctx, &query, s.primaryKeys, func(output *dynamodb.QueryOutput) error {
+	err = s.client.QueryIteratorV2(ctx, &query, keys, func(output *dynamodb.QueryOutput) error {
 		if len(output.Items) > 0 {
 			result = output.Items[0]
 		}
@@ -502,14 +642,23 @@ func (s *Builder) QuerySingle(ctx context.Context) (map[string]*dynamodb.Attribu
 }
 
 // ScanAll returns all results matching the scan
-func (s *Builder) ScanAll(ctx context.Context) (Maps, error) {
+func (s *Builder) ScanAll(ctx context.Context) (_ Maps, err error) {
+	ctx, done := s.start(ctx, "ScanAll")
+	defer done(&err)
 	if s.err != nil {
 		return nil, s.err
 	}
+	if s.client == nil {
+		return nil, ErrClientNotSet
+	}
 	query, _ := s.ToScan()
+	keys, err := s.cursorKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
 	s.lastEvaluatedKey = nil
 	var results Maps
-	err := s.client.ScanIteratorV2(ctx, &query, s.primaryKeys, func(output *dynamodb.ScanOutput) error {
+	err = s.client.ScanIteratorV2(ctx, &query, keys, func(output *dynamodb.ScanOutput) error {
 		results = append(results, output.Items...)
 		s.lastEvaluatedKey = output.LastEvaluatedKey
 
@@ -521,7 +670,9 @@ func (s *Builder) ScanAll(ctx context.Context) (Maps, error) {
 
 // ScanIterate allows you to query dynamo based on the built object.
 // the fn parameter will be called as often as needed to retrieve all results
-func (s *Builder) ScanIterate(ctx context.Context, fn func(output *dynamodb.ScanOutput) error) error {
+func (s *Builder) ScanIterate(ctx context.Context, fn func(output *dynamodb.ScanOutput) error) (err error) {
+	ctx, done := s.start(ctx, "ScanIterate")
+	defer done(&err)
 	if s.err != nil {
 		return s.err
 	}
@@ -530,13 +681,19 @@ func (s *Builder) ScanIterate(ctx context.Context, fn func(output *dynamodb.Scan
 	}
 
 	query, _ := s.ToScan()
+	keys, err := s.cursorKeys(ctx)
+	if err != nil {
+		return err
+	}
 
-	return s.client.ScanIteratorV2(ctx, &query, s.primaryKeys, fn)
+	return s.client.ScanIteratorV2(ctx, &query, keys, fn)
 }
 
 // ParallelScanIterate allows you to do a parallel scan in dynamo based on the built object.
 // the fn parameter will be called as often as needed to retrieve all results
-func (s *Builder) ParallelScanIterate(ctx context.Context, workers int, fn func(output *dynamodb.ScanOutput) error, unsafe bool) error {
+func (s *Builder) ParallelScanIterate(ctx context.Context, workers int, fn func(output *dynamodb.ScanOutput) error, unsafe bool) (err error) {
+	ctx, done := s.start(ctx, "ParallelScanIterate")
+	defer done(&err)
 	if s.err != nil {
 		return s.err
 	}
@@ -550,7 +707,9 @@ func (s *Builder) ParallelScanIterate(ctx context.Context, workers int, fn func(
 }
 
 // QueryDelete deletes all records matching the query.
-func (s *Builder) QueryDelete(ctx context.Context) error {
+func (s *Builder) QueryDelete(ctx context.Context) (err error) {
+	ctx, done := s.start(ctx, "QueryDelete")
+	defer done(&err)
 	if s.err != nil {
 		return s.err
 	}
@@ -559,13 +718,41 @@ func (s *Builder) QueryDelete(ctx context.Context) error {
 	}
 
 	query, _ := s.ToQuery()
+	keys, err := s.tableKeys(ctx)
+	if err != nil {
+		return err
+	}
 
-	return s.client.QueryDeleter(ctx, s.table, &query, s.primaryKeys)
+	return s.client.QueryDeleter(ctx, s.table, &query, keys)
+}
+
+// Count counts the items matching the built query without retrieving them.
+// a scan split into parallel segments is counted if no key condition was set. the limit is ignored
+func (s *Builder) Count(ctx context.Context) (_ CountResult, err error) {
+	ctx, done := s.start(ctx, "Count")
+	defer done(&err)
+	if s.err != nil {
+		return CountResult{}, s.err
+	}
+	if s.client == nil {
+		return CountResult{}, ErrClientNotSet
+	}
+
+	if s.keyExpression != "" {
+		query, _ := s.ToQuery()
+		return s.client.QueryCount(ctx, &query)
+	}
+
+	scan, _ := s.ToScan()
+
+	return s.client.ParallelScanCount(ctx, &scan, s.scanSegments())
 }
 
 // ScanDelete deletes all records matching the scan.
-// note: you must provide a function that will select the relevant Key fields needed for deletion
-func (s *Builder) ScanDelete(ctx context.Context) error {
+// note: the table keys are discovered via the client unless set via WithPrimaryKeys
+func (s *Builder) ScanDelete(ctx context.Context) (err error) {
+	ctx, done := s.start(ctx, "ScanDelete")
+	defer done(&err)
 	if s.err != nil {
 		return s.err
 	}
@@ -574,8 +761,12 @@ func (s *Builder) ScanDelete(ctx context.Context) error {
 	}
 
 	query, _ := s.ToScan()
+	keys, err := s.tableKeys(ctx)
+	if err != nil {
+		return err
+	}
 
-	return s.client.ScanDeleter(ctx, s.table, &query, s.primaryKeys)
+	return s.client.ScanDeleter(ctx, s.table, &query, keys)
 }
 
 // ToDelete produces a dynamodb.DeleteItemInput value based on configured builder
@@ -608,6 +799,10 @@ func (s *Builder) ToDelete() (dynamodb.DeleteItemInput, error) {
 		request.ReturnValues = s.returnVal
 	}
 
+	if s.capacity != nil {
+		request.ReturnConsumedCapacity = aws.String(s.capacity.Mode())
+	}
+
 	return request, nil
 }
 
@@ -663,6 +858,10 @@ func (s *Builder) ToQuery() (dynamodb.QueryInput, error) {
 		query.ExclusiveStartKey = s.pageToken
 	}
 
+	if s.capacity != nil {
+		query.ReturnConsumedCapacity = aws.String(s.capacity.Mode())
+	}
+
 	return query, nil
 }
 
@@ -710,6 +909,10 @@ func (s *Builder) ToScan() (dynamodb.ScanInput, error) {
 		query.ExclusiveStartKey = s.pageToken
 	}
 
+	if s.capacity != nil {
+		query.ReturnConsumedCapacity = aws.String(s.capacity.Mode())
+	}
+
 	return query, nil
 }
 
@@ -733,6 +936,10 @@ func (s *Builder) ToGet() (dynamodb.GetItemInput, error) {
 		query.ConsistentRead = s.consistent
 	}
 
+	if s.capacity != nil {
+		query.ReturnConsumedCapacity = aws.String(s.capacity.Mode())
+	}
+
 	return query, nil
 }
 
@@ -770,10 +977,15 @@ func (s *Builder) ToUpdate() (dynamodb.UpdateItemInput, error) {
 		query.ReturnValues = s.returnVal
 	}
 
+	if s.capacity != nil {
+		query.ReturnConsumedCapacity = aws.String(s.capacity.Mode())
+	}
+
 	return query, nil
 }
 
-// ToPut produces a dynamodb.PutItemInput value based on configured builder
+// ToPut produces a dynamodb.PutItemInput value based on configured builder.
+// items of type Map are used as is, anything else is marshaled via dynamodbattribute.MarshalMap
 func (s *Builder) ToPut(item interface{}) (dynamodb.PutItemInput, error) {
 	if s.err != nil {
 		return dynamodb.PutItemInput{}, s.err
@@ -799,6 +1011,15 @@ func (s *Builder) ToPut(item interface{}) (dynamodb.PutItemInput, error) {
 		query.ReturnValues = s.returnVal
 	}
 
+	if s.capacity != nil {
+		query.ReturnConsumedCapacity = aws.String(s.capacity.Mode())
+	}
+
+	if m, ok := item.(Map); ok {
+		query.Item = m
+		return query, nil
+	}
+
 	var err error
 	query.Item, err = dynamodbattribute.MarshalMap(item)
 
@@ -823,6 +1044,22 @@ func (s *Builder) Limit(limit int) *Builder {
 	return s
 }
 
+// Segments sets the amount of segments scanned in parallel by Count and the aggregations such as Sum.
+// defaults to DefaultScanSegments
+func (s *Builder) Segments(segments int) *Builder {
+	return s.update(func() {
+		s.segments = segments
+	})
+}
+
+func (s *Builder) scanSegments() int {
+	if s.segments > 0 {
+		return s.segments
+	}
+
+	return DefaultScanSegments
+}
+
 // Index sets the index to use
 func (s *Builder) Index(index string) *Builder {
 	if s.err != nil {
@@ -832,54 +1069,101 @@ func (s *Builder) Index(index string) *Builder {
 	return s
 }
 
+// CountPlaceholders returns the amount of ? placeholders in query, which is the amount of positional inputs
+// Builder methods such as Where or Update expect for it. question marks inside quoted names such as 'a?b' aren't placeholders
+func CountPlaceholders(query string) int {
+	sc := newExpressionScanner(query)
+	count := 0
+	for tok := sc.Scan(); tok != scanner.EOF; tok = sc.Scan() {
+		if tok == '?' {
+			count++
+		}
+	}
+
+	return count
+}
+
+// newExpressionScanner returns a scanner tokenizing query the way Builder expressions are parsed
+func newExpressionScanner(query string) *scanner.Scanner {
+	var sc scanner.Scanner
+	sc.Init(strings.NewReader(query))
+	sc.Whitespace = 0
+	sc.Error = func(s *scanner.Scanner, msg string) {
+	}
+
+	return &sc
+}
+
 // scan takes an input and produces a parsed version with relevant colNames and values set on the builder object
 // e.g scan("'myField' = ?", 1.0)
 // produces -> "#1 = :1"
 // and sets #1 and :1 appropriately
+//
+// unquoted identifiers that are reserved words or contain characters dynamodb doesn't allow
+// are aliased automatically, function names such as attribute_exists are left untouched.
+// if the last input is of type Params, named placeholders (e.g :status) are resolved from it.
+// each name maps to a single value placeholder no matter how many times it is referenced
 func (s *Builder) scan(query string, inputs ...interface{}) (updatedQuery string, err error) {
 	var builder strings.Builder
 	builder.Grow(len(query))
-	var sc scanner.Scanner
+	sc := newExpressionScanner(query)
 
-	sc.Init(strings.NewReader(query))
-	sc.Whitespace = 0
-	sc.Error = func(s *scanner.Scanner, msg string) {
+	var params Params
+	if total := len(inputs); total > 0 {
+		if p, ok := inputs[total-1].(Params); ok {
+			params = p
+			inputs = inputs[:total-1]
+		}
 	}
+	named := make(map[string]string)
+	position := 0
+	var prev rune
 
-	start := s.valColsIdx
-
-	for tok := sc.Scan(); tok != scanner.EOF; tok = sc.Scan() {
+	for tok := sc.Scan(); tok != scanner.EOF; prev, tok = tok, sc.Scan() {
 		val := sc.TokenText()
 		switch tok {
+		case scanner.Ident:
+			if prev == '#' || prev == ':' || !needsAlias(val, nextNonSpace(query[sc.Pos().Offset:])) {
+				builder.WriteString(val)
+				continue
+			}
+			col := s.nextCol()
+			s.cols[col] = &val
+			builder.WriteString(col)
 		case -5:
-			s.colsIdx++
-			var c strings.Builder
-			num := strconv.Itoa(s.colsIdx)
-			c.Grow(1 + len(num))
-			c.WriteRune('#')
-			c.WriteString(num)
-
-			col := c.String()
+			col := s.nextCol()
 			value := strings.Trim(val, `'`)
 			s.cols[col] = &value
 			builder.WriteString(col)
 		case '?':
-			s.valColsIdx++
-			if len(inputs) <= (s.valColsIdx - start - 1) {
+			if len(inputs) <= position {
 				return "", ErrQueryMisMatch
 			}
-			var c strings.Builder
-			num := strconv.Itoa(s.valColsIdx)
-			c.Grow(1 + len(num))
-			c.WriteRune(':')
-			c.WriteString(num)
-
-			col := c.String()
-			attr, err := typeToAttributeVal(inputs[s.valColsIdx-start-1])
+			col, err := s.addVal(inputs[position])
 			if err != nil {
 				return "", err
 			}
-			s.vals[col] = attr
+			position++
+			builder.WriteString(col)
+		case ':':
+			if params == nil || !isIdentStart(sc.Peek()) {
+				builder.WriteString(val)
+				continue
+			}
+			sc.Scan()
+			name := sc.TokenText()
+			col, found := named[name]
+			if !found {
+				input, ok := params[name]
+				if !ok {
+					return "", ErrMissingParam
+				}
+				col, err = s.addVal(input)
+				if err != nil {
+					return "", err
+				}
+				named[name] = col
+			}
 			builder.WriteString(col)
 		default:
 			builder.WriteString(sc.TokenText())
@@ -889,16 +1173,81 @@ func (s *Builder) scan(query string, inputs ...interface{}) (updatedQuery string
 	return builder.String(), nil
 }
 
+// nextCol reserves the next expression attribute name placeholder
+func (s *Builder) nextCol() string {
+	s.colsIdx++
+	var c strings.Builder
+	num := strconv.Itoa(s.colsIdx)
+	c.Grow(1 + len(num))
+	c.WriteRune('#')
+	c.WriteString(num)
+
+	return c.String()
+}
+
+// addVal converts the input to an attribute value and stores it under the next value placeholder
+func (s *Builder) addVal(input interface{}) (string, error) {
+	attr, err := typeToAttributeVal(input)
+	if err != nil {
+		return "", err
+	}
+
+	s.valColsIdx++
+	var c strings.Builder
+	num := strconv.Itoa(s.valColsIdx)
+	c.Grow(1 + len(num))
+	c.WriteRune(':')
+	c.WriteString(num)
+
+	col := c.String()
+	s.vals[col] = attr
+
+	return col, nil
+}
+
+func isIdentStart(ch rune) bool {
+	return ch == '_' || unicode.IsLetter(ch)
+}
+
+// typeToAttributeVal converts the provided value to an attribute value.
+// common types are converted directly and everything else is marshaled via the aws sdk
+// (including dynamodbattribute.Marshaler implementations). slices other than []string and [][]byte become lists,
+// use IntSet or FloatSet to bind a number set
 func typeToAttributeVal(raw interface{}) (*dynamodb.AttributeValue, error) {
 	switch v := raw.(type) {
+	case dynamodb.AttributeValue:
+		return &v, nil
+	case *dynamodb.AttributeValue:
+		return v, nil
+	case dynamodbattribute.Marshaler:
+		return marshalAttributeVal(v)
 	case string:
 		return &dynamodb.AttributeValue{S: aws.String(v)}, nil
 	case []string:
 		return &dynamodb.AttributeValue{SS: aws.StringSlice(v)}, nil
 	case int:
-		return &dynamodb.AttributeValue{N: aws.String(strconv.Itoa(v))}, nil
+		return intAttributeVal(int64(v)), nil
+	case int8:
+		return intAttributeVal(int64(v)), nil
+	case int16:
+		return intAttributeVal(int64(v)), nil
+	case int32:
+		return intAttributeVal(int64(v)), nil
 	case int64:
-		return typeToAttributeVal(int(v))
+		return intAttributeVal(v), nil
+	case uint:
+		return uintAttributeVal(uint64(v)), nil
+	case uint8:
+		return uintAttributeVal(uint64(v)), nil
+	case uint16:
+		return uintAttributeVal(uint64(v)), nil
+	case uint32:
+		return uintAttributeVal(uint64(v)), nil
+	case uint64:
+		return uintAttributeVal(v), nil
+	case float32:
+		return &dynamodb.AttributeValue{N: aws.String(
+			strconv.FormatFloat(float64(v), 'f', -1, 32))}, nil
 	case float64:
 		return &dynamodb.AttributeValue{N: aws.String(
 			strconv.FormatFloat(v, 'f', -1, 64))}, nil
@@ -908,13 +1257,30 @@ func typeToAttributeVal(raw interface{}) (*dynamodb.AttributeValue, error) {
 		return &dynamodb.AttributeValue{BS: v}, nil
 	case bool:
 		return &dynamodb.AttributeValue{BOOL: aws.Bool(v)}, nil
-	case dynamodb.AttributeValue:
-		return &v, nil
-	case *dynamodb.AttributeValue:
-		return v, nil
 	}
 
-	return nil, ErrUnsupportedType
+	return marshalAttributeVal(raw)
+}
+
+func intAttributeVal(v int64) *dynamodb.AttributeValue {
+	return &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(v, 10))}
+}
+
+func uintAttributeVal(v uint64) *dynamodb.AttributeValue {
+	return &dynamodb.AttributeValue{N: aws.String(strconv.FormatUint(v, 10))}
+}
+
+func marshalAttributeVal(raw interface{}) (*dynamodb.AttributeValue, error) {
+	result, err := dynamodbattribute.Marshal(raw)
+	if err != nil {
+		return nil, errors.Wrap(ErrUnsupportedType, err.Error())
+	}
+	// the sdk silently produces an empty attribute value for types it can't handle (e.g channels and functions)
+	if reflect.DeepEqual(*result, dynamodb.AttributeValue{}) {
+		return nil, ErrUnsupportedType
+	}
+
+	return result, nil
 }
 
 func sliceToValues(slice interface{}) []interface{} {
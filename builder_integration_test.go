@@ -1,7 +1,7 @@
 //go:build integration
 // +build integration
 
-package dyc
+package dyc_test
 
 import (
 	"context"
@@ -11,6 +11,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/darwayne/dyc"
 	"github.com/darwayne/dyc/internal/testing/dynamotest"
 )
 
@@ -258,6 +259,82 @@ func TestBuilder(t *testing.T) {
 		})
 	})
 
+	t.Run("KeyConditions", func(t *testing.T) {
+		t.Run("happy path", func(t *testing.T) {
+			builder := setupBuilder(t)
+			const totalRows = 10
+			expecations := make([]Row, totalRows)
+			for i := 0; i < totalRows; i++ {
+				row := genericRow()
+				row.SK += fmt.Sprintf("%d", i)
+				expecations[i] = row
+
+				_, err := builder.PutItem(defaultCtx(), expecations[i])
+				require.NoError(t, err)
+			}
+
+			var result []Row
+			_, err := builder.Builder().
+				PartitionKey("PK", expecations[0].PK).
+				SortKeyBetween("SK", expecations[2].SK, expecations[4].SK).
+				Result(&result).
+				QueryAll(defaultCtx())
+
+			require.NoError(t, err)
+			require.Equal(t, expecations[2:5], result)
+		})
+	})
+
+	t.Run("Index pagination", func(t *testing.T) {
+		t.Run("cursor and limit should behave as expected", func(t *testing.T) {
+			type IndexRow struct {
+				PK     string
+				SK     string
+				GSI1PK string
+				GSI1SK string
+			}
+
+			builder := setupBuilder(t)
+			const totalRows = 10
+			expecations := make([]IndexRow, totalRows)
+			for i := 0; i < totalRows; i++ {
+				expecations[i] = IndexRow{
+					PK:     fmt.Sprintf("ROW%d", i),
+					SK:     "ROW",
+					GSI1PK: "INDEXED",
+					GSI1SK: fmt.Sprintf("%d", i),
+				}
+
+				_, err := builder.PutItem(defaultCtx(), expecations[i])
+				require.NoError(t, err)
+			}
+
+			var result []IndexRow
+			b := builder.Builder()
+			_, err := b.Index("GSI1").
+				PartitionKey("GSI1PK", "INDEXED").
+				Result(&result).
+				Limit(5).
+				QueryAll(defaultCtx())
+
+			require.NoError(t, err)
+			require.Equal(t, expecations[:5], result)
+			require.Len(t, b.PageToken(), 4)
+
+			var result2 []IndexRow
+			c := builder.Builder()
+			_, err = c.Index("GSI1").
+				PartitionKey("GSI1PK", "INDEXED").
+				Result(&result2).
+				Cursor(b.PageToken()).
+				Limit(5).
+				QueryAll(defaultCtx())
+
+			require.NoError(t, err)
+			require.Equal(t, expecations[5:], result2)
+		})
+	})
+
 	t.Run("ScanAll", func(t *testing.T) {
 		t.Run("happy path", func(t *testing.T) {
 			builder := setupBuilder(t)
@@ -355,6 +432,53 @@ func TestBuilder(t *testing.T) {
 		})
 	})
 
+	t.Run("Count", func(t *testing.T) {
+		t.Run("happy path", func(t *testing.T) {
+			builder := setupBuilder(t)
+			for i := 0; i < 5; i++ {
+				_, err := builder.Builder().PutItem(defaultCtx(), Row{PK: "count", SK: fmt.Sprint(i)})
+				require.NoError(t, err)
+			}
+			_, err := builder.Builder().PutItem(defaultCtx(), Row{PK: "other", SK: "0"})
+			require.NoError(t, err)
+
+			result, err := builder.Builder().WhereKey("PK = ?", "count").Where("SK > ?", "1").Count(defaultCtx())
+			require.NoError(t, err)
+			require.Equal(t, dyc.CountResult{Count: 3, ScannedCount: 5}, result)
+
+			result, err = builder.Builder().Where("PK = ?", "count").SelectFields("SK").Segments(3).Count(defaultCtx())
+			require.NoError(t, err)
+			require.Equal(t, dyc.CountResult{Count: 5, ScannedCount: 6}, result)
+		})
+	})
+
+	t.Run("Aggregations", func(t *testing.T) {
+		t.Run("happy path", func(t *testing.T) {
+			builder := setupBuilder(t)
+			for i := 0; i < 4; i++ {
+				_, err := builder.Builder().PutItem(defaultCtx(), map[string]interface{}{
+					"PK":    "agg",
+					"SK":    fmt.Sprint(i),
+					"stats": map[string]interface{}{"views": i * 10},
+					"data":  fmt.Sprint(i % 2),
+				})
+				require.NoError(t, err)
+			}
+
+			sum, err := builder.Builder().WhereKey("PK = ?", "agg").Sum(defaultCtx(), "stats.views")
+			require.NoError(t, err)
+			require.Equal(t, 60.0, sum)
+
+			max, err := builder.Builder().Where("PK = ?", "agg").Segments(3).Max(defaultCtx(), "stats.views")
+			require.NoError(t, err)
+			require.Equal(t, 30.0, max)
+
+			groups, err := builder.Builder().WhereKey("PK = ?", "agg").GroupBy("data").Count(defaultCtx())
+			require.NoError(t, err)
+			require.Equal(t, map[string]int64{"0": 2, "1": 2}, groups)
+		})
+	})
+
 	t.Run("UpdateItem", func(t *testing.T) {
 		t.Run("happy path", func(t *testing.T) {
 			builder := setupBuilder(t)
@@ -463,10 +587,10 @@ func defaultCtx() context.Context {
 	return ctx
 }
 
-func setupBuilder(t *testing.T) *Builder {
+func setupBuilder(t *testing.T) *dyc.Builder {
 	t.Helper()
 	t.Parallel()
 	table, db := dynamotest.SetupTestTable(context.Background(), t, "builder", dynamotest.DefaultSchema())
 
-	return NewClient(db).Builder().Table(table)
+	return dyc.NewClient(db).Builder().Table(table)
 }
@@ -3,9 +3,13 @@
 package dyc
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -144,3 +148,327 @@ func TestBuilder_ToQuery(t *testing.T) {
 		})
 	})
 }
+
+func TestBuilder_NamedParams(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		b := NewBuilder()
+		b.Where(`'status' = :status AND assignee = :owner OR 'prev'.'status' = :status`, Params{
+			"status": "open",
+			"owner":  7,
+		})
+
+		require.Empty(t, b.err)
+		assert.Equal(t, "(#1 = :0 AND assignee = :1 OR #2.#3 = :0)", b.filterExpresion)
+		require.Len(t, b.vals, 2)
+
+		require.NotPanics(t, func() {
+			require.Equal(t, "open", *b.vals[":0"].S)
+			require.Equal(t, "7", *b.vals[":1"].N)
+		})
+	})
+
+	t.Run("should mix with positional inputs", func(t *testing.T) {
+		b := NewBuilder()
+		b.WhereKey(`PK = ? AND SK BETWEEN :start AND :end`, "key", Params{"start": 1, "end": 10})
+
+		require.Empty(t, b.err)
+		assert.Equal(t, "(PK = :0 AND SK BETWEEN :1 AND :2)", b.keyExpression)
+		require.Len(t, b.vals, 3)
+	})
+
+	t.Run("should leave placeholders alone without params", func(t *testing.T) {
+		b := NewBuilder()
+		b.Where(`PK = :pk`)
+
+		require.Empty(t, b.err)
+		assert.Equal(t, "(PK = :pk)", b.filterExpresion)
+	})
+
+	t.Run("with errors", func(t *testing.T) {
+		t.Run("should error on missing param", func(t *testing.T) {
+			b := NewBuilder()
+			b.Where(`'status' = :status`, Params{"owner": 1})
+
+			require.Equal(t, ErrMissingParam, b.err)
+		})
+	})
+}
+
+func TestBuilder_ReservedWords(t *testing.T) {
+	t.Run("should alias reserved words", func(t *testing.T) {
+		b := NewBuilder()
+		b.Where(`status = ? AND DAT.name = ? AND size(data) > ?`, "open", "yo", 3)
+
+		require.Empty(t, b.err)
+		assert.Equal(t, "(#1 = :0 AND DAT.#2 = :1 AND size(#3) > :2)", b.filterExpresion)
+
+		require.NotPanics(t, func() {
+			assert.Equal(t, "status", *b.cols["#1"])
+			assert.Equal(t, "name", *b.cols["#2"])
+			assert.Equal(t, "data", *b.cols["#3"])
+		})
+	})
+
+	t.Run("should leave functions and keywords untouched", func(t *testing.T) {
+		b := NewBuilder()
+		b.Condition(`attribute_not_exists(PK) OR NOT begins_with(SK, ?)`, "yo")
+
+		require.Empty(t, b.err)
+		assert.Equal(t, "(attribute_not_exists(PK) OR NOT begins_with(SK, :0))", b.conditionExpression)
+		require.Empty(t, b.cols)
+	})
+
+	t.Run("should leave functions followed by whitespace untouched", func(t *testing.T) {
+		b := NewBuilder()
+		b.Where("size (tags) > ? AND attribute_exists\t(data)", 1)
+
+		require.Empty(t, b.err)
+		assert.Equal(t, "(size (tags) > :0 AND attribute_exists\t(#1))", b.filterExpresion)
+		require.NotPanics(t, func() {
+			assert.Equal(t, "data", *b.cols["#1"])
+		})
+
+		b = NewBuilder()
+		b.Where("size > ?", 1)
+		assert.Equal(t, "(#1 > :0)", b.filterExpresion)
+	})
+
+	t.Run("should alias names with characters requiring aliasing", func(t *testing.T) {
+		b := NewBuilder()
+		b.Update(`SET _hidden = ?, prénom = ?`, 1, "yo")
+
+		require.Empty(t, b.err)
+		assert.Equal(t, "SET #1 = :0, #2 = :1", b.updateExpression)
+	})
+
+	t.Run("should not alias raw placeholders", func(t *testing.T) {
+		b := NewBuilder()
+		b.Where(`#status = :status`)
+
+		require.Empty(t, b.err)
+		assert.Equal(t, "(#status = :status)", b.filterExpresion)
+		require.Empty(t, b.cols)
+	})
+}
+
+type marshalerStub struct{}
+
+func (marshalerStub) MarshalDynamoDBAttributeValue(av *dynamodb.AttributeValue) error {
+	av.S = aws.String("custom")
+	return nil
+}
+
+func TestTypeToAttributeVal(t *testing.T) {
+	type nested struct {
+		Name string
+	}
+	type status string
+
+	t.Run("happy path", func(t *testing.T) {
+		ts := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		tests := []struct {
+			name     string
+			input    interface{}
+			expected *dynamodb.AttributeValue
+		}{
+			{"int32", int32(-5), &dynamodb.AttributeValue{N: aws.String("-5")}},
+			{"uint", uint(5), &dynamodb.AttributeValue{N: aws.String("5")}},
+			{"float32", float32(1.5), &dynamodb.AttributeValue{N: aws.String("1.5")}},
+			{"named string", status("open"), &dynamodb.AttributeValue{S: aws.String("open")}},
+			{"time", ts, &dynamodb.AttributeValue{S: aws.String("2020-01-02T03:04:05Z")}},
+			{"marshaler", marshalerStub{}, &dynamodb.AttributeValue{S: aws.String("custom")}},
+			{"numbers keep duplicates and order", []int{3, 1, 1}, &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{
+				{N: aws.String("3")}, {N: aws.String("1")}, {N: aws.String("1")},
+			}}},
+			{"floats", []float64{1.25}, &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{{N: aws.String("1.25")}}}},
+			{"number set", IntSet(3, 1), &dynamodb.AttributeValue{NS: aws.StringSlice([]string{"3", "1"})}},
+			{"float set", FloatSet(1.25), &dynamodb.AttributeValue{NS: aws.StringSlice([]string{"1.25"})}},
+			{"list", []interface{}{"a", 1}, &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{
+				{S: aws.String("a")}, {N: aws.String("1")},
+			}}},
+			{"map", map[string]int{"a": 1}, &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{
+				"a": {N: aws.String("1")},
+			}}},
+			{"struct", nested{Name: "yo"}, &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{
+				"Name": {S: aws.String("yo")},
+			}}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				result, err := typeToAttributeVal(tt.input)
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, result)
+			})
+		}
+	})
+
+	t.Run("with errors", func(t *testing.T) {
+		t.Run("should error on types the sdk can't marshal", func(t *testing.T) {
+			_, err := typeToAttributeVal(make(chan int))
+			require.True(t, errors.Is(err, ErrUnsupportedType))
+		})
+	})
+}
+
+func TestBuilder_KeyConditions(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		b := NewBuilder().
+			PartitionKey("PK", "user#1").
+			SortKeyBetween("SK", 1, 10)
+
+		require.Empty(t, b.err)
+		assert.Equal(t, "(#1 = :0) AND (#2 BETWEEN :1 AND :2)", b.keyExpression)
+
+		require.NotPanics(t, func() {
+			assert.Equal(t, "PK", *b.cols["#1"])
+			assert.Equal(t, "SK", *b.cols["#2"])
+			assert.Equal(t, "user#1", *b.vals[":0"].S)
+		})
+	})
+
+	t.Run("begins with", func(t *testing.T) {
+		b := NewBuilder().
+			PartitionKey("PK", "user#1").
+			SortKeyBeginsWith("SK", "order#")
+
+		require.Empty(t, b.err)
+		assert.Equal(t, "(#1 = :0) AND (begins_with(#2, :1))", b.keyExpression)
+	})
+
+	t.Run("should track keys set via WhereKey", func(t *testing.T) {
+		b := NewBuilder().WhereKey("SK > ? AND PK = ?", 1, "user#1")
+		require.Empty(t, b.err)
+		assert.Equal(t, "PK", b.partitionKey)
+		assert.Equal(t, "SK", b.sortKey)
+
+		b = NewBuilder().PartitionKey("PK", "user#1").WhereKey("'SK' BETWEEN ? AND ?", 1, 2)
+		require.Empty(t, b.err)
+		assert.Equal(t, "SK", b.sortKey)
+	})
+
+	t.Run("should use known keys to find the partition key of WhereKey", func(t *testing.T) {
+		b := NewBuilder().WithPrimaryKeys("Tenant", "ID").WhereKey("ID = ? AND Tenant = ?", 1, "a")
+		require.Empty(t, b.err)
+		assert.Equal(t, "Tenant", b.partitionKey)
+		assert.Equal(t, "ID", b.sortKey)
+
+		b = NewBuilder().Index("GSI1").WithIndexKeys("GSI1PK", "GSI1SK").WhereKey("GSI1SK = ? AND GSI1PK = ?", 1, "a")
+		require.Empty(t, b.err)
+		assert.Equal(t, "GSI1PK", b.partitionKey)
+		assert.Equal(t, "GSI1SK", b.sortKey)
+
+		cli := NewClient(nil)
+		_, err := cli.schemas.get(context.Background(), "MyTable", func(ctx context.Context) (*TableSchema, error) {
+			return &TableSchema{Name: "MyTable", Keys: []string{"PK", "SK"}, Indexes: map[string][]string{"GSI1": {"GSI1PK", "GSI1SK"}}}, nil
+		})
+		require.NoError(t, err)
+		b = cli.Builder().Table("MyTable").WhereKey("GSI1SK = ? AND GSI1PK = ?", 1, "a").Index("GSI1")
+		require.Empty(t, b.err)
+		assert.Equal(t, "GSI1PK", b.partitionKey)
+		assert.Equal(t, "GSI1SK", b.sortKey)
+	})
+
+	t.Run("should not track ambiguous WhereKey keys", func(t *testing.T) {
+		b := NewBuilder().WhereKey("SK = ? AND PK = ?", 1, "a").WhereKey("'Other' > ?", 2)
+		require.Empty(t, b.err)
+		assert.Empty(t, b.partitionKey)
+		assert.Empty(t, b.sortKey)
+		assert.Equal(t, "(SK = :0 AND PK = :1) AND (#1 > :2)", b.keyExpression)
+	})
+
+	t.Run("with errors", func(t *testing.T) {
+		t.Run("should reject multiple sort key conditions", func(t *testing.T) {
+			b := NewBuilder().
+				PartitionKey("PK", "user#1").
+				SortKeyGreaterThan("SK", 1).
+				SortKeyLessThan("SK", 10)
+
+			require.Equal(t, ErrInvalidKeyCondition, b.err)
+		})
+
+		t.Run("should reject multiple partition keys", func(t *testing.T) {
+			b := NewBuilder().
+				PartitionKey("PK", "user#1").
+				PartitionKey("PK", "user#2")
+
+			require.Equal(t, ErrInvalidKeyCondition, b.err)
+		})
+
+		t.Run("should reject multiple sort key conditions via WhereKey", func(t *testing.T) {
+			b := NewBuilder().
+				WhereKey("PK = ? AND SK > ?", "user#1", 1).
+				WhereKey("'SK' < ?", 10)
+			require.Equal(t, ErrInvalidKeyCondition, b.err)
+
+			b = NewBuilder().
+				WhereKey("PK = ? AND SK > ?", "user#1", 1).
+				SortKeyEquals("SK", 2)
+			require.Equal(t, ErrInvalidKeyCondition, b.err)
+
+			b = NewBuilder().
+				PartitionKey("PK", "user#1").
+				WhereKey("SK BETWEEN ? AND ? AND begins_with(SK, ?)", 1, 2, "a")
+			require.Equal(t, ErrInvalidKeyCondition, b.err)
+		})
+
+		t.Run("should reject sort key conditions without a partition key", func(t *testing.T) {
+			b := NewBuilder().SortKeyEquals("SK", 1)
+			require.Equal(t, ErrInvalidKeyCondition, b.err)
+
+			b = NewBuilder().WhereKey("begins_with(SK, ?)", "a")
+			require.Equal(t, ErrInvalidKeyCondition, b.err)
+
+			b = NewBuilder().WhereKey("SK BETWEEN ? AND ?", 1, 2)
+			require.Equal(t, ErrInvalidKeyCondition, b.err)
+		})
+
+		t.Run("should reject unsupported operators in WhereKey", func(t *testing.T) {
+			queries := []string{
+				"PK = ? OR PK = ?",
+				"PK = ? AND NOT SK = ?",
+				"PK = ? AND SK <> ?",
+				"PK IN (?, ?)",
+				"PK = ? AND contains(SK, ?)",
+			}
+			for _, query := range queries {
+				b := NewBuilder().WhereKey(query, 1, 2)
+				require.Equal(t, ErrInvalidKeyCondition, b.err, query)
+			}
+		})
+	})
+}
+
+func TestBuilder_CursorKeys(t *testing.T) {
+	t.Run("should include index keys", func(t *testing.T) {
+		b := NewClient(nil, WithoutSchemaDiscovery()).Builder().
+			Table("MyTable").
+			Index("GSI1").
+			WithIndexKeys("GSI1PK", "PK")
+
+		keys, err := b.cursorKeys(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"PK", "SK", "GSI1PK"}, keys)
+	})
+
+	t.Run("should ignore index keys without an index", func(t *testing.T) {
+		b := NewClient(nil, WithoutSchemaDiscovery()).Builder().
+			Table("MyTable").
+			WithIndexKeys("GSI1PK")
+
+		keys, err := b.cursorKeys(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"PK", "SK"}, keys)
+	})
+}
+
+func TestCountPlaceholders(t *testing.T) {
+	require.Equal(t, 0, CountPlaceholders(""))
+	require.Equal(t, 2, CountPlaceholders("PK = ? AND SK > ?"))
+	require.Equal(t, 1, CountPlaceholders("'a?b' = ?"))
+	require.Equal(t, 1, CountPlaceholders("status = :status AND #count > ?"))
+
+	input, err := NewBuilder().Where("'a?b' = ?", "c").ToScan()
+	require.NoError(t, err)
+	require.Equal(t, map[string]*string{"#1": aws.String("a?b")}, input.ExpressionAttributeNames)
+}
@@ -0,0 +1,412 @@
+package dyc
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// CacheStore stores cached items and query pages. implementations must be safe for concurrent use
+type CacheStore interface {
+	// Get returns the value stored for key and true if it was found and hasn't expired
+	Get(key string) (interface{}, bool)
+	// Set stores value for key, a ttl of 0 or less never expires
+	Set(key string, value interface{}, ttl time.Duration)
+	// Delete removes the value stored for key
+	Delete(key string)
+}
+
+// CacheOption allows you to configure the item cache
+type CacheOption func(c *itemCache)
+
+// CacheQueries enables caching of query pages for the provided ttl.
+// pages are keyed by the rendered query input and every write to a table made through the client
+// invalidates all cached pages of that table
+func CacheQueries(ttl time.Duration) CacheOption {
+	return func(c *itemCache) {
+		c.queries = true
+		c.queryTTL = ttl
+	}
+}
+
+// WithItemCache enables a read-through cache for GetItem and BatchGetIterator results.
+// items are cached for ttl and invalidated when written via Builder PutItem, UpdateItem, DeleteItem, BatchWriter or CopyTable.
+// consistent reads and projected batch gets always bypass the cache.
+//
+// note: invalidation only applies to writes made through this client, writes made elsewhere
+// are visible once the cached value expires
+func WithItemCache(store CacheStore, ttl time.Duration, opts ...CacheOption) ClientOption {
+	return func(c *Client) {
+		c.cache = &itemCache{
+			store:       store,
+			ttl:         ttl,
+			generations: make(map[string]uint64),
+		}
+		for _, opt := range opts {
+			opt(c.cache)
+		}
+	}
+}
+
+// itemCache renders cache keys and tracks a generation per table so writes can invalidate cached query pages.
+// reads capture the generation before calling dynamodb, and their results are only cached if no write
+// invalidated the table in the meantime so stale results can't be cached after an invalidation
+type itemCache struct {
+	store    CacheStore
+	ttl      time.Duration
+	queries  bool
+	queryTTL time.Duration
+
+	mu          sync.Mutex
+	generations map[string]uint64
+}
+
+func (c *itemCache) getItem(table string, key Map) (Map, bool) {
+	val, found := c.store.Get(itemCacheKey(table, key))
+	if !found {
+		return nil, false
+	}
+	item, ok := val.(Map)
+	if !ok {
+		return nil, false
+	}
+
+	return copyMap(item), true
+}
+
+// setItem caches the item unless the table was invalidated since generation was captured
+func (c *itemCache) setItem(table string, key Map, item Map, generation uint64) {
+	// the generation is checked while holding the lock so an invalidation can't happen between the check and the set
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generations[table] != generation {
+		return
+	}
+
+	c.store.Set(itemCacheKey(table, key), copyMap(item), c.ttl)
+}
+
+// generation returns the current generation of the table, it must be captured before reading from dynamodb
+func (c *itemCache) generation(table string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generations[table]
+}
+
+// invalidate removes the cached item with the provided key and all cached query pages of the table
+func (c *itemCache) invalidate(table string, keys ...Map) {
+	c.mu.Lock()
+	c.generations[table]++
+	c.mu.Unlock()
+
+	for _, key := range keys {
+		c.store.Delete(itemCacheKey(table, key))
+	}
+}
+
+func (c *itemCache) getQuery(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, bool) {
+	val, found := c.store.Get(queryCacheKey(input, c.generation(aws.StringValue(input.TableName))))
+	if !found {
+		return nil, false
+	}
+	output, ok := val.(*dynamodb.QueryOutput)
+	if !ok {
+		return nil, false
+	}
+
+	return copyQueryOutput(output), true
+}
+
+// setQuery caches the page under the generation captured before the query,
+// pages read before an invalidation are stored under a key that is never read again
+func (c *itemCache) setQuery(input *dynamodb.QueryInput, output *dynamodb.QueryOutput, generation uint64) {
+	c.store.Set(queryCacheKey(input, generation), copyQueryOutput(output), c.queryTTL)
+}
+
+func (c *itemCache) cacheQuery(input *dynamodb.QueryInput) bool {
+	return c.queries && !aws.BoolValue(input.ConsistentRead)
+}
+
+func queryCacheKey(input *dynamodb.QueryInput, generation uint64) string {
+	table := aws.StringValue(input.TableName)
+	// json encodes map keys in sorted order which makes the rendered input deterministic
+	rendered, _ := json.Marshal(input)
+
+	return "query:" + table + ":" + strconv.FormatUint(generation, 10) + ":" + string(rendered)
+}
+
+func itemCacheKey(table string, key Map) string {
+	rendered, _ := json.Marshal(key)
+
+	return "item:" + table + ":" + string(rendered)
+}
+
+// copyMap deep copies item so cached values can't be modified through the maps handed out by the cache
+func copyMap(item Map) Map {
+	if item == nil {
+		return nil
+	}
+
+	result := make(Map, len(item))
+	for k, v := range item {
+		result[k] = copyAttributeValue(v)
+	}
+
+	return result
+}
+
+func copyAttributeValue(val *dynamodb.AttributeValue) *dynamodb.AttributeValue {
+	if val == nil {
+		return nil
+	}
+
+	result := &dynamodb.AttributeValue{
+		M:  copyMap(val.M),
+		NS: copyStrings(val.NS),
+		SS: copyStrings(val.SS),
+	}
+	if val.BOOL != nil {
+		result.BOOL = aws.Bool(*val.BOOL)
+	}
+	if val.NULL != nil {
+		result.NULL = aws.Bool(*val.NULL)
+	}
+	if val.N != nil {
+		result.N = aws.String(*val.N)
+	}
+	if val.S != nil {
+		result.S = aws.String(*val.S)
+	}
+	if val.B != nil {
+		result.B = append([]byte{}, val.B...)
+	}
+	if val.BS != nil {
+		result.BS = make([][]byte, len(val.BS))
+		for i, b := range val.BS {
+			result.BS[i] = append([]byte{}, b...)
+		}
+	}
+	if val.L != nil {
+		result.L = make([]*dynamodb.AttributeValue, len(val.L))
+		for i, v := range val.L {
+			result.L[i] = copyAttributeValue(v)
+		}
+	}
+
+	return result
+}
+
+func copyStrings(arr []*string) []*string {
+	if arr == nil {
+		return nil
+	}
+
+	result := make([]*string, len(arr))
+	for i, v := range arr {
+		if v != nil {
+			result[i] = aws.String(*v)
+		}
+	}
+
+	return result
+}
+
+func copyQueryOutput(output *dynamodb.QueryOutput) *dynamodb.QueryOutput {
+	result := *output
+	result.Items = make(Maps, 0, len(output.Items))
+	for _, item := range output.Items {
+		result.Items = append(result.Items, copyMap(item))
+	}
+	result.LastEvaluatedKey = copyMap(output.LastEvaluatedKey)
+
+	return &result
+}
+
+// getItem gets a single item, serving it from the item cache when possible
+// and recording consumed capacity to the reports attached to the context
+func (c *Client) getItem(ctx context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	cacheable := c.cache != nil && !aws.BoolValue(input.ConsistentRead) && input.ProjectionExpression == nil
+	table := aws.StringValue(input.TableName)
+	var generation uint64
+	if cacheable {
+		if item, found := c.cache.getItem(table, input.Key); found {
+			return &dynamodb.GetItemOutput{Item: item}, nil
+		}
+		generation = c.cache.generation(table)
+	}
+
+	input.ReturnConsumedCapacity = c.capacityMode(ctx, input.ReturnConsumedCapacity)
+	req, output := c.DynamoDB.GetItemRequest(input)
+	err := c.do(ctx, req)
+	if err != nil {
+		return output, err
+	}
+	recordReads(ctx, output.ConsumedCapacity)
+	if cacheable && len(output.Item) > 0 {
+		c.cache.setItem(table, input.Key, output.Item, generation)
+	}
+
+	return output, err
+}
+
+// writtenKeys returns the keys of the items written by the provided requests
+// so they can be invalidated once the requests complete
+func (c *Client) writtenKeys(ctx context.Context, table string, requests []*dynamodb.WriteRequest) ([]Map, error) {
+	var keys []string
+	written := make([]Map, 0, len(requests))
+	for _, req := range requests {
+		if req.DeleteRequest != nil {
+			written = append(written, req.DeleteRequest.Key)
+			continue
+		}
+		if req.PutRequest == nil {
+			continue
+		}
+
+		if keys == nil {
+			var err error
+			if keys, err = c.TableKeys(ctx, table); err != nil {
+				return nil, err
+			}
+		}
+		written = append(written, extractFields(req.PutRequest.Item, keys...))
+	}
+
+	return written, nil
+}
+
+// batchGetFromCache serves the cacheable keys of the input from the item cache.
+// the remaining keys are returned along with the key names of every table whose results should be cached
+func (c *Client) batchGetFromCache(input *dynamodb.BatchGetItemInput, fn func(output *dynamodb.GetItemOutput) error) (*dynamodb.BatchGetItemInput, map[string][]string, error) {
+	remaining := &dynamodb.BatchGetItemInput{
+		RequestItems:           make(map[string]*dynamodb.KeysAndAttributes, len(input.RequestItems)),
+		ReturnConsumedCapacity: input.ReturnConsumedCapacity,
+	}
+	keyNames := make(map[string][]string, len(input.RequestItems))
+	for table, req := range input.RequestItems {
+		if aws.BoolValue(req.ConsistentRead) || req.ProjectionExpression != nil || len(req.AttributesToGet) > 0 || len(req.Keys) == 0 {
+			remaining.RequestItems[table] = req
+			continue
+		}
+
+		for name := range req.Keys[0] {
+			keyNames[table] = append(keyNames[table], name)
+		}
+
+		missing := *req
+		missing.Keys = nil
+		for _, key := range req.Keys {
+			item, found := c.cache.getItem(table, key)
+			if !found {
+				missing.Keys = append(missing.Keys, key)
+				continue
+			}
+			if err := fn(&dynamodb.GetItemOutput{Item: item}); err != nil {
+				return nil, nil, err
+			}
+		}
+		if len(missing.Keys) > 0 {
+			remaining.RequestItems[table] = &missing
+		}
+	}
+
+	return remaining, keyNames, nil
+}
+
+// LRUCache is an in memory CacheStore that evicts the least recently used values once full
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+	now      func() time.Time
+}
+
+type lruEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// NewLRUCache creates an in memory cache holding up to capacity values
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		now:      time.Now,
+	}
+}
+
+// Get returns the value stored for key and true if it was found and hasn't expired
+func (l *LRUCache) Get(key string) (interface{}, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, found := l.entries[key]
+	if !found {
+		return nil, false
+	}
+
+	entry := elem.Value.(*lruEntry)
+	if !entry.expires.IsZero() && !l.now().Before(entry.expires) {
+		l.remove(elem)
+		return nil, false
+	}
+	l.order.MoveToFront(elem)
+
+	return entry.value, true
+}
+
+// Set stores value for key, a ttl of 0 or less never expires
+func (l *LRUCache) Set(key string, value interface{}, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = l.now().Add(ttl)
+	}
+
+	if elem, found := l.entries[key]; found {
+		entry := elem.Value.(*lruEntry)
+		entry.value, entry.expires = value, expires
+		l.order.MoveToFront(elem)
+		return
+	}
+
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, value: value, expires: expires})
+	for l.capacity > 0 && l.order.Len() > l.capacity {
+		l.remove(l.order.Back())
+	}
+}
+
+// Delete removes the value stored for key
+func (l *LRUCache) Delete(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elem, found := l.entries[key]; found {
+		l.remove(elem)
+	}
+}
+
+// Len returns the amount of values stored, including expired values that haven't been evicted yet
+func (l *LRUCache) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.order.Len()
+}
+
+func (l *LRUCache) remove(elem *list.Element) {
+	l.order.Remove(elem)
+	delete(l.entries, elem.Value.(*lruEntry).key)
+}
@@ -0,0 +1,95 @@
+//go:build integration
+// +build integration
+
+package dyc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/darwayne/dyc"
+	"github.com/darwayne/dyc/internal/testing/dynamotest"
+)
+
+func TestItemCache_Integration(t *testing.T) {
+	setup := func(t *testing.T, opts ...dyc.CacheOption) (*dyc.Client, string) {
+		t.Helper()
+		t.Parallel()
+		table, db := dynamotest.SetupTestTable(context.Background(), t, "cache", dynamotest.DefaultSchema())
+
+		return dyc.NewClient(db, dyc.WithItemCache(dyc.NewLRUCache(100), time.Minute, opts...)), table
+	}
+
+	type row struct {
+		PK    string
+		SK    string
+		Value string
+	}
+
+	t.Run("GetItem", func(t *testing.T) {
+		cli, table := setup(t)
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		_, err := cli.Builder().Table(table).PutItem(ctx, row{PK: "config", SK: "flags", Value: "v1"})
+		require.NoError(t, err)
+
+		get := func() string {
+			var result row
+			_, err := cli.Builder().Table(table).Key("PK", "config", "SK", "flags").Result(&result).GetItem(ctx)
+			require.NoError(t, err)
+			return result.Value
+		}
+		require.Equal(t, "v1", get())
+
+		// writes that bypass the client aren't visible until the cached item expires
+		_, err = cli.DynamoDB.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+			TableName: aws.String(table),
+			Item:      dyc.Map{"PK": dyc.String("config"), "SK": dyc.String("flags"), "Value": dyc.String("v2")},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "v1", get())
+
+		_, err = cli.Builder().Table(table).Key("PK", "config", "SK", "flags").
+			Update("SET 'Value' = ?", "v3").
+			UpdateItem(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "v3", get())
+
+		_, err = cli.Builder().Table(table).Key("PK", "config", "SK", "flags").DeleteItem(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "", get())
+	})
+
+	t.Run("QueryAll", func(t *testing.T) {
+		cli, table := setup(t, dyc.CacheQueries(time.Minute))
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		_, err := cli.BatchPut(ctx, table, row{PK: "config", SK: "a"}, row{PK: "config", SK: "b"})
+		require.NoError(t, err)
+
+		query := func() int {
+			results, err := cli.Builder().Table(table).WhereKey("PK = ?", "config").QueryAll(ctx)
+			require.NoError(t, err)
+			return len(results)
+		}
+		require.Equal(t, 2, query())
+
+		_, err = cli.DynamoDB.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+			TableName: aws.String(table),
+			Item:      dyc.Map{"PK": dyc.String("config"), "SK": dyc.String("c")},
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, query())
+
+		_, err = cli.Builder().Table(table).PutItem(ctx, row{PK: "config", SK: "d"})
+		require.NoError(t, err)
+		require.Equal(t, 4, query())
+	})
+}
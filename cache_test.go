@@ -0,0 +1,203 @@
+//go:build unit
+// +build unit
+
+package dyc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUCache(t *testing.T) {
+	t.Run("should evict least recently used values", func(t *testing.T) {
+		cache := NewLRUCache(2)
+		cache.Set("a", 1, 0)
+		cache.Set("b", 2, 0)
+		_, found := cache.Get("a")
+		require.True(t, found)
+
+		cache.Set("c", 3, 0)
+		require.Equal(t, 2, cache.Len())
+		_, found = cache.Get("b")
+		require.False(t, found)
+
+		val, found := cache.Get("a")
+		require.True(t, found)
+		require.Equal(t, 1, val)
+	})
+
+	t.Run("should expire values", func(t *testing.T) {
+		now := time.Now()
+		cache := NewLRUCache(10)
+		cache.now = func() time.Time { return now }
+		cache.Set("a", 1, time.Second)
+		cache.Set("b", 2, 0)
+
+		now = now.Add(2 * time.Second)
+		_, found := cache.Get("a")
+		require.False(t, found)
+		_, found = cache.Get("b")
+		require.True(t, found)
+		require.Equal(t, 1, cache.Len())
+	})
+
+	t.Run("should delete values", func(t *testing.T) {
+		cache := NewLRUCache(10)
+		cache.Set("a", 1, 0)
+		cache.Delete("a")
+		_, found := cache.Get("a")
+		require.False(t, found)
+	})
+}
+
+func TestItemCache(t *testing.T) {
+	key := Map{"PK": String("config"), "SK": String("flags")}
+	item := Map{"PK": String("config"), "SK": String("flags"), "Enabled": {BOOL: aws.Bool(true)}}
+
+	t.Run("GetItem should be served from cache", func(t *testing.T) {
+		cli := NewClient(nil, WithItemCache(NewLRUCache(10), time.Minute))
+		cli.cache.setItem("MyTable", key, item, 0)
+
+		var result struct{ Enabled bool }
+		output, err := cli.Builder().
+			Table("MyTable").
+			Key("PK", "config", "SK", "flags").
+			Result(&result).
+			GetItem(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, item, output.Item)
+		require.True(t, result.Enabled)
+	})
+
+	t.Run("invalidate should remove items and query pages", func(t *testing.T) {
+		cli := NewClient(nil, WithItemCache(NewLRUCache(10), time.Minute, CacheQueries(time.Minute)))
+		cli.cache.setItem("MyTable", key, item, 0)
+		input := &dynamodb.QueryInput{TableName: aws.String("MyTable")}
+		cli.cache.setQuery(input, &dynamodb.QueryOutput{Items: Maps{item}}, 0)
+
+		_, found := cli.cache.getQuery(input)
+		require.True(t, found)
+
+		cli.cache.invalidate("MyTable", key)
+		_, found = cli.cache.getItem("MyTable", key)
+		require.False(t, found)
+		_, found = cli.cache.getQuery(input)
+		require.False(t, found)
+	})
+
+	t.Run("CopyTable should invalidate the destination table", func(t *testing.T) {
+		cli := NewClient(offlineDB(t), WithoutSchemaDiscovery(), WithItemCache(NewLRUCache(10), time.Minute, CacheQueries(time.Minute)))
+		cli.Use(func(next Handler) Handler {
+			return func(ctx context.Context, op *Operation) error {
+				if op.Name == "Scan" {
+					op.Output.(*dynamodb.ScanOutput).Items = Maps{item}
+				}
+				return nil
+			}
+		})
+		cli.cache.setItem("Destination", key, Map{"PK": String("config"), "SK": String("flags")}, 0)
+		input := &dynamodb.QueryInput{TableName: aws.String("Destination")}
+		cli.cache.setQuery(input, &dynamodb.QueryOutput{}, 0)
+
+		require.NoError(t, cli.CopyTable(context.Background(), "Destination", "Source", 1, nil))
+		_, found := cli.cache.getItem("Destination", key)
+		require.False(t, found)
+		_, found = cli.cache.getQuery(input)
+		require.False(t, found)
+	})
+
+	t.Run("cached values should not be shared with callers", func(t *testing.T) {
+		cli := NewClient(nil, WithItemCache(NewLRUCache(10), time.Minute))
+		cli.cache.setItem("MyTable", key, item, 0)
+
+		cached, found := cli.cache.getItem("MyTable", key)
+		require.True(t, found)
+		cached["Extra"] = String("value")
+
+		cached["Enabled"].BOOL = aws.Bool(false)
+
+		cached, found = cli.cache.getItem("MyTable", key)
+		require.True(t, found)
+		require.NotContains(t, cached, "Extra")
+		require.True(t, aws.BoolValue(cached["Enabled"].BOOL))
+	})
+
+	t.Run("nested values should be copied", func(t *testing.T) {
+		nested := Map{
+			"List": {L: []*dynamodb.AttributeValue{String("a")}},
+			"Map":  {M: Map{"Name": String("b")}},
+			"Bin":  {B: []byte("c")},
+			"Set":  StringSet("d"),
+		}
+		copied := copyMap(nested)
+		require.Equal(t, nested, copied)
+
+		*copied["List"].L[0].S = "changed"
+		*copied["Map"].M["Name"].S = "changed"
+		copied["Bin"].B[0] = 'x'
+		*copied["Set"].SS[0] = "changed"
+		require.Equal(t, "a", aws.StringValue(nested["List"].L[0].S))
+		require.Equal(t, "b", aws.StringValue(nested["Map"].M["Name"].S))
+		require.Equal(t, []byte("c"), nested["Bin"].B)
+		require.Equal(t, "d", aws.StringValue(nested["Set"].SS[0]))
+	})
+
+	t.Run("reads that raced an invalidation should not be cached", func(t *testing.T) {
+		cli := NewClient(nil, WithItemCache(NewLRUCache(10), time.Minute, CacheQueries(time.Minute)))
+		generation := cli.cache.generation("MyTable")
+		input := &dynamodb.QueryInput{TableName: aws.String("MyTable")}
+
+		cli.cache.invalidate("MyTable", key)
+		cli.cache.setItem("MyTable", key, item, generation)
+		cli.cache.setQuery(input, &dynamodb.QueryOutput{Items: Maps{item}}, generation)
+
+		_, found := cli.cache.getItem("MyTable", key)
+		require.False(t, found)
+		_, found = cli.cache.getQuery(input)
+		require.False(t, found)
+
+		cli.cache.setItem("MyTable", key, item, cli.cache.generation("MyTable"))
+		_, found = cli.cache.getItem("MyTable", key)
+		require.True(t, found)
+	})
+
+	t.Run("cache keys should not depend on map order", func(t *testing.T) {
+		require.Equal(t,
+			itemCacheKey("MyTable", Map{"PK": String("a"), "SK": String("b")}),
+			itemCacheKey("MyTable", Map{"SK": String("b"), "PK": String("a")}),
+		)
+	})
+
+	t.Run("BatchGetIterator should serve cached keys", func(t *testing.T) {
+		cli := NewClient(nil, WithItemCache(NewLRUCache(10), time.Minute))
+		cli.cache.setItem("MyTable", key, item, 0)
+
+		var results Maps
+		err := cli.BatchGetIterator(context.Background(), cli.ToBatchGetItemInput("MyTable", Maps{key}), func(output *dynamodb.GetItemOutput) error {
+			results = append(results, output.Item)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, Maps{item}, results)
+	})
+
+	t.Run("consistent reads should bypass the cache", func(t *testing.T) {
+		cli := NewClient(nil, WithItemCache(NewLRUCache(10), time.Minute))
+		cli.cache.setItem("MyTable", key, item, 0)
+
+		input := cli.ToBatchGetItemInput("MyTable", Maps{key})
+		input.RequestItems["MyTable"].ConsistentRead = aws.Bool(true)
+		remaining, keyNames, err := cli.batchGetFromCache(input, func(output *dynamodb.GetItemOutput) error {
+			t.Fatal("should not be served from cache")
+			return nil
+		})
+		require.NoError(t, err)
+		require.Empty(t, keyNames)
+		require.Equal(t, Maps{key}, remaining.RequestItems["MyTable"].Keys)
+	})
+}
@@ -0,0 +1,202 @@
+package dyc
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Capacity contains consumed read and write capacity units
+type Capacity struct {
+	ReadCapacityUnits  float64
+	WriteCapacityUnits float64
+}
+
+// Total returns the sum of read and write capacity units
+func (c Capacity) Total() float64 {
+	return c.ReadCapacityUnits + c.WriteCapacityUnits
+}
+
+func (c *Capacity) add(other Capacity) {
+	c.ReadCapacityUnits += other.ReadCapacityUnits
+	c.WriteCapacityUnits += other.WriteCapacityUnits
+}
+
+// TableCapacity contains the capacity consumed by operations on a single table
+type TableCapacity struct {
+	// Total is the capacity consumed by the table and all of its indexes
+	Total Capacity
+	// Table is the capacity consumed by the table itself. only reported in INDEXES mode
+	Table Capacity
+	// Indexes contains the capacity consumed by every global and local secondary index keyed by index name.
+	// only reported in INDEXES mode
+	Indexes map[string]Capacity
+}
+
+// CapacityReport accumulates consumed capacity per table and index. it is safe for concurrent use
+type CapacityReport struct {
+	mode   string
+	mu     sync.Mutex
+	tables map[string]*TableCapacity
+}
+
+// NewCapacityReport creates a report requesting consumed capacity in the provided mode
+// (dynamodb.ReturnConsumedCapacityTotal or dynamodb.ReturnConsumedCapacityIndexes)
+func NewCapacityReport(mode string) *CapacityReport {
+	return &CapacityReport{
+		mode:   mode,
+		tables: make(map[string]*TableCapacity),
+	}
+}
+
+// Mode returns the consumed capacity mode requested by the report
+func (r *CapacityReport) Mode() string {
+	return r.mode
+}
+
+// Tables returns a snapshot of the capacity consumed per table
+func (r *CapacityReport) Tables() map[string]TableCapacity {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make(map[string]TableCapacity, len(r.tables))
+	for name, table := range r.tables {
+		snapshot := *table
+		snapshot.Indexes = make(map[string]Capacity, len(table.Indexes))
+		for index, capacity := range table.Indexes {
+			snapshot.Indexes[index] = capacity
+		}
+		result[name] = snapshot
+	}
+
+	return result
+}
+
+// Total returns the capacity consumed across all tables
+func (r *CapacityReport) Total() Capacity {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var total Capacity
+	for _, table := range r.tables {
+		total.add(table.Total)
+	}
+
+	return total
+}
+
+// add records consumed capacity. read determines if units reported without a read/write breakdown are read or write units
+func (r *CapacityReport) add(read bool, consumed ...*dynamodb.ConsumedCapacity) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, cc := range consumed {
+		if cc == nil {
+			continue
+		}
+
+		name := aws.StringValue(cc.TableName)
+		table, found := r.tables[name]
+		if !found {
+			table = &TableCapacity{Indexes: make(map[string]Capacity)}
+			r.tables[name] = table
+		}
+
+		table.Total.add(toCapacity(read, cc.CapacityUnits, cc.ReadCapacityUnits, cc.WriteCapacityUnits))
+		if cc.Table != nil {
+			table.Table.add(toCapacity(read, cc.Table.CapacityUnits, cc.Table.ReadCapacityUnits, cc.Table.WriteCapacityUnits))
+		}
+		for _, indexes := range []map[string]*dynamodb.Capacity{cc.GlobalSecondaryIndexes, cc.LocalSecondaryIndexes} {
+			for index, c := range indexes {
+				capacity := table.Indexes[index]
+				capacity.add(toCapacity(read, c.CapacityUnits, c.ReadCapacityUnits, c.WriteCapacityUnits))
+				table.Indexes[index] = capacity
+			}
+		}
+	}
+}
+
+// toCapacity prefers the read/write breakdown and falls back to attributing all units to the operation type
+func toCapacity(read bool, units, readUnits, writeUnits *float64) Capacity {
+	if readUnits != nil || writeUnits != nil {
+		return Capacity{
+			ReadCapacityUnits:  aws.Float64Value(readUnits),
+			WriteCapacityUnits: aws.Float64Value(writeUnits),
+		}
+	}
+	if read {
+		return Capacity{ReadCapacityUnits: aws.Float64Value(units)}
+	}
+
+	return Capacity{WriteCapacityUnits: aws.Float64Value(units)}
+}
+
+type capacityReportsKey struct{}
+
+// WithCapacityReport returns a context that accumulates the capacity consumed by client operations into report.
+// reports attached to parent contexts keep receiving consumed capacity as well
+// e.g err := cli.CopyTable(dyc.WithCapacityReport(ctx, report), "dst", "src", 10, nil)
+func WithCapacityReport(ctx context.Context, report *CapacityReport) context.Context {
+	reports := capacityReports(ctx)
+	for _, existing := range reports {
+		if existing == report {
+			return ctx
+		}
+	}
+
+	combined := make([]*CapacityReport, 0, len(reports)+1)
+	combined = append(combined, reports...)
+
+	return context.WithValue(ctx, capacityReportsKey{}, append(combined, report))
+}
+
+func capacityReports(ctx context.Context) []*CapacityReport {
+	reports, _ := ctx.Value(capacityReportsKey{}).([]*CapacityReport)
+	return reports
+}
+
+// capacityMode returns the consumed capacity mode to request for the context,
+// TOTAL is requested when metrics are enabled and neither the input nor the context request a mode
+func (c *Client) capacityMode(ctx context.Context, mode *string) *string {
+	if result := capacityMode(ctx, mode); result != nil || c.metrics == nil {
+		return result
+	}
+
+	return aws.String(dynamodb.ReturnConsumedCapacityTotal)
+}
+
+// capacityMode returns the consumed capacity mode to request for the context.
+// the provided mode is kept if it was set explicitly or no reports are attached to the context
+func capacityMode(ctx context.Context, mode *string) *string {
+	if mode != nil {
+		return mode
+	}
+
+	var result *string
+	for _, report := range capacityReports(ctx) {
+		if report.mode == dynamodb.ReturnConsumedCapacityIndexes {
+			return aws.String(report.mode)
+		}
+		if report.mode != "" && report.mode != dynamodb.ReturnConsumedCapacityNone {
+			result = aws.String(report.mode)
+		}
+	}
+
+	return result
+}
+
+// recordReads adds consumed read capacity to all reports attached to the context
+func recordReads(ctx context.Context, consumed ...*dynamodb.ConsumedCapacity) {
+	for _, report := range capacityReports(ctx) {
+		report.add(true, consumed...)
+	}
+}
+
+// recordWrites adds consumed write capacity to all reports attached to the context
+func recordWrites(ctx context.Context, consumed ...*dynamodb.ConsumedCapacity) {
+	for _, report := range capacityReports(ctx) {
+		report.add(false, consumed...)
+	}
+}
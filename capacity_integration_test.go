@@ -0,0 +1,60 @@
+//go:build integration
+// +build integration
+
+package dyc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/darwayne/dyc"
+	"github.com/darwayne/dyc/internal/testing/dynamotest"
+)
+
+func TestCapacityReport_Integration(t *testing.T) {
+	type row struct {
+		PK string
+		SK string
+	}
+
+	t.Run("Builder", func(t *testing.T) {
+		cli, table := setupClient(t)
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		_, err := cli.BatchPut(ctx, table, row{PK: "a", SK: "1"}, row{PK: "a", SK: "2"})
+		require.NoError(t, err)
+
+		b := cli.Builder().Table(table).WhereKey("PK = ?", "a").ReturnConsumedCapacity(dynamodb.ReturnConsumedCapacityTotal)
+		results, err := b.QueryAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+
+		report := b.CapacityReport()
+		require.Greater(t, report.Tables()[table].Total.ReadCapacityUnits, 0.0)
+
+		require.NoError(t, b.ScanDelete(ctx))
+		require.Greater(t, report.Tables()[table].Total.WriteCapacityUnits, 0.0)
+	})
+
+	t.Run("CopyTable", func(t *testing.T) {
+		cli, src := setupClient(t)
+		dst, _ := dynamotest.SetupTestTable(context.Background(), t, "capacity-dst", dynamotest.DefaultSchema())
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		_, err := cli.BatchPut(ctx, src, row{PK: "a", SK: "1"}, row{PK: "b", SK: "1"})
+		require.NoError(t, err)
+
+		report := dyc.NewCapacityReport(dynamodb.ReturnConsumedCapacityTotal)
+		require.NoError(t, cli.CopyTable(dyc.WithCapacityReport(ctx, report), dst, src, 2, nil))
+
+		tables := report.Tables()
+		require.Greater(t, tables[src].Total.ReadCapacityUnits, 0.0)
+		require.Greater(t, tables[dst].Total.WriteCapacityUnits, 0.0)
+	})
+}
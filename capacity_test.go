@@ -0,0 +1,85 @@
+//go:build unit
+// +build unit
+
+package dyc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapacityReport(t *testing.T) {
+	t.Run("should attribute total units by operation type", func(t *testing.T) {
+		report := NewCapacityReport(dynamodb.ReturnConsumedCapacityTotal)
+		report.add(true, &dynamodb.ConsumedCapacity{TableName: aws.String("MyTable"), CapacityUnits: aws.Float64(2)})
+		report.add(false, &dynamodb.ConsumedCapacity{TableName: aws.String("MyTable"), CapacityUnits: aws.Float64(3)})
+		report.add(true, &dynamodb.ConsumedCapacity{TableName: aws.String("Other"), CapacityUnits: aws.Float64(0.5)}, nil)
+
+		tables := report.Tables()
+		require.Len(t, tables, 2)
+		require.Equal(t, Capacity{ReadCapacityUnits: 2, WriteCapacityUnits: 3}, tables["MyTable"].Total)
+		require.Equal(t, Capacity{ReadCapacityUnits: 0.5}, tables["Other"].Total)
+		require.Equal(t, 5.5, report.Total().Total())
+	})
+
+	t.Run("should track indexes", func(t *testing.T) {
+		report := NewCapacityReport(dynamodb.ReturnConsumedCapacityIndexes)
+		report.add(false, &dynamodb.ConsumedCapacity{
+			TableName:          aws.String("MyTable"),
+			CapacityUnits:      aws.Float64(3),
+			WriteCapacityUnits: aws.Float64(3),
+			Table:              &dynamodb.Capacity{CapacityUnits: aws.Float64(1)},
+			GlobalSecondaryIndexes: map[string]*dynamodb.Capacity{
+				"GSI1": {CapacityUnits: aws.Float64(1)},
+			},
+			LocalSecondaryIndexes: map[string]*dynamodb.Capacity{
+				"LSI1": {CapacityUnits: aws.Float64(1)},
+			},
+		})
+
+		table := report.Tables()["MyTable"]
+		require.Equal(t, Capacity{WriteCapacityUnits: 3}, table.Total)
+		require.Equal(t, Capacity{WriteCapacityUnits: 1}, table.Table)
+		require.Equal(t, map[string]Capacity{
+			"GSI1": {WriteCapacityUnits: 1},
+			"LSI1": {WriteCapacityUnits: 1},
+		}, table.Indexes)
+	})
+}
+
+func TestWithCapacityReport(t *testing.T) {
+	ctx := context.Background()
+	require.Nil(t, capacityMode(ctx, nil))
+
+	total := NewCapacityReport(dynamodb.ReturnConsumedCapacityTotal)
+	ctx = WithCapacityReport(ctx, total)
+	require.Equal(t, dynamodb.ReturnConsumedCapacityTotal, aws.StringValue(capacityMode(ctx, nil)))
+	require.Equal(t, dynamodb.ReturnConsumedCapacityNone, aws.StringValue(capacityMode(ctx, aws.String(dynamodb.ReturnConsumedCapacityNone))))
+
+	indexes := NewCapacityReport(dynamodb.ReturnConsumedCapacityIndexes)
+	ctx = WithCapacityReport(ctx, indexes)
+	require.Same(t, ctx, WithCapacityReport(ctx, indexes))
+	require.Equal(t, dynamodb.ReturnConsumedCapacityIndexes, aws.StringValue(capacityMode(ctx, nil)))
+
+	recordReads(ctx, &dynamodb.ConsumedCapacity{TableName: aws.String("MyTable"), CapacityUnits: aws.Float64(1)})
+	require.Equal(t, 1.0, total.Total().ReadCapacityUnits)
+	require.Equal(t, 1.0, indexes.Total().ReadCapacityUnits)
+}
+
+func TestBuilder_ReturnConsumedCapacity(t *testing.T) {
+	b := NewBuilder().Table("MyTable").WhereKey("PK = ?", "a").ReturnConsumedCapacity(dynamodb.ReturnConsumedCapacityIndexes)
+	require.NotNil(t, b.CapacityReport())
+
+	query, err := b.ToQuery()
+	require.NoError(t, err)
+	require.Equal(t, dynamodb.ReturnConsumedCapacityIndexes, aws.StringValue(query.ReturnConsumedCapacity))
+
+	query, err = NewBuilder().Table("MyTable").WhereKey("PK = ?", "a").ToQuery()
+	require.NoError(t, err)
+	require.Nil(t, query.ReturnConsumedCapacity)
+	require.Nil(t, NewBuilder().CapacityReport())
+}
@@ -16,11 +16,35 @@ import (
 // such as iteration, processing unprocessed items and more
 type Client struct {
 	*dynamodb.DynamoDB
+	schemas                schemaCache
+	disableSchemaDiscovery bool
+	cache                  *itemCache
+	middlewareMu           sync.RWMutex
+	middleware             []Middleware
+	callHooks              []CallHook
+	metrics                Metrics
 }
 
+// ClientOption allows you to configure optional client behavior
+type ClientOption func(c *Client)
+
 // NewClient creates a new dyc client
-func NewClient(db *dynamodb.DynamoDB) *Client {
-	return &Client{DynamoDB: db}
+func NewClient(db *dynamodb.DynamoDB, opts ...ClientOption) *Client {
+	c := &Client{DynamoDB: db}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// WithoutSchemaDiscovery disables automatic key discovery via DescribeTable.
+// builders will rely on the keys set via Builder.WithPrimaryKeys (PK,SK by default).
+// the same keys are used if DescribeTable is denied, disabling discovery only avoids the DescribeTable call
+func WithoutSchemaDiscovery() ClientOption {
+	return func(c *Client) {
+		c.disableSchemaDiscovery = true
+	}
 }
 
 // BatchPut allows you to put a batch of items to a table
@@ -52,23 +76,38 @@ func (c *Client) BatchPut(ctx context.Context, tableName string, items ...interf
 
 // BatchWriter batch writes an array of write requests to a table
 func (c *Client) BatchWriter(ctx context.Context, tableName string, requests ...*dynamodb.WriteRequest) (int, error) {
+	if c.cache != nil {
+		written, err := c.writtenKeys(ctx, tableName, requests)
+		if err != nil {
+			return 0, err
+		}
+		defer c.cache.invalidate(tableName, written...)
+	}
+
 	totalWritten := 0
 	chunks := c.ChunkWriteRequests(requests)
 	for _, chunk := range chunks {
-		out, err := c.DynamoDB.BatchWriteItemWithContext(ctx, &dynamodb.BatchWriteItemInput{
+		out, err := c.batchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
 			RequestItems: map[string][]*dynamodb.WriteRequest{
 				tableName: chunk,
 			},
+			ReturnConsumedCapacity: c.capacityMode(ctx, nil),
 		})
 
 		if err != nil {
 			return totalWritten, err
 		}
+		recordWrites(ctx, out.ConsumedCapacity...)
 
-		totalWritten += len(chunk) - len(out.UnprocessedItems)
+		unprocessed := 0
+		for _, reqs := range out.UnprocessedItems {
+			unprocessed += len(reqs)
+		}
+		totalWritten += len(chunk) - unprocessed
 
 		if len(out.UnprocessedItems) > 0 {
 			for table, reqs := range out.UnprocessedItems {
+				c.observeUnprocessed(table, "BatchWriteItem", len(reqs))
 				total, err := c.BatchWriter(ctx, table, reqs...)
 				totalWritten += total
 				if err != nil {
@@ -98,7 +137,7 @@ func (c *Client) QueryIterator(ctx context.Context, input *dynamodb.QueryInput,
 	}
 	seen := 0
 	var pageError error
-	err := c.DynamoDB.QueryPagesWithContext(ctx, &in2, func(output *dynamodb.QueryOutput, b bool) bool {
+	err := c.queryPages(ctx, &in2, func(output *dynamodb.QueryOutput, b bool) bool {
 		if hasLimit {
 			var added, broke bool
 			var items []map[string]*dynamodb.AttributeValue
@@ -131,11 +170,13 @@ func (c *Client) QueryIterator(ctx context.Context, input *dynamodb.QueryInput,
 	return nil
 }
 
-// QueryIteratorV2 iterates all results of a query respecting relevant keys
+// QueryIteratorV2 iterates all results of a query respecting relevant keys.
+// when querying an index the index keys are added to the provided keys when synthesizing LastEvaluatedKey
 func (c *Client) QueryIteratorV2(ctx context.Context, input *dynamodb.QueryInput, keys []string, fn func(output *dynamodb.QueryOutput) error) error {
 	modifier := limitModifier(&input.Limit)
+	cursor := c.cursorSynthesizer(ctx, input.TableName, input.IndexName, keys)
 	var pageError error
-	err := c.DynamoDB.QueryPagesWithContext(ctx, input, func(output *dynamodb.QueryOutput, b bool) bool {
+	err := c.queryPages(ctx, input, func(output *dynamodb.QueryOutput, b bool) bool {
 		if len(output.Items) == 0 {
 			return true
 		}
@@ -146,7 +187,11 @@ func (c *Client) QueryIteratorV2(ctx context.Context, input *dynamodb.QueryInput
 		totalItems := len(output.Items)
 		lastIDX := totalItems - 1
 		if totalItems > 0 && trimmed {
-			output.SetLastEvaluatedKey(extractFields(output.Items[lastIDX], keys...))
+			var lastKey Map
+			if lastKey, pageError = cursor(output.Items[lastIDX]); pageError != nil {
+				return false
+			}
+			output.SetLastEvaluatedKey(lastKey)
 		}
 		pageError = fn(output)
 		return pageError == nil
@@ -163,15 +208,67 @@ func (c *Client) QueryIteratorV2(ctx context.Context, input *dynamodb.QueryInput
 	return nil
 }
 
+// queryPages behaves like QueryPagesWithContext, routing every page through the middleware chain.
+// pages are served from the query cache when enabled and consumed capacity is recorded to the reports attached to the context
+func (c *Client) queryPages(ctx context.Context, input *dynamodb.QueryInput, fn func(output *dynamodb.QueryOutput, lastPage bool) bool) error {
+	in := *input
+	in.ReturnConsumedCapacity = c.capacityMode(ctx, in.ReturnConsumedCapacity)
+	cacheable := c.cache != nil && c.cache.cacheQuery(&in)
+	for {
+		var output *dynamodb.QueryOutput
+		var generation uint64
+		found := false
+		if cacheable {
+			output, found = c.cache.getQuery(&in)
+			generation = c.cache.generation(aws.StringValue(in.TableName))
+		}
+		if !found {
+			var err error
+			if output, err = c.query(ctx, &in); err != nil {
+				return err
+			}
+			recordReads(ctx, output.ConsumedCapacity)
+			if cacheable {
+				c.cache.setQuery(&in, output, generation)
+			}
+		}
+
+		next := output.LastEvaluatedKey
+		lastPage := len(next) == 0
+		if !fn(output, lastPage) || lastPage {
+			return nil
+		}
+		in.ExclusiveStartKey = next
+	}
+}
+
+// scanPages behaves like ScanPagesWithContext, routing every page through the middleware chain.
+// consumed capacity is recorded to the reports attached to the context
+func (c *Client) scanPages(ctx context.Context, input *dynamodb.ScanInput, fn func(output *dynamodb.ScanOutput, lastPage bool) bool) error {
+	in := *input
+	in.ReturnConsumedCapacity = c.capacityMode(ctx, in.ReturnConsumedCapacity)
+	for {
+		output, err := c.scan(ctx, &in)
+		if err != nil {
+			return err
+		}
+		recordReads(ctx, output.ConsumedCapacity)
+
+		next := output.LastEvaluatedKey
+		lastPage := len(next) == 0
+		if !fn(output, lastPage) || lastPage {
+			return nil
+		}
+		in.ExclusiveStartKey = next
+	}
+}
+
 func (c *Client) onCopyData(ctx context.Context, dst string, working *int64, errChan chan error, data map[string]*dynamodb.AttributeValue) {
 	atomic.AddInt64(working, 1)
 	defer func() {
 		atomic.AddInt64(working, -1)
 	}()
-	_, err := c.PutItemWithContext(ctx, &dynamodb.PutItemInput{
-		Item:      data,
-		TableName: &dst,
-	})
+	out, err := c.copyItem(ctx, dst, data)
 
 	if err != nil {
 		select {
@@ -179,9 +276,28 @@ func (c *Client) onCopyData(ctx context.Context, dst string, working *int64, err
 			return
 		case errChan <- err:
 		}
+		return
 	}
+	recordWrites(ctx, out.ConsumedCapacity)
+}
 
+// copyItem puts the item into the destination table, invalidating the cached item and query pages like Builder.PutItem
+func (c *Client) copyItem(ctx context.Context, dst string, data map[string]*dynamodb.AttributeValue) (*dynamodb.PutItemOutput, error) {
+	if c.cache != nil {
+		keys, err := c.TableKeys(ctx, dst)
+		if err != nil {
+			return nil, err
+		}
+		defer c.cache.invalidate(dst, extractFields(data, keys...))
+	}
+
+	return c.putItem(ctx, &dynamodb.PutItemInput{
+		Item:                   data,
+		TableName:              &dst,
+		ReturnConsumedCapacity: c.capacityMode(ctx, nil),
+	})
 }
+
 func (c *Client) copyTableWorker(ctx context.Context, dst string, readComplete chan struct{}, dataChan chan map[string]*dynamodb.AttributeValue, working *int64, wg *sync.WaitGroup, errChan chan error) {
 	defer wg.Done()
 	for {
@@ -298,6 +414,7 @@ func (c *Client) parallelScanWorker(ctx context.Context, idx int, arg dynamodb.S
 			case <-ctx.Done():
 				return ctx.Err()
 			case errChan <- e:
+				return e
 			default:
 				return errors.New("exit early")
 			}
@@ -339,11 +456,22 @@ func (c *Client) ParallelScanIterator(ctx context.Context, input *dynamodb.ScanI
 
 	select {
 	case err := <-errChan:
+		// stop the remaining workers so fn isn't called once the scan returned
+		cancel()
+		wg.Wait()
 		return err
 	case <-workerCtx.Done():
 	}
+	wg.Wait()
 
-	return nil
+	// workers send their errors before they are done, so an error may be pending once all workers finished
+	select {
+	case err := <-errChan:
+		return err
+	default:
+	}
+
+	return ctx.Err()
 }
 
 // ScanIterator iterates all results of a scan
@@ -358,7 +486,7 @@ func (c *Client) ScanIterator(ctx context.Context, input *dynamodb.ScanInput, fn
 	}
 	seen := 0
 	var pageError error
-	err := c.DynamoDB.ScanPagesWithContext(ctx, &in2, func(output *dynamodb.ScanOutput, b bool) bool {
+	err := c.scanPages(ctx, &in2, func(output *dynamodb.ScanOutput, b bool) bool {
 		if hasLimit {
 			var added, broke bool
 			var items []map[string]*dynamodb.AttributeValue
@@ -391,11 +519,13 @@ func (c *Client) ScanIterator(ctx context.Context, input *dynamodb.ScanInput, fn
 	return nil
 }
 
-// ScanIteratorV2 iterates all results of a scan respecting keys
+// ScanIteratorV2 iterates all results of a scan respecting keys.
+// when scanning an index the index keys are added to the provided keys when synthesizing LastEvaluatedKey
 func (c *Client) ScanIteratorV2(ctx context.Context, input *dynamodb.ScanInput, keys []string, fn func(output *dynamodb.ScanOutput) error) error {
 	modifier := limitModifier(&input.Limit)
+	cursor := c.cursorSynthesizer(ctx, input.TableName, input.IndexName, keys)
 	var pageError error
-	err := c.DynamoDB.ScanPagesWithContext(ctx, input, func(output *dynamodb.ScanOutput, b bool) bool {
+	err := c.scanPages(ctx, input, func(output *dynamodb.ScanOutput, b bool) bool {
 		if len(output.Items) == 0 {
 			return true
 		}
@@ -406,7 +536,11 @@ func (c *Client) ScanIteratorV2(ctx context.Context, input *dynamodb.ScanInput,
 		totalItems := len(output.Items)
 		lastIDX := totalItems - 1
 		if totalItems > 0 && trimmed {
-			output.SetLastEvaluatedKey(extractFields(output.Items[lastIDX], keys...))
+			var lastKey Map
+			if lastKey, pageError = cursor(output.Items[lastIDX]); pageError != nil {
+				return false
+			}
+			output.SetLastEvaluatedKey(lastKey)
 		}
 		pageError = fn(output)
 		return pageError == nil
@@ -428,14 +562,106 @@ func (c *Client) ScanCount(ctx context.Context, input *dynamodb.ScanInput) (int6
 	i := *input
 	i.Select = aws.String(dynamodb.SelectCount)
 	var total int64
-	c.ScanIterator(ctx, &i, func(output *dynamodb.ScanOutput) error {
+	err := c.ScanIterator(ctx, &i, func(output *dynamodb.ScanOutput) error {
 		if output.Count == nil {
 			return errors.New("count nil")
 		}
 		atomic.AddInt64(&total, *output.Count)
 		return nil
 	})
-	return total, nil
+	return total, err
+}
+
+// CountResult contains the amount of items counted by a query or scan
+type CountResult struct {
+	// Count is the amount of items matching the key condition and filter
+	Count int64
+	// ScannedCount is the amount of items evaluated before the filter was applied
+	ScannedCount int64
+}
+
+func (r *CountResult) add(count, scanned *int64) {
+	atomic.AddInt64(&r.Count, aws.Int64Value(count))
+	atomic.AddInt64(&r.ScannedCount, aws.Int64Value(scanned))
+}
+
+// QueryCount counts the items matching the query without retrieving them.
+// the limit and projection of the input are ignored
+func (c *Client) QueryCount(ctx context.Context, input *dynamodb.QueryInput) (CountResult, error) {
+	in := *input
+	in.Select = aws.String(dynamodb.SelectCount)
+	in.Limit = nil
+	in.ProjectionExpression = nil
+	in.ExpressionAttributeNames = usedNames(in.ExpressionAttributeNames, in.KeyConditionExpression, in.FilterExpression)
+
+	var result CountResult
+	err := c.queryPages(ctx, &in, func(output *dynamodb.QueryOutput, lastPage bool) bool {
+		result.add(output.Count, output.ScannedCount)
+		return true
+	})
+
+	return result, err
+}
+
+// ParallelScanCount counts the items matching the scan without retrieving them, splitting the scan in the provided
+// amount of segments which are counted in parallel. the limit and projection of the input are ignored
+func (c *Client) ParallelScanCount(ctx context.Context, input *dynamodb.ScanInput, segments int) (CountResult, error) {
+	in := *input
+	in.Select = aws.String(dynamodb.SelectCount)
+	in.Limit = nil
+	in.ProjectionExpression = nil
+	in.ExpressionAttributeNames = usedNames(in.ExpressionAttributeNames, in.FilterExpression)
+
+	var result CountResult
+	count := func(output *dynamodb.ScanOutput) error {
+		result.add(output.Count, output.ScannedCount)
+		return nil
+	}
+	if segments <= 1 {
+		err := c.scanPages(ctx, &in, func(output *dynamodb.ScanOutput, lastPage bool) bool {
+			_ = count(output)
+			return true
+		})
+		return result, err
+	}
+	err := c.ParallelScanIterator(ctx, &in, segments, count, true)
+
+	return result, err
+}
+
+// usedNames returns the expression attribute names referenced by the provided expressions.
+// dynamodb rejects requests containing names that aren't used by any expression
+func usedNames(names map[string]*string, expressions ...*string) map[string]*string {
+	var result map[string]*string
+	for _, expr := range expressions {
+		for _, placeholder := range placeholderRegex.FindAllString(aws.StringValue(expr), -1) {
+			if name, found := names[placeholder]; found {
+				if result == nil {
+					result = make(map[string]*string)
+				}
+				result[placeholder] = name
+			}
+		}
+	}
+
+	return result
+}
+
+// usedValues returns the expression attribute values referenced by the provided expressions
+func usedValues(values map[string]*dynamodb.AttributeValue, expressions ...*string) map[string]*dynamodb.AttributeValue {
+	var result map[string]*dynamodb.AttributeValue
+	for _, expr := range expressions {
+		for _, placeholder := range placeholderRegex.FindAllString(aws.StringValue(expr), -1) {
+			if val, found := values[placeholder]; found {
+				if result == nil {
+					result = make(map[string]*dynamodb.AttributeValue)
+				}
+				result[placeholder] = val
+			}
+		}
+	}
+
+	return result
 }
 
 // QueryDeleter deletes all records that match the query
@@ -494,40 +720,57 @@ func (c *Client) ScanDeleter(ctx context.Context, table string, input *dynamodb.
 
 // BatchGetIterator retrieves all items from the batch get input
 func (c *Client) BatchGetIterator(ctx context.Context, input *dynamodb.BatchGetItemInput, fn func(output *dynamodb.GetItemOutput) error) error {
-	var pageError error
-	err := c.DynamoDB.BatchGetItemPagesWithContext(ctx, input, func(output *dynamodb.BatchGetItemOutput, b bool) bool {
-		var capacity *dynamodb.ConsumedCapacity = nil
-		if len(output.ConsumedCapacity) > 0 {
-			capacity = output.ConsumedCapacity[0]
-		}
-		for _, results := range output.Responses {
-			for _, raw := range results {
-				pageError = fn(&dynamodb.GetItemOutput{
-					Item:             raw,
-					ConsumedCapacity: capacity,
-				})
-				if pageError != nil {
-					return false
-				}
-			}
+	var cacheKeys map[string][]string
+	generations := make(map[string]uint64, len(input.RequestItems))
+	if c.cache != nil {
+		for tbl := range input.RequestItems {
+			generations[tbl] = c.cache.generation(tbl)
 		}
 
-		for tbl, unprocessed := range output.UnprocessedKeys {
-			pageError = c.BatchGetIterator(ctx, c.ToBatchGetItemInput(tbl, unprocessed.Keys), fn)
-			if pageError != nil {
-				return false
-			}
+		var err error
+		if input, cacheKeys, err = c.batchGetFromCache(input, fn); err != nil {
+			return err
 		}
+		if len(input.RequestItems) == 0 {
+			return nil
+		}
+	}
 
-		return true
-	})
-
+	in := *input
+	in.ReturnConsumedCapacity = c.capacityMode(ctx, in.ReturnConsumedCapacity)
+	output, err := c.batchGetItem(ctx, &in)
 	if err != nil {
 		return err
 	}
 
-	if pageError != nil {
-		return pageError
+	recordReads(ctx, output.ConsumedCapacity...)
+	capacities := make(map[string]*dynamodb.ConsumedCapacity, len(output.ConsumedCapacity))
+	for _, cc := range output.ConsumedCapacity {
+		capacities[aws.StringValue(cc.TableName)] = cc
+	}
+	for tbl, results := range output.Responses {
+		capacity := capacities[tbl]
+		for _, raw := range results {
+			if keys, found := cacheKeys[tbl]; found {
+				c.cache.setItem(tbl, extractFields(raw, keys...), raw, generations[tbl])
+			}
+			if err := fn(&dynamodb.GetItemOutput{
+				Item:             raw,
+				ConsumedCapacity: capacity,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(output.UnprocessedKeys) > 0 {
+		for table, keys := range output.UnprocessedKeys {
+			c.observeUnprocessed(table, "BatchGetItem", len(keys.Keys))
+		}
+		return c.BatchGetIterator(ctx, &dynamodb.BatchGetItemInput{
+			RequestItems:           output.UnprocessedKeys,
+			ReturnConsumedCapacity: input.ReturnConsumedCapacity,
+		}, fn)
 	}
 
 	return nil
@@ -564,6 +807,32 @@ func (c *Client) ChunkWriteRequests(requests []*dynamodb.WriteRequest) [][]*dyna
 	return results
 }
 
+// cursorSynthesizer produces a function that builds a LastEvaluatedKey from the last item of a trimmed page.
+// for index operations the table and index keys are looked up via the schema cache,
+// if schema discovery is disabled or DescribeTable is denied the provided keys must already include the index keys.
+// keys are only resolved once and only if a page is trimmed
+func (c *Client) cursorSynthesizer(ctx context.Context, table, index *string, keys []string) func(item Map) (Map, error) {
+	var resolved []string
+	return func(item Map) (Map, error) {
+		if resolved == nil {
+			resolved = keys
+			if table != nil && index != nil && !c.disableSchemaDiscovery {
+				schema, err := c.TableSchema(ctx, *table)
+				switch {
+				case isAccessDenied(err):
+					// the provided keys are used as if schema discovery was disabled
+				case err != nil:
+					return nil, err
+				default:
+					resolved = mergeKeys(schema.Keys, schema.IndexKeys(*index))
+				}
+			}
+		}
+
+		return extractFields(item, resolved...), nil
+	}
+}
+
 // limitModifier utilizes the dynamo limit input and treats it as page size. if limit is set it will be unset
 func limitModifier(inputLimit **int64) func(maps *Maps) (trimmed, exitEarly bool) {
 	hasLimit := *inputLimit != nil
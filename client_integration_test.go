@@ -0,0 +1,168 @@
+//go:build integration
+// +build integration
+
+package dyc_test
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/darwayne/dyc"
+	"github.com/darwayne/dyc/internal/testing/dynamotest"
+)
+
+func TestClient(t *testing.T) {
+	t.Run("TableSchema", func(t *testing.T) {
+		t.Run("happy path", func(t *testing.T) {
+			cli, table := setupClient(t)
+
+			schema, err := cli.TableSchema(defaultCtx(), table)
+			require.NoError(t, err)
+			require.Equal(t, table, schema.Name)
+			require.Equal(t, []string{"PK", "SK"}, schema.Keys)
+			require.Equal(t, []string{"GSI1PK", "GSI1SK"}, schema.IndexKeys("GSI1"))
+			require.Equal(t, []string{"TYP"}, schema.IndexKeys("TYPE"))
+
+			cached, err := cli.TableSchema(defaultCtx(), table)
+			require.NoError(t, err)
+			require.True(t, schema == cached)
+		})
+	})
+
+	t.Run("ExportTable", func(t *testing.T) {
+		t.Run("happy path", func(t *testing.T) {
+			cli, table := setupClient(t)
+			_, err := cli.BatchPut(defaultCtx(), table,
+				dyc.Map{"PK": dyc.String("a"), "SK": dyc.String("1"), "Age": dyc.Int(30)},
+				dyc.Map{"PK": dyc.String("a"), "SK": dyc.String("2"), "Age": dyc.Int(40)},
+				dyc.Map{"PK": dyc.String("b"), "SK": dyc.String("1"), "Age": dyc.Int(50)},
+			)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			filter := cli.Builder().Where("PK = ?", "a").SelectFields("SK", "Age")
+			exported, err := cli.ExportTable(defaultCtx(), table, &buf, dyc.JSONLines, dyc.ExportFilter(filter), dyc.ExportSegments(2))
+			require.NoError(t, err)
+			require.Equal(t, int64(2), exported)
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			sort.Strings(lines)
+			require.Equal(t, []string{`{"Age":30,"SK":"1"}`, `{"Age":40,"SK":"2"}`}, lines)
+		})
+	})
+
+	t.Run("ImportTable", func(t *testing.T) {
+		t.Run("should restore an export", func(t *testing.T) {
+			cli, src := setupClient(t)
+			_, dst := setupClient(t)
+			_, err := cli.BatchPut(defaultCtx(), src,
+				dyc.Map{"PK": dyc.String("a"), "SK": dyc.String("1"), "Tags": dyc.StringSet("x")},
+				dyc.Map{"PK": dyc.String("b"), "SK": dyc.String("1"), "Age": dyc.Int(50)},
+			)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			_, err = cli.ExportTable(defaultCtx(), src, &buf, dyc.DynamoDBJSON)
+			require.NoError(t, err)
+			buf.WriteString("{\"Item\":{\"PK\":{\"S\":\"missing sort key\"}}}\n")
+
+			result, err := cli.ImportTable(defaultCtx(), dst, &buf, dyc.DynamoDBJSON)
+			require.NoError(t, err)
+			require.Equal(t, int64(2), result.Imported)
+			require.Len(t, result.Rejected, 1)
+			require.Equal(t, 3, result.Rejected[0].Line)
+
+			items, err := cli.Builder().Table(dst).ScanAll(defaultCtx())
+			require.NoError(t, err)
+			require.Len(t, items, 2)
+		})
+
+		t.Run("should import csv", func(t *testing.T) {
+			cli, table := setupClient(t)
+			input := "PK,SK,Age\na,1,30\nb,2,40\n"
+			result, err := cli.ImportTable(defaultCtx(), table, strings.NewReader(input), dyc.CSV,
+				dyc.ImportColumnTypes(map[string]string{"Age": "N"}))
+			require.NoError(t, err)
+			require.Equal(t, int64(2), result.Imported)
+
+			sum, err := cli.Builder().Table(table).Sum(defaultCtx(), "Age")
+			require.NoError(t, err)
+			require.Equal(t, 70.0, sum)
+		})
+	})
+}
+
+func TestClient_EnsureTable(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	cli := dyc.NewClient(dynamotest.SetupTestDB(t))
+	spec := dyc.TableSpec{
+		Name:         dynamotest.UniqueTableName(t, "ensure"),
+		PartitionKey: dyc.KeyAttribute{Name: "PK", Type: "S"},
+		SortKey:      &dyc.KeyAttribute{Name: "SK", Type: "S"},
+		Throughput:   dynamotest.DefaultThroughput,
+		GlobalIndexes: []dyc.IndexSpec{
+			{
+				Name:         "GSI1",
+				PartitionKey: dyc.KeyAttribute{Name: "GSI1PK", Type: "S"},
+				SortKey:      &dyc.KeyAttribute{Name: "GSI1SK", Type: "S"},
+			},
+		},
+	}
+
+	t.Run("should create missing table", func(t *testing.T) {
+		diff, err := cli.EnsureTable(ctx, spec)
+		require.NoError(t, err)
+		require.Len(t, diff.Changes, 1)
+		require.Equal(t, dyc.ChangeCreateTable, diff.Changes[0].Type)
+
+		schema, err := cli.TableSchema(ctx, spec.Name)
+		require.NoError(t, err)
+		require.Equal(t, []string{"GSI1PK", "GSI1SK"}, schema.IndexKeys("GSI1"))
+	})
+
+	t.Run("should be a no-op when up to date", func(t *testing.T) {
+		diff, err := cli.EnsureTable(ctx, spec)
+		require.NoError(t, err)
+		require.True(t, diff.Empty(), diff.String())
+	})
+
+	spec.GlobalIndexes = append(spec.GlobalIndexes, dyc.IndexSpec{
+		Name:         "GSI2",
+		PartitionKey: dyc.KeyAttribute{Name: "GSI2PK", Type: "S"},
+	})
+
+	t.Run("dry run should not apply changes", func(t *testing.T) {
+		diff, err := cli.EnsureTable(ctx, spec, dyc.DryRun())
+		require.NoError(t, err)
+		require.Len(t, diff.Changes, 1)
+		require.Equal(t, dyc.ChangeCreateIndex, diff.Changes[0].Type)
+
+		diff, err = cli.EnsureTable(ctx, spec, dyc.DryRun())
+		require.NoError(t, err)
+		require.Len(t, diff.Changes, 1)
+	})
+
+	t.Run("should add missing indexes", func(t *testing.T) {
+		_, err := cli.EnsureTable(ctx, spec)
+		require.NoError(t, err)
+
+		schema, err := cli.TableSchema(ctx, spec.Name)
+		require.NoError(t, err)
+		require.Equal(t, []string{"GSI2PK"}, schema.IndexKeys("GSI2"))
+	})
+}
+
+func setupClient(t *testing.T) (*dyc.Client, string) {
+	t.Helper()
+	t.Parallel()
+	table, db := dynamotest.SetupTestTable(context.Background(), t, "client", dynamotest.DefaultSchema())
+
+	return dyc.NewClient(db), table
+}
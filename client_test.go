@@ -0,0 +1,107 @@
+//go:build unit
+// +build unit
+
+package dyc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+// stubClient returns a client whose requests are answered by respond instead of dynamodb
+func stubClient(t *testing.T, respond func(op *Operation) error) *Client {
+	cli := NewClient(offlineDB(t), WithoutSchemaDiscovery())
+	var mu sync.Mutex
+	cli.Use(func(next Handler) Handler {
+		return func(ctx context.Context, op *Operation) error {
+			mu.Lock()
+			defer mu.Unlock()
+			return respond(op)
+		}
+	})
+
+	return cli
+}
+
+func TestBuilder_Count(t *testing.T) {
+	t.Run("should count query pages", func(t *testing.T) {
+		pages := 0
+		cli := stubClient(t, func(op *Operation) error {
+			input := op.Input.(*dynamodb.QueryInput)
+			require.Equal(t, dynamodb.SelectCount, aws.StringValue(input.Select))
+			require.Nil(t, input.Limit)
+			require.Nil(t, input.ProjectionExpression)
+			require.Len(t, input.ExpressionAttributeNames, 1)
+
+			pages++
+			output := op.Output.(*dynamodb.QueryOutput)
+			output.Count, output.ScannedCount = aws.Int64(2), aws.Int64(5)
+			if pages < 3 {
+				output.LastEvaluatedKey = Map{"PK": String("a")}
+			}
+			return nil
+		})
+
+		result, err := cli.Builder().
+			Table("MyTable").
+			WhereKey("PK = ?", "a").
+			Where("status = ?", "active").
+			SelectFields("name").
+			Limit(1).
+			Count(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, CountResult{Count: 6, ScannedCount: 15}, result)
+	})
+
+	t.Run("should count scan segments in parallel", func(t *testing.T) {
+		calls := make(map[int64]int)
+		cli := stubClient(t, func(op *Operation) error {
+			input := op.Input.(*dynamodb.ScanInput)
+			require.Equal(t, int64(3), aws.Int64Value(input.TotalSegments))
+			segment := aws.Int64Value(input.Segment)
+			calls[segment]++
+
+			output := op.Output.(*dynamodb.ScanOutput)
+			output.Count, output.ScannedCount = aws.Int64(1), aws.Int64(2)
+			if calls[segment] < 2 {
+				output.LastEvaluatedKey = Map{"PK": String("a")}
+			}
+			return nil
+		})
+
+		result, err := cli.Builder().Table("MyTable").Segments(3).Count(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, CountResult{Count: 6, ScannedCount: 12}, result)
+		require.Equal(t, map[int64]int{0: 2, 1: 2, 2: 2}, calls)
+	})
+
+	t.Run("should propagate errors", func(t *testing.T) {
+		fault := errors.New("boom")
+		cli := stubClient(t, func(op *Operation) error {
+			if input, ok := op.Input.(*dynamodb.ScanInput); ok && aws.Int64Value(input.Segment) == 1 {
+				return fault
+			}
+			output := op.Output.(*dynamodb.ScanOutput)
+			output.Count = aws.Int64(1)
+			return nil
+		})
+
+		_, err := cli.Builder().Table("MyTable").Count(context.Background())
+		require.ErrorIs(t, err, fault)
+
+		_, err = cli.ScanCount(context.Background(), &dynamodb.ScanInput{TableName: aws.String("MyTable"), Segment: aws.Int64(1), TotalSegments: aws.Int64(2)})
+		require.ErrorIs(t, err, fault)
+	})
+}
+
+func TestUsedNames(t *testing.T) {
+	names := map[string]*string{"#1": aws.String("status"), "#10": aws.String("name")}
+	require.Equal(t, map[string]*string{"#1": aws.String("status")}, usedNames(names, aws.String("#1 = :0"), nil))
+	require.Nil(t, usedNames(names, aws.String("PK = :0")))
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/darwayne/dyc"
+)
+
+var (
+	// errBadValue occurs if a typed value can't be parsed
+	errBadValue = errors.New("invalid value")
+	// errBadPair occurs if a NAME=VALUE flag has no =
+	errBadPair = errors.New("expected NAME=VALUE")
+)
+
+// multiFlag collects the values of a flag that can be repeated
+type multiFlag []string
+
+func (m *multiFlag) String() string {
+	return strings.Join(*m, ", ")
+}
+
+func (m *multiFlag) Set(val string) error {
+	*m = append(*m, val)
+	return nil
+}
+
+// parseValue converts a command line value to an input of the builder.
+// values are strings unless prefixed with a type:
+//
+//	n:10          number
+//	bool:true     bool
+//	null:         null
+//	json:[1, 2]   any json value, numbers keep their precision
+//	s:n:10        the string n:10
+func parseValue(raw string) (interface{}, error) {
+	prefix, val, found := strings.Cut(raw, ":")
+	if !found {
+		return raw, nil
+	}
+
+	switch prefix {
+	case "s":
+		return val, nil
+	case "n":
+		if _, err := strconv.ParseFloat(val, 64); err != nil {
+			return nil, errBadValue
+		}
+		return &dynamodb.AttributeValue{N: aws.String(val)}, nil
+	case "bool":
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, errBadValue
+		}
+		return b, nil
+	case "null":
+		return &dynamodb.AttributeValue{NULL: aws.Bool(true)}, nil
+	case "json":
+		item, err := dyc.UnmarshalItem(dyc.JSONLines, []byte(`{"v":`+val+`}`))
+		if err != nil {
+			return nil, errBadValue
+		}
+		return item["v"], nil
+	}
+
+	return raw, nil
+}
+
+// parseValues converts values via parseValue
+func parseValues(raw []string) ([]interface{}, error) {
+	result := make([]interface{}, 0, len(raw))
+	for _, r := range raw {
+		val, err := parseValue(r)
+		if err != nil {
+			return nil, errors.New(err.Error() + ": " + r)
+		}
+		result = append(result, val)
+	}
+
+	return result, nil
+}
+
+// parsePairs converts NAME=VALUE flags, values are parsed via parseValue
+func parsePairs(raw []string) ([]string, []interface{}, error) {
+	names := make([]string, 0, len(raw))
+	vals := make([]interface{}, 0, len(raw))
+	for _, r := range raw {
+		name, val, found := strings.Cut(r, "=")
+		if !found || name == "" {
+			return nil, nil, errors.New(errBadPair.Error() + ": " + r)
+		}
+		parsed, err := parseValue(val)
+		if err != nil {
+			return nil, nil, errors.New(err.Error() + ": " + r)
+		}
+		names = append(names, name)
+		vals = append(vals, parsed)
+	}
+
+	return names, vals, nil
+}
+
+// parseParams converts NAME=VALUE flags to params for named placeholders
+func parseParams(raw []string) (dyc.Params, error) {
+	names, vals, err := parsePairs(raw)
+	if err != nil || len(names) == 0 {
+		return nil, err
+	}
+
+	params := make(dyc.Params, len(names))
+	for idx, name := range names {
+		params[name] = vals[idx]
+	}
+
+	return params, nil
+}
+
+// splitArgs hands out the values for the ? placeholders of expressions in the order the expressions are added
+type splitArgs struct {
+	vals   []interface{}
+	params dyc.Params
+}
+
+// next returns the inputs of an expression: one value per ? placeholder followed by the params if any were set.
+// question marks inside quoted names aren't placeholders
+func (a *splitArgs) next(expr string) ([]interface{}, error) {
+	count := dyc.CountPlaceholders(expr)
+	if count > len(a.vals) {
+		return nil, dyc.ErrQueryMisMatch
+	}
+
+	inputs := append([]interface{}(nil), a.vals[:count]...)
+	a.vals = a.vals[count:]
+	if a.params != nil {
+		inputs = append(inputs, a.params)
+	}
+
+	return inputs, nil
+}
+
+// done returns an error if values were left over
+func (a *splitArgs) done() error {
+	if len(a.vals) > 0 {
+		return dyc.ErrQueryMisMatch
+	}
+
+	return nil
+}
+
+// parseInterspersed parses flags that may follow positional arguments e.g copy src dst --workers 40
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		if args[0] == "--" {
+			return append(positional, args[1:]...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// parseFormat converts a format name to a dyc.Format
+func parseFormat(name string) (dyc.Format, error) {
+	for _, format := range []dyc.Format{dyc.DynamoDBJSON, dyc.JSONLines, dyc.CSV} {
+		if format.String() == name {
+			return format, nil
+		}
+	}
+
+	return 0, dyc.ErrUnsupportedFormat
+}
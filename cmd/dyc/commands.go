@@ -0,0 +1,393 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/darwayne/dyc"
+)
+
+func runQuery(ctx context.Context, e env, args []string) error {
+	fs := newFlagSet(e, "query", "query --table T --key EXPR [--where EXPR] [--arg VALUE...]")
+	var f builderFlags
+	f.register(fs, true)
+	f.registerPaging(fs)
+	output := fs.String("output", dyc.JSONLines.String(), "output format: jsonl or dynamodb-json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if f.key == "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	w, err := newItemWriter(e.stdout, *output)
+	if err != nil {
+		return err
+	}
+	cli, err := f.conn.client()
+	if err != nil {
+		return err
+	}
+	b, err := f.builder(cli)
+	if err != nil {
+		return err
+	}
+
+	return w.flush(b.QueryIterate(ctx, func(output *dynamodb.QueryOutput) error {
+		return w.write(output.Items...)
+	}))
+}
+
+func runScan(ctx context.Context, e env, args []string) error {
+	fs := newFlagSet(e, "scan", "scan --table T [--where EXPR] [--arg VALUE...]")
+	var f builderFlags
+	f.register(fs, false)
+	fs.IntVar(&f.limit, "limit", 0, "maximum amount of items, only applies without segments")
+	segments := fs.Int("segments", 1, "amount of segments scanned in parallel")
+	output := fs.String("output", dyc.JSONLines.String(), "output format: jsonl or dynamodb-json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	w, err := newItemWriter(e.stdout, *output)
+	if err != nil {
+		return err
+	}
+	cli, err := f.conn.client()
+	if err != nil {
+		return err
+	}
+	b, err := f.builder(cli)
+	if err != nil {
+		return err
+	}
+
+	write := func(output *dynamodb.ScanOutput) error {
+		return w.write(output.Items...)
+	}
+	if *segments > 1 {
+		return w.flush(b.ParallelScanIterate(ctx, *segments, write, false))
+	}
+
+	return w.flush(b.ScanIterate(ctx, write))
+}
+
+func runGet(ctx context.Context, e env, args []string) error {
+	fs := newFlagSet(e, "get", "get --table T --item-key NAME=VALUE [--item-key NAME=VALUE]")
+	var conn connFlags
+	conn.register(fs)
+	table := fs.String("table", "", "table name (required)")
+	var keys multiFlag
+	fs.Var(&keys, "item-key", "NAME=VALUE of a key attribute, repeat for the sort key")
+	consistent := fs.Bool("consistent", false, "use a strongly consistent read")
+	output := fs.String("output", dyc.JSONLines.String(), "output format: jsonl or dynamodb-json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *table == "" || len(keys) == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	names, vals, err := parsePairs(keys)
+	if err != nil {
+		return err
+	}
+	w, err := newItemWriter(e.stdout, *output)
+	if err != nil {
+		return err
+	}
+	cli, err := conn.client()
+	if err != nil {
+		return err
+	}
+
+	additional := make([]interface{}, 0, 2*(len(names)-1))
+	for idx := 1; idx < len(names); idx++ {
+		additional = append(additional, names[idx], vals[idx])
+	}
+	out, err := cli.Builder().Table(*table).Key(names[0], vals[0], additional...).ConsistentRead(*consistent).GetItem(ctx)
+	if err != nil {
+		return err
+	}
+	if out.Item == nil {
+		return errors.New("item not found")
+	}
+
+	return w.flush(w.write(out.Item))
+}
+
+func runPut(ctx context.Context, e env, args []string) error {
+	fs := newFlagSet(e, "put", "put --table T --item JSON [--condition EXPR] [--arg VALUE...]")
+	var conn connFlags
+	conn.register(fs)
+	table := fs.String("table", "", "table name (required)")
+	item := fs.String("item", "", "item as json, read from stdin if omitted")
+	input := fs.String("input", dyc.JSONLines.String(), "item format: jsonl or dynamodb-json")
+	condition := fs.String("condition", "", "condition expression e.g attribute_not_exists(PK)")
+	var argFlags, paramFlags multiFlag
+	fs.Var(&argFlags, "arg", "value of the next ? placeholder of the condition, repeatable")
+	fs.Var(&paramFlags, "param", "NAME=VALUE of a :name placeholder, repeatable")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *table == "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	format, err := parseFormat(*input)
+	if err != nil {
+		return err
+	}
+	data := []byte(*item)
+	if *item == "" {
+		var buf json.RawMessage
+		if err := json.NewDecoder(e.stdin).Decode(&buf); err != nil {
+			return err
+		}
+		data = buf
+	}
+	parsed, err := dyc.UnmarshalItem(format, data)
+	if err != nil {
+		return err
+	}
+	cli, err := conn.client()
+	if err != nil {
+		return err
+	}
+
+	b := cli.Builder().Table(*table)
+	if *condition != "" {
+		vals, err := parseValues(argFlags)
+		if err != nil {
+			return err
+		}
+		params, err := parseParams(paramFlags)
+		if err != nil {
+			return err
+		}
+		conditionArgs := &splitArgs{vals: vals, params: params}
+		inputs, err := conditionArgs.next(*condition)
+		if err != nil {
+			return err
+		}
+		if err := conditionArgs.done(); err != nil {
+			return err
+		}
+		b.Condition(*condition, inputs...)
+	}
+	_, err = b.PutItem(ctx, parsed)
+
+	return err
+}
+
+func runDeleteByQuery(ctx context.Context, e env, args []string) error {
+	fs := newFlagSet(e, "delete-by-query", "delete-by-query --table T --key EXPR [--where EXPR] [--arg VALUE...] --yes")
+	var f builderFlags
+	f.register(fs, true)
+	yes := fs.Bool("yes", false, "delete the matching items, without it the items that would be deleted are only counted")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if f.key == "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	cli, err := f.conn.client()
+	if err != nil {
+		return err
+	}
+	b, err := f.builder(cli)
+	if err != nil {
+		return err
+	}
+
+	if !*yes {
+		result, err := b.Count(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(e.stderr, "would delete %d items, rerun with --yes to delete them\n", result.Count)
+		return nil
+	}
+
+	return b.QueryDelete(ctx)
+}
+
+func runCopy(ctx context.Context, e env, args []string) error {
+	fs := newFlagSet(e, "copy", "copy SOURCE DESTINATION [--workers N]")
+	var conn connFlags
+	conn.register(fs)
+	workers := fs.Int("workers", 10, "amount of segments scanned and written in parallel")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 || *workers < 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	cli, err := conn.client()
+	if err != nil {
+		return err
+	}
+
+	return cli.CopyTable(ctx, positional[1], positional[0], *workers, nil)
+}
+
+func runCount(ctx context.Context, e env, args []string) error {
+	fs := newFlagSet(e, "count", "count --table T [--key EXPR] [--where EXPR] [--arg VALUE...]")
+	var f builderFlags
+	f.register(fs, true)
+	segments := fs.Int("segments", dyc.DefaultScanSegments, "amount of segments scanned in parallel without --key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cli, err := f.conn.client()
+	if err != nil {
+		return err
+	}
+	b, err := f.builder(cli)
+	if err != nil {
+		return err
+	}
+
+	result, err := b.Segments(*segments).Count(ctx)
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(e.stdout).Encode(result)
+}
+
+func runExport(ctx context.Context, e env, args []string) error {
+	fs := newFlagSet(e, "export", "export --table T [--out FILE] [--format dynamodb-json|jsonl] [--gzip]")
+	var f builderFlags
+	f.register(fs, false)
+	out := fs.String("out", "", "file to write, stdout if omitted")
+	format := fs.String("format", dyc.DynamoDBJSON.String(), "dynamodb-json or jsonl")
+	gzip := fs.Bool("gzip", false, "compress the export with gzip")
+	segments := fs.Int("segments", dyc.DefaultExportSegments, "amount of segments scanned in parallel")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	exportFormat, err := parseFormat(*format)
+	if err != nil {
+		return err
+	}
+	cli, err := f.conn.client()
+	if err != nil {
+		return err
+	}
+	b, err := f.builder(cli)
+	if err != nil {
+		return err
+	}
+
+	opts := []dyc.ExportOption{dyc.ExportFilter(b), dyc.ExportSegments(*segments)}
+	if *gzip {
+		opts = append(opts, dyc.ExportGzip())
+	}
+
+	w := e.stdout
+	var file *os.File
+	if *out != "" {
+		if file, err = os.Create(*out); err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	exported, err := cli.ExportTable(ctx, f.table, w, exportFormat, opts...)
+	if err != nil {
+		return err
+	}
+	if file != nil {
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(e.stderr, "exported %d items\n", exported)
+
+	return nil
+}
+
+func runImport(ctx context.Context, e env, args []string) error {
+	fs := newFlagSet(e, "import", "import --table T [--in FILE] [--format dynamodb-json|jsonl|csv] [--gzip]")
+	var conn connFlags
+	conn.register(fs)
+	table := fs.String("table", "", "table name (required)")
+	in := fs.String("in", "", "file to read, stdin if omitted")
+	format := fs.String("format", dyc.DynamoDBJSON.String(), "dynamodb-json, jsonl or csv")
+	gzip := fs.Bool("gzip", false, "decompress the input with gzip")
+	workers := fs.Int("workers", dyc.DefaultImportWorkers, "amount of batches written concurrently")
+	var types multiFlag
+	fs.Var(&types, "type", "COLUMN=TYPE of a csv column (S, N, BOOL, B or JSON), repeatable")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *table == "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	importFormat, err := parseFormat(*format)
+	if err != nil {
+		return err
+	}
+	opts := []dyc.ImportOption{dyc.ImportWorkers(*workers)}
+	if *gzip {
+		opts = append(opts, dyc.ImportGzip())
+	}
+	if len(types) > 0 {
+		columnTypes := make(map[string]string, len(types))
+		for _, pair := range types {
+			column, typ, found := strings.Cut(pair, "=")
+			if !found || column == "" {
+				return errors.New(errBadPair.Error() + ": " + pair)
+			}
+			columnTypes[column] = strings.ToUpper(typ)
+		}
+		opts = append(opts, dyc.ImportColumnTypes(columnTypes))
+	}
+
+	r := e.stdin
+	if *in != "" {
+		file, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+	cli, err := conn.client()
+	if err != nil {
+		return err
+	}
+
+	result, err := cli.ImportTable(ctx, *table, r, importFormat, opts...)
+	for _, rejected := range result.Rejected {
+		fmt.Fprintf(e.stderr, "line %d: %v\n", rejected.Line, rejected.Err)
+	}
+	fmt.Fprintf(e.stderr, "imported %d items, rejected %d rows\n", result.Imported, len(result.Rejected))
+	if err != nil {
+		return err
+	}
+	if len(result.Rejected) > 0 {
+		return errors.New("some rows were rejected")
+	}
+
+	return nil
+}
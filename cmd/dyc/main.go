@@ -0,0 +1,282 @@
+// Command dyc runs dynamodb operations from the command line using the expression syntax of dyc.Builder.
+//
+// Usage:
+//
+//	dyc query --table T --key "PK = ?" --arg user#1 --where "'status' = ?" --arg open
+//	dyc scan --table T --where "begins_with(SK, ?)" --arg order# --segments 8
+//	dyc get --table T --item-key PK=user#1 --item-key SK=profile
+//	dyc put --table T --item '{"PK":"user#1","SK":"profile","Age":30}'
+//	dyc delete-by-query --table T --key "PK = ?" --arg user#1 --yes
+//	dyc copy src dst --workers 40
+//	dyc count --table T --where "Age > ?" --arg n:21
+//	dyc export --table T --out backup.json.gz --gzip
+//	dyc import --table T --in backup.json.gz --gzip
+//
+// values passed via --arg replace the ? placeholders of --key, --where and --condition in the order the flags
+// are given and are strings unless prefixed with a type e.g n:10, bool:true, null: or json:[1,2].
+// named placeholders are set via --param name=value.
+// use --endpoint http://localhost:8000 to connect to DynamoDB Local
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/darwayne/dyc"
+)
+
+// errUsage occurs if a command was called with invalid arguments, the usage of the command has been printed
+var errUsage = errors.New("usage")
+
+// env contains the streams of a command
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, e env, args []string) error
+}
+
+var commands = map[string]command{
+	"query":           {"query items matching a key condition", runQuery},
+	"scan":            {"scan items, optionally in parallel segments", runScan},
+	"get":             {"get a single item by its key", runGet},
+	"put":             {"put a single item", runPut},
+	"delete-by-query": {"delete all items matching a query", runDeleteByQuery},
+	"copy":            {"copy all items of a table to another table", runCopy},
+	"count":           {"count items matching a query or scan", runCount},
+	"export":          {"export a table to dynamodb json or json lines", runExport},
+	"import":          {"import dynamodb json, json lines or csv into a table", runImport},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := run(ctx, env{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}, os.Args[1:])
+	switch {
+	case errors.Is(err, errUsage), errors.Is(err, flag.ErrHelp):
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "dyc:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, e env, args []string) error {
+	if len(args) == 0 {
+		printUsage(e.stderr)
+		return errUsage
+	}
+	cmd, found := commands[args[0]]
+	if !found {
+		if args[0] != "help" && args[0] != "-h" && args[0] != "--help" {
+			fmt.Fprintf(e.stderr, "dyc: unknown command %q\n", args[0])
+		}
+		printUsage(e.stderr)
+		return errUsage
+	}
+
+	return cmd.run(ctx, e, args[1:])
+}
+
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "usage: dyc <command> [flags]")
+	fmt.Fprintln(w, "\ncommands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-16s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintln(w, "\nrun dyc <command> -h for the flags of a command")
+}
+
+// newFlagSet creates the flags of a command, errors are reported by the command instead of exiting
+func newFlagSet(e env, name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "usage: dyc %s\n\nflags:\n", usage)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// connFlags configure the connection to dynamodb
+type connFlags struct {
+	endpoint string
+	region   string
+	profile  string
+}
+
+func (f *connFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.endpoint, "endpoint", "", "dynamodb endpoint e.g http://localhost:8000 for DynamoDB Local")
+	fs.StringVar(&f.region, "region", "", "aws region, defaults to the region of the environment or profile")
+	fs.StringVar(&f.profile, "profile", "", "aws shared config profile")
+}
+
+func (f *connFlags) client() (*dyc.Client, error) {
+	cfg := aws.Config{}
+	if f.endpoint != "" {
+		cfg.Endpoint = aws.String(f.endpoint)
+	}
+	if f.region != "" {
+		cfg.Region = aws.String(f.region)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            cfg,
+		Profile:           f.profile,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dyc.NewClient(dynamodb.New(sess)), nil
+}
+
+// builderFlags configure the builder of query, scan, count, delete-by-query and export
+type builderFlags struct {
+	conn       connFlags
+	table      string
+	index      string
+	key        string
+	where      multiFlag
+	args       multiFlag
+	params     multiFlag
+	fields     string
+	limit      int
+	descending bool
+	consistent bool
+}
+
+func (f *builderFlags) register(fs *flag.FlagSet, withKey bool) {
+	f.conn.register(fs)
+	fs.StringVar(&f.table, "table", "", "table name (required)")
+	fs.StringVar(&f.index, "index", "", "index name")
+	if withKey {
+		fs.StringVar(&f.key, "key", "", "key condition expression e.g \"PK = ? AND begins_with(SK, ?)\"")
+	}
+	fs.Var(&f.where, "where", "filter expression, repeat to combine with AND")
+	fs.Var(&f.args, "arg", "value of the next ? placeholder, repeatable. typed via n:, bool:, null:, json: or s: prefixes")
+	fs.Var(&f.params, "param", "NAME=VALUE of a :name placeholder, repeatable")
+	fs.StringVar(&f.fields, "select", "", "comma separated attributes to retrieve")
+	fs.BoolVar(&f.consistent, "consistent", false, "use strongly consistent reads")
+}
+
+func (f *builderFlags) registerPaging(fs *flag.FlagSet) {
+	fs.IntVar(&f.limit, "limit", 0, "maximum amount of items")
+	fs.BoolVar(&f.descending, "desc", false, "return items in descending sort key order")
+}
+
+// builder builds the query described by the flags
+func (f *builderFlags) builder(cli *dyc.Client) (*dyc.Builder, error) {
+	if f.table == "" {
+		return nil, errors.New("--table is required")
+	}
+	vals, err := parseValues(f.args)
+	if err != nil {
+		return nil, err
+	}
+	params, err := parseParams(f.params)
+	if err != nil {
+		return nil, err
+	}
+	args := &splitArgs{vals: vals, params: params}
+
+	b := cli.Builder().Table(f.table)
+	if f.index != "" {
+		b.Index(f.index)
+	}
+	if f.key != "" {
+		inputs, err := args.next(f.key)
+		if err != nil {
+			return nil, err
+		}
+		b.WhereKey(f.key, inputs...)
+	}
+	for _, where := range f.where {
+		inputs, err := args.next(where)
+		if err != nil {
+			return nil, err
+		}
+		b.Where(where, inputs...)
+	}
+	if err := args.done(); err != nil {
+		return nil, err
+	}
+	if f.fields != "" {
+		b.SelectFields(strings.Split(f.fields, ",")...)
+	}
+	if f.limit > 0 {
+		b.Limit(f.limit)
+	}
+	if f.descending {
+		b.Descending()
+	}
+	if f.consistent {
+		b.ConsistentRead(true)
+	}
+
+	_, err = b.ToScan()
+
+	return b, err
+}
+
+// itemWriter writes items one per line in the output format
+type itemWriter struct {
+	w      *bufio.Writer
+	format dyc.Format
+}
+
+func newItemWriter(w io.Writer, name string) (*itemWriter, error) {
+	format, err := parseFormat(name)
+	if err != nil || format == dyc.CSV {
+		return nil, dyc.ErrUnsupportedFormat
+	}
+
+	return &itemWriter{w: bufio.NewWriter(w), format: format}, nil
+}
+
+func (w *itemWriter) write(items ...dyc.Map) error {
+	for _, item := range items {
+		line, err := dyc.MarshalItem(w.format, item)
+		if err != nil {
+			return err
+		}
+		if _, err := w.w.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flush writes the buffered items, err is returned if set so items written before a failure are still printed
+func (w *itemWriter) flush(err error) error {
+	if flushErr := w.w.Flush(); err == nil {
+		err = flushErr
+	}
+
+	return err
+}
@@ -0,0 +1,188 @@
+//go:build unit
+// +build unit
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/darwayne/dyc"
+)
+
+func TestParseValue(t *testing.T) {
+	for raw, expected := range map[string]interface{}{
+		"user#1":         "user#1",
+		"s:n:10":         "n:10",
+		"n:10.5":         &dynamodb.AttributeValue{N: aws.String("10.5")},
+		"bool:true":      true,
+		"null:":          &dynamodb.AttributeValue{NULL: aws.Bool(true)},
+		"json:[1,\"a\"]": &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{{N: aws.String("1")}, dyc.String("a")}},
+		"other:value":    "other:value",
+	} {
+		val, err := parseValue(raw)
+		require.NoError(t, err, raw)
+		require.Equal(t, expected, val, raw)
+	}
+
+	for _, raw := range []string{"n:abc", "bool:maybe", "json:{"} {
+		_, err := parseValue(raw)
+		require.ErrorIs(t, err, errBadValue, raw)
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	args := &splitArgs{vals: []interface{}{"a", "b", "c"}, params: dyc.Params{"status": "open"}}
+
+	inputs, err := args.next("PK = ? AND SK > ?")
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"a", "b", dyc.Params{"status": "open"}}, inputs)
+	require.ErrorIs(t, args.done(), dyc.ErrQueryMisMatch)
+
+	_, err = args.next("A = ? AND B = ?")
+	require.ErrorIs(t, err, dyc.ErrQueryMisMatch)
+
+	inputs, err = args.next("'a?b' = ?")
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"c", dyc.Params{"status": "open"}}, inputs)
+	require.NoError(t, args.done())
+}
+
+func TestParseInterspersed(t *testing.T) {
+	fs := flag.NewFlagSet("copy", flag.ContinueOnError)
+	workers := fs.Int("workers", 1, "")
+	positional, err := parseInterspersed(fs, []string{"src", "dst", "--workers", "40"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"src", "dst"}, positional)
+	require.Equal(t, 40, *workers)
+
+	positional, err = parseInterspersed(fs, []string{"--workers", "2", "src", "--", "--dst"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"src", "--dst"}, positional)
+	require.Equal(t, 2, *workers)
+}
+
+// fakeDynamo serves dynamodb requests via respond, which receives the operation name and the decoded request.
+// tables are described with the keys PK and SK
+func fakeDynamo(t *testing.T, respond func(op string, req map[string]interface{}) interface{}) string {
+	t.Setenv("AWS_ACCESS_KEY_ID", "id")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var req map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		op := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "DynamoDB_20120810.")
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		if op == "DescribeTable" {
+			_, _ = w.Write([]byte(`{"Table":{"TableName":"T","KeySchema":[` +
+				`{"AttributeName":"PK","KeyType":"HASH"},{"AttributeName":"SK","KeyType":"RANGE"}]}}`))
+			return
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(respond(op, req)))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv.URL
+}
+
+func runCLI(t *testing.T, endpoint, stdin string, args ...string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	args = append(args, "--endpoint", endpoint, "--region", "us-east-1")
+	err := run(context.Background(), env{stdin: strings.NewReader(stdin), stdout: &stdout, stderr: &stderr}, args)
+
+	return stdout.String(), stderr.String(), err
+}
+
+func TestRun(t *testing.T) {
+	t.Run("query should split args between the key condition and filters", func(t *testing.T) {
+		endpoint := fakeDynamo(t, func(op string, req map[string]interface{}) interface{} {
+			require.Equal(t, "Query", op)
+			require.Equal(t, "(PK = :0)", req["KeyConditionExpression"])
+			require.Equal(t, "(#1 = :1) AND (Age > :2)", req["FilterExpression"])
+			require.Equal(t, map[string]interface{}{
+				":0": map[string]interface{}{"S": "user#1"},
+				":1": map[string]interface{}{"S": "open"},
+				":2": map[string]interface{}{"N": "21"},
+			}, req["ExpressionAttributeValues"])
+			return map[string]interface{}{
+				"Items": []interface{}{map[string]interface{}{"PK": map[string]interface{}{"S": "user#1"}, "Age": map[string]interface{}{"N": "30"}}},
+			}
+		})
+
+		stdout, _, err := runCLI(t, endpoint, "", "query", "--table", "T",
+			"--key", "PK = ?", "--arg", "user#1",
+			"--where", "status = ?", "--arg", "open",
+			"--where", "Age > ?", "--arg", "n:21")
+		require.NoError(t, err)
+		require.Equal(t, `{"Age":30,"PK":"user#1"}`+"\n", stdout)
+	})
+
+	t.Run("put should read the item from stdin", func(t *testing.T) {
+		endpoint := fakeDynamo(t, func(op string, req map[string]interface{}) interface{} {
+			require.Equal(t, "PutItem", op)
+			require.Equal(t, map[string]interface{}{
+				"PK":  map[string]interface{}{"S": "user#1"},
+				"Age": map[string]interface{}{"N": "30"},
+			}, req["Item"])
+			require.Equal(t, "(attribute_not_exists(PK))", req["ConditionExpression"])
+			return map[string]interface{}{}
+		})
+
+		_, _, err := runCLI(t, endpoint, `{"PK":"user#1","Age":30}`, "put", "--table", "T", "--condition", "attribute_not_exists(PK)")
+		require.NoError(t, err)
+	})
+
+	t.Run("get should fail if the item doesn't exist", func(t *testing.T) {
+		endpoint := fakeDynamo(t, func(op string, req map[string]interface{}) interface{} {
+			require.Equal(t, "GetItem", op)
+			require.Equal(t, map[string]interface{}{
+				"PK": map[string]interface{}{"S": "user#1"},
+				"SK": map[string]interface{}{"N": "1"},
+			}, req["Key"])
+			return map[string]interface{}{}
+		})
+
+		_, _, err := runCLI(t, endpoint, "", "get", "--table", "T", "--item-key", "PK=user#1", "--item-key", "SK=n:1")
+		require.EqualError(t, err, "item not found")
+	})
+
+	t.Run("delete-by-query should only count without --yes", func(t *testing.T) {
+		endpoint := fakeDynamo(t, func(op string, req map[string]interface{}) interface{} {
+			require.Equal(t, "Query", op)
+			require.Equal(t, dynamodb.SelectCount, req["Select"])
+			return map[string]interface{}{"Count": 3, "ScannedCount": 3}
+		})
+
+		_, stderr, err := runCLI(t, endpoint, "", "delete-by-query", "--table", "T", "--key", "PK = ?", "--arg", "a")
+		require.NoError(t, err)
+		require.Contains(t, stderr, "would delete 3 items")
+	})
+
+	t.Run("should report usage errors", func(t *testing.T) {
+		var stderr bytes.Buffer
+		err := run(context.Background(), env{stdout: io.Discard, stderr: &stderr}, []string{"nope"})
+		require.ErrorIs(t, err, errUsage)
+		require.Contains(t, stderr.String(), `unknown command "nope"`)
+
+		err = run(context.Background(), env{stdout: io.Discard, stderr: &stderr}, []string{"query", "--table", "T"})
+		require.ErrorIs(t, err, errUsage)
+
+		_, _, err = runCLI(t, "http://127.0.0.1:1", "", "scan", "--table", "T", "--where", "A = ?")
+		require.ErrorIs(t, err, dyc.ErrQueryMisMatch)
+	})
+}
@@ -0,0 +1,137 @@
+package dyc
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/pkg/errors"
+)
+
+// DefaultCounterAttribute is the attribute counters are stored in unless WithCounterAttribute is provided
+const DefaultCounterAttribute = "CounterValue"
+
+// Counter is an atomic counter stored in a single item
+type Counter struct {
+	client    *Client
+	table     string
+	key       Map
+	attribute string
+}
+
+// CounterOption allows you to configure a counter
+type CounterOption func(c *Counter)
+
+// WithCounterAttribute sets the attribute the counter value is stored in
+func WithCounterAttribute(name string) CounterOption {
+	return func(c *Counter) {
+		c.attribute = name
+	}
+}
+
+// Counter returns an atomic counter stored in the item with the provided key.
+// the item is created on the first increment
+// e.g cli.Counter("MyTable", dyc.Map{"PK": dyc.String("counter#orders"), "SK": dyc.String("counter#orders")})
+func (c *Client) Counter(table string, key Map, opts ...CounterOption) *Counter {
+	counter := &Counter{
+		client:    c,
+		table:     table,
+		key:       key,
+		attribute: DefaultCounterAttribute,
+	}
+	for _, opt := range opts {
+		opt(counter)
+	}
+
+	return counter
+}
+
+// Incr atomically adds n to the counter and returns the new value. n can be negative
+func (c *Counter) Incr(ctx context.Context, n int64) (int64, error) {
+	output, err := c.builder().
+		Update(`ADD `+quoteName(c.attribute)+` ?`, n).
+		Return(dynamodb.ReturnValueUpdatedNew).
+		UpdateItem(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return counterValue(output.Attributes[c.attribute])
+}
+
+// Get returns the current value of the counter. counters that were never incremented are 0
+func (c *Counter) Get(ctx context.Context) (int64, error) {
+	output, err := c.builder().
+		ConsistentRead(true).
+		GetItem(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return counterValue(output.Item[c.attribute])
+}
+
+func (c *Counter) builder() *Builder {
+	b := c.client.Builder().Table(c.table)
+	for k, v := range c.key {
+		b.keys[k] = v
+	}
+
+	return b
+}
+
+// counterValue parses a counter attribute, missing attributes are treated as 0
+func counterValue(val *dynamodb.AttributeValue) (int64, error) {
+	if val == nil || val.N == nil {
+		return 0, nil
+	}
+
+	num, err := strconv.ParseInt(aws.StringValue(val.N), 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid counter value")
+	}
+
+	return num, nil
+}
+
+// Sequence hands out increasing ids backed by a counter.
+// ids are reserved from the counter in blocks so most calls to Next don't make a network call.
+// ids from a block that isn't fully used are skipped, so sequences are unique but may have gaps
+type Sequence struct {
+	counter   *Counter
+	blockSize int64
+	mu        sync.Mutex
+	// next is the next id to hand out, a new block is reserved once it passes limit
+	next  int64
+	limit int64
+}
+
+// Sequence returns a sequence that reserves blockSize ids at a time from the counter
+func (c *Counter) Sequence(blockSize int64) *Sequence {
+	if blockSize < 1 {
+		blockSize = 1
+	}
+
+	return &Sequence{counter: c, blockSize: blockSize, next: 1}
+}
+
+// Next returns the next id of the sequence, reserving a new block if the current one is used up
+func (s *Sequence) Next(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.next > s.limit {
+		limit, err := s.counter.Incr(ctx, s.blockSize)
+		if err != nil {
+			return 0, err
+		}
+		s.next, s.limit = limit-s.blockSize+1, limit
+	}
+
+	id := s.next
+	s.next++
+
+	return id, nil
+}
@@ -0,0 +1,78 @@
+//go:build integration
+// +build integration
+
+package dyc_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/darwayne/dyc"
+)
+
+func TestCounter_Integration(t *testing.T) {
+	t.Run("Incr", func(t *testing.T) {
+		cli, table := setupClient(t)
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		counter := cli.Counter(table, dyc.Map{"PK": dyc.String("counter"), "SK": dyc.String("orders")})
+		val, err := counter.Get(ctx)
+		require.NoError(t, err)
+		require.Zero(t, val)
+
+		val, err = counter.Incr(ctx, 5)
+		require.NoError(t, err)
+		require.EqualValues(t, 5, val)
+
+		val, err = counter.Incr(ctx, -2)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, val)
+
+		val, err = counter.Get(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, val)
+	})
+
+	t.Run("Sequence", func(t *testing.T) {
+		cli, table := setupClient(t)
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		counter := cli.Counter(table, dyc.Map{"PK": dyc.String("counter"), "SK": dyc.String("ids")})
+		sequences := []*dyc.Sequence{counter.Sequence(10), counter.Sequence(10)}
+
+		var mu sync.Mutex
+		seen := make(map[int64]bool)
+		var wg sync.WaitGroup
+		for _, seq := range sequences {
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func(seq *dyc.Sequence) {
+					defer wg.Done()
+					for j := 0; j < 5; j++ {
+						id, err := seq.Next(ctx)
+						if !assert.NoError(t, err) {
+							return
+						}
+						mu.Lock()
+						assert.False(t, seen[id], "duplicate id %d", id)
+						seen[id] = true
+						mu.Unlock()
+					}
+				}(seq)
+			}
+		}
+		wg.Wait()
+
+		require.Len(t, seen, 50)
+		val, err := counter.Get(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 60, val)
+	})
+}
@@ -0,0 +1,37 @@
+//go:build unit
+// +build unit
+
+package dyc
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounter(t *testing.T) {
+	t.Run("builder", func(t *testing.T) {
+		counter := NewClient(nil).Counter("MyTable", Map{"PK": String("orders")}, WithCounterAttribute("Total"))
+		input, err := counter.builder().Update(`ADD 'Total' ?`, 5).ToUpdate()
+		require.NoError(t, err)
+		require.Equal(t, "MyTable", aws.StringValue(input.TableName))
+		require.Equal(t, Map{"PK": String("orders")}, input.Key)
+		require.Equal(t, "ADD #1 :0", aws.StringValue(input.UpdateExpression))
+		require.Equal(t, "Total", aws.StringValue(input.ExpressionAttributeNames["#1"]))
+	})
+
+	t.Run("counterValue", func(t *testing.T) {
+		val, err := counterValue(nil)
+		require.NoError(t, err)
+		require.Zero(t, val)
+
+		val, err = counterValue(&dynamodb.AttributeValue{N: aws.String("42")})
+		require.NoError(t, err)
+		require.EqualValues(t, 42, val)
+
+		_, err = counterValue(&dynamodb.AttributeValue{N: aws.String("4.2")})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,317 @@
+// Package election provides leader election backed by a dynamodb table.
+//
+// Candidates campaign for leadership by conditionally writing a lease to a shared item.
+// Every successful election increments the term of the item, so terms can be used as fencing tokens.
+// The leader renews its lease while elected and steps down gracefully once its context is canceled.
+// The context passed to OnElected is canceled once the lease expires without being renewed,
+// so work can be stopped before another candidate is elected.
+//
+// note: lease expiration is compared using the local clock of each process,
+// so lease durations should be comfortably larger than expected clock skew
+package election
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/darwayne/dyc"
+	"github.com/darwayne/dyc/internal/coordination"
+)
+
+// Leader describes the current leader of an election
+type Leader struct {
+	ID             string
+	Term           int64
+	LeaseExpiresAt time.Time
+}
+
+// state is the election item stored in dynamodb
+type state struct {
+	Leader         string
+	Term           int64
+	LeaseExpiresAt int64
+}
+
+// Election campaigns for leadership of a named election
+type Election struct {
+	cli           *dyc.Client
+	table         string
+	name          string
+	keyNames      []string
+	id            string
+	leaseDuration time.Duration
+	heartbeat     time.Duration
+	onElected     func(ctx context.Context, term int64)
+	onRevoked     func(term int64)
+	now           func() time.Time
+
+	mu   sync.Mutex
+	term int64
+}
+
+// Option allows you to configure the election
+type Option func(e *Election)
+
+// WithKeyNames sets the key attribute names of the table. by default they are discovered via the client
+func WithKeyNames(names ...string) Option {
+	return func(e *Election) {
+		e.keyNames = names
+	}
+}
+
+// WithID sets the id used to identify this candidate. defaults to the hostname and pid
+func WithID(id string) Option {
+	return func(e *Election) {
+		e.id = id
+	}
+}
+
+// WithLeaseDuration sets how long leadership is held without being renewed. defaults to 15 seconds
+func WithLeaseDuration(duration time.Duration) Option {
+	return func(e *Election) {
+		e.leaseDuration = duration
+	}
+}
+
+// WithHeartbeat sets how often the lease is renewed while elected and how often followers campaign.
+// defaults to a third of the lease duration
+func WithHeartbeat(interval time.Duration) Option {
+	return func(e *Election) {
+		e.heartbeat = interval
+	}
+}
+
+// OnElected sets a function called in its own goroutine when this candidate is elected.
+// the provided context is canceled once leadership is lost, and fn should return shortly after
+func OnElected(fn func(ctx context.Context, term int64)) Option {
+	return func(e *Election) {
+		e.onElected = fn
+	}
+}
+
+// OnRevoked sets a function called once leadership of the provided term is lost or given up
+func OnRevoked(fn func(term int64)) Option {
+	return func(e *Election) {
+		e.onRevoked = fn
+	}
+}
+
+// New creates an election with the provided name stored in the provided table
+func New(cli *dyc.Client, table, name string, opts ...Option) *Election {
+	e := &Election{
+		cli:           cli,
+		table:         table,
+		name:          name,
+		id:            coordination.DefaultOwner(),
+		leaseDuration: 15 * time.Second,
+		now:           time.Now,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.heartbeat <= 0 {
+		e.heartbeat = e.leaseDuration / 3
+	}
+
+	return e
+}
+
+// ID returns the id of this candidate
+func (e *Election) ID() string {
+	return e.id
+}
+
+// Term returns the term this candidate is leader of, or 0 if it isn't the leader
+func (e *Election) Term() int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.term
+}
+
+// IsLeader returns true if this candidate is currently the leader
+func (e *Election) IsLeader() bool {
+	return e.Term() != 0
+}
+
+// Leader returns the current leader, nil is returned if nobody holds a valid lease
+func (e *Election) Leader(ctx context.Context) (*Leader, error) {
+	b, err := e.builder(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var current state
+	if _, err := b.ConsistentRead(true).Result(&current).GetItem(ctx); err != nil {
+		return nil, err
+	}
+	if current.Leader == "" || current.LeaseExpiresAt < e.now().UnixNano() {
+		return nil, nil
+	}
+
+	return &Leader{
+		ID:             current.Leader,
+		Term:           current.Term,
+		LeaseExpiresAt: time.Unix(0, current.LeaseExpiresAt),
+	}, nil
+}
+
+// Run campaigns for leadership until the context is canceled.
+// when elected the lease is renewed every heartbeat, and once the context is canceled
+// the leader waits for OnElected to return and releases its lease so another candidate can take over.
+// transient errors are retried, Run only returns once the context is done
+func (e *Election) Run(ctx context.Context) error {
+	ticker := time.NewTicker(e.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		term, expires, err := e.campaign(ctx)
+		if err == nil && term > 0 {
+			e.lead(ctx, term, expires, ticker)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// campaign attempts to become the leader, the new term and the expiration of its lease are returned if elected
+func (e *Election) campaign(ctx context.Context) (int64, time.Time, error) {
+	b, err := e.builder(ctx)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	now := e.now()
+	expires := now.Add(e.leaseDuration)
+	var elected state
+	_, err = b.
+		Update(`SET Leader = :id, LeaseExpiresAt = :expires ADD Term :one`, dyc.Params{
+			"id":      e.id,
+			"expires": expires.UnixNano(),
+			"one":     1,
+		}).
+		Condition(`attribute_not_exists(Leader) OR LeaseExpiresAt < ?`, now.UnixNano()).
+		Return(dynamodb.ReturnValueAllNew).
+		Result(&elected).
+		UpdateItem(ctx)
+	if dyc.IsConditionalCheckFailure(err) {
+		return 0, time.Time{}, nil
+	}
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	return elected.Term, expires, nil
+}
+
+// lead renews the lease of the provided term until leadership is lost or the context is done.
+// the leader context is canceled once the lease expires, every successful renewal pushes that deadline forward
+func (e *Election) lead(ctx context.Context, term int64, expires time.Time, ticker *time.Ticker) {
+	e.mu.Lock()
+	e.term = term
+	e.mu.Unlock()
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	deadline := time.AfterFunc(expires.Sub(e.now()), cancel)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if e.onElected != nil {
+			e.onElected(leaderCtx, term)
+		}
+	}()
+
+	defer func() {
+		deadline.Stop()
+		cancel()
+		<-done
+		if ctx.Err() != nil {
+			e.resign(term)
+		}
+
+		e.mu.Lock()
+		e.term = 0
+		e.mu.Unlock()
+		if e.onRevoked != nil {
+			e.onRevoked(term)
+		}
+	}()
+
+	for {
+		select {
+		case <-leaderCtx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		renewed, err := e.renew(leaderCtx, term, expires)
+		if dyc.IsConditionalCheckFailure(err) {
+			return
+		}
+		// transient failures are retried on the next tick, the deadline revokes leadership once the lease expires.
+		// the lease may also have expired while a successful renewal was in flight, leadership is lost either way
+		if err == nil {
+			if leaderCtx.Err() != nil || !deadline.Stop() {
+				return
+			}
+			expires = renewed
+			deadline.Reset(expires.Sub(e.now()))
+		}
+	}
+}
+
+// renew extends the lease of the provided term. the request times out well before the current lease expires
+// so a slow renewal can't keep the leader context alive past the lease
+func (e *Election) renew(ctx context.Context, term int64, current time.Time) (time.Time, error) {
+	b, err := e.builder(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	timeout := current.Sub(e.now()) / 2
+	if timeout > e.heartbeat {
+		timeout = e.heartbeat
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	expires := e.now().Add(e.leaseDuration)
+	_, err = b.
+		Update(`SET LeaseExpiresAt = ?`, expires.UnixNano()).
+		Condition(`Leader = ? AND Term = ?`, e.id, term).
+		UpdateItem(ctx)
+
+	return expires, err
+}
+
+// resign releases the lease of the provided term so another candidate can be elected right away
+func (e *Election) resign(term int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.heartbeat)
+	defer cancel()
+
+	b, err := e.builder(ctx)
+	if err != nil {
+		return
+	}
+
+	_, _ = b.
+		Update(`REMOVE Leader, LeaseExpiresAt`).
+		Condition(`Leader = ? AND Term = ?`, e.id, term).
+		UpdateItem(ctx)
+}
+
+func (e *Election) builder(ctx context.Context) (*dyc.Builder, error) {
+	keys, err := coordination.ResolveKey(ctx, e.cli, e.table, e.keyNames, "dyc#election#"+e.name)
+	if err != nil {
+		return nil, err
+	}
+
+	return coordination.Builder(e.cli, e.table, keys)
+}
@@ -0,0 +1,107 @@
+//go:build integration
+// +build integration
+
+package election
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/darwayne/dyc"
+	"github.com/darwayne/dyc/internal/testing/dynamotest"
+)
+
+func setup(t *testing.T) (*dyc.Client, string) {
+	t.Helper()
+	t.Parallel()
+	table, db := dynamotest.SetupTestTable(context.Background(), t, "election", dynamotest.DefaultSchema())
+
+	return dyc.NewClient(db), table
+}
+
+type events struct {
+	elected chan int64
+	revoked chan int64
+}
+
+func candidate(cli *dyc.Client, table, id string) (*Election, events) {
+	ev := events{elected: make(chan int64, 10), revoked: make(chan int64, 10)}
+	e := New(cli, table, "scheduler",
+		WithID(id),
+		WithLeaseDuration(time.Second),
+		WithHeartbeat(100*time.Millisecond),
+		OnElected(func(ctx context.Context, term int64) {
+			ev.elected <- term
+			<-ctx.Done()
+		}),
+		OnRevoked(func(term int64) {
+			ev.revoked <- term
+		}),
+	)
+
+	return e, ev
+}
+
+func receive(t *testing.T, ch chan int64) int64 {
+	t.Helper()
+	select {
+	case term := <-ch:
+		return term
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for election event")
+	}
+	return 0
+}
+
+func TestElection(t *testing.T) {
+	t.Run("should elect a single leader and hand over on step down", func(t *testing.T) {
+		cli, table := setup(t)
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		first, firstEvents := candidate(cli, table, "first")
+		firstCtx, stepDown := context.WithCancel(ctx)
+		firstDone := make(chan error, 1)
+		go func() { firstDone <- first.Run(firstCtx) }()
+		require.EqualValues(t, 1, receive(t, firstEvents.elected))
+		require.True(t, first.IsLeader())
+
+		second, secondEvents := candidate(cli, table, "second")
+		go second.Run(ctx)
+
+		time.Sleep(500 * time.Millisecond)
+		require.False(t, second.IsLeader())
+		leader, err := second.Leader(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "first", leader.ID)
+		require.EqualValues(t, 1, leader.Term)
+
+		stepDown()
+		require.Equal(t, context.Canceled, <-firstDone)
+		require.EqualValues(t, 1, receive(t, firstEvents.revoked))
+		require.False(t, first.IsLeader())
+
+		require.EqualValues(t, 2, receive(t, secondEvents.elected))
+		require.EqualValues(t, 2, second.Term())
+	})
+
+	t.Run("should revoke leadership once the lease is lost", func(t *testing.T) {
+		cli, table := setup(t)
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		e, ev := candidate(cli, table, "first")
+		go e.Run(ctx)
+		term := receive(t, ev.elected)
+
+		b, err := e.builder(ctx)
+		require.NoError(t, err)
+		_, err = b.Update(`SET Leader = ?`, "someone-else").UpdateItem(ctx)
+		require.NoError(t, err)
+
+		require.Equal(t, term, receive(t, ev.revoked))
+	})
+}
@@ -0,0 +1,119 @@
+//go:build unit
+// +build unit
+
+package election
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/darwayne/dyc"
+	"github.com/darwayne/dyc/internal/testing/dynamotest"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("should default heartbeat to a third of the lease", func(t *testing.T) {
+		e := New(nil, "MyTable", "scheduler", WithLeaseDuration(30*time.Second))
+		require.Equal(t, 10*time.Second, e.heartbeat)
+		require.NotEmpty(t, e.ID())
+		require.False(t, e.IsLeader())
+	})
+
+	t.Run("should respect options", func(t *testing.T) {
+		e := New(nil, "MyTable", "scheduler", WithHeartbeat(time.Second), WithID("node-1"))
+		require.Equal(t, time.Second, e.heartbeat)
+		require.Equal(t, "node-1", e.ID())
+	})
+}
+
+func TestElection_builder(t *testing.T) {
+	e := New(dyc.NewClient(nil), "MyTable", "scheduler", WithKeyNames("PK", "SK"))
+	b, err := e.builder(context.Background())
+	require.NoError(t, err)
+
+	input, err := b.ToGet()
+	require.NoError(t, err)
+	require.Equal(t, "MyTable", aws.StringValue(input.TableName))
+	require.Equal(t, dyc.Map{
+		"PK": dyc.String("dyc#election#scheduler"),
+		"SK": dyc.String("dyc#election#scheduler"),
+	}, input.Key)
+}
+
+func TestElection_lead(t *testing.T) {
+	const lease = 200 * time.Millisecond
+
+	// stub elects the candidate for term 1 and answers renewals with renew
+	stub := func(t *testing.T, renew func(ctx context.Context) error) *dyc.Client {
+		var elected int32
+		return dynamotest.StubClient(t, func(ctx context.Context, op *dyc.Operation) error {
+			input, ok := op.Input.(*dynamodb.UpdateItemInput)
+			if !ok {
+				return nil
+			}
+			if aws.StringValue(input.ReturnValues) != dynamodb.ReturnValueAllNew {
+				return renew(ctx)
+			}
+			if atomic.AddInt32(&elected, 1) > 1 {
+				return awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "held", nil)
+			}
+			op.Output.(*dynamodb.UpdateItemOutput).Attributes = dyc.Map{"Term": dyc.Int(1)}
+			return nil
+		})
+	}
+
+	// run campaigns until leadership of the first term is revoked and returns how long it was held
+	run := func(t *testing.T, cli *dyc.Client, timeout time.Duration) (time.Duration, bool) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		var held time.Duration
+		revoked := make(chan struct{})
+		e := New(cli, "MyTable", "scheduler", WithKeyNames("PK"), WithLeaseDuration(lease), WithHeartbeat(lease/4),
+			OnElected(func(leaderCtx context.Context, term int64) {
+				started := time.Now()
+				<-leaderCtx.Done()
+				held = time.Since(started)
+				close(revoked)
+			}))
+		go func() {
+			_ = e.Run(ctx)
+		}()
+
+		select {
+		case <-revoked:
+			return held, ctx.Err() == nil
+		case <-time.After(timeout + lease):
+			t.Fatal("leadership was never revoked")
+			return 0, false
+		}
+	}
+
+	t.Run("should revoke leadership when the lease expires while renewals hang", func(t *testing.T) {
+		cli := stub(t, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		held, beforeTimeout := run(t, cli, 4*lease)
+		require.True(t, beforeTimeout)
+		require.Less(t, held, lease+lease/4)
+	})
+
+	t.Run("should keep leadership while renewals succeed", func(t *testing.T) {
+		cli := stub(t, func(ctx context.Context) error {
+			return nil
+		})
+
+		held, beforeTimeout := run(t, cli, 3*lease)
+		require.False(t, beforeTimeout)
+		require.Greater(t, held, 2*lease)
+	})
+}
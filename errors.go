@@ -1,6 +1,11 @@
 package dyc
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
 
 var (
 	// ErrClientNotSet occurs if you try to make a client call from a builder without setting the client
@@ -15,8 +20,65 @@ var (
 	ErrUnsupportedType = errors.New("unsupported type")
 	// ErrQueryMisMatch occurs if the number of ? don't line up with the given inputs for a query
 	ErrQueryMisMatch = errors.New("inputs don't match query")
+	// ErrMissingParam occurs if a named placeholder in a query has no matching entry in the provided Params
+	ErrMissingParam = errors.New("named placeholder has no matching param")
+	// ErrInvalidKeyCondition occurs if a key condition can't be used by dynamodb (e.g multiple sort key conditions or an OR)
+	ErrInvalidKeyCondition = errors.New("invalid key condition")
+	// ErrInvalidTableSpec occurs if a table spec is missing a name, keys, provisioned throughput or has conflicting key attribute types
+	ErrInvalidTableSpec = errors.New("invalid table spec")
+	// ErrKeySchemaMismatch occurs if an existing table has different keys than its spec. key schemas can't be changed
+	ErrKeySchemaMismatch = errors.New("table key schema does not match spec")
+	// ErrLocalIndexMismatch occurs if an existing table has different local indexes than its spec.
+	// local indexes can only be defined when a table is created
+	ErrLocalIndexMismatch = errors.New("table local indexes do not match spec")
+	// ErrTTLSwitchPending occurs if EnsureTable disabled ttl on the previous attribute of a table but couldn't enable it
+	// on the new one yet. dynamodb rejects ttl changes within about an hour of the previous one so EnsureTable has to run
+	// again after that
+	ErrTTLSwitchPending = errors.New("ttl attribute switch pending")
 	// ErrNotSlice occurs if a non slice type is provided as a value for any of the IN builder query functions
 	ErrNotSlice = errors.New("provided value is not a slice")
+	// ErrNoValues occurs if an aggregation such as Min, Max or Avg found no numeric values to aggregate
+	ErrNoValues = errors.New("no values to aggregate")
+	// ErrInvalidPath occurs if an attribute path provided to an aggregation can't be parsed
+	ErrInvalidPath = errors.New("invalid attribute path")
+	// ErrUnsupportedFormat occurs if a table is exported or imported in a format that isn't supported
+	ErrUnsupportedFormat = errors.New("unsupported format")
+	// ErrInvalidItem occurs if an imported row can't be decoded to an item or is missing a key attribute
+	ErrInvalidItem = errors.New("invalid item")
 	// ErrNotPointer occurs if a non pointer type is provided to the Result method of the builder type
 	ErrNotPointer = errors.New("provided result type is not a slice")
 )
+
+// IsConditionalCheckFailure returns true if the error occurred because a condition expression evaluated to false
+func IsConditionalCheckFailure(err error) bool {
+	var aerr awserr.Error
+	return errors.As(err, &aerr) && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException
+}
+
+// isValidationError returns true if dynamodb rejected the request because it is invalid e.g an item has an empty key
+func isValidationError(err error) bool {
+	var aerr awserr.Error
+	return errors.As(err, &aerr) && aerr.Code() == "ValidationException"
+}
+
+// isAccessDenied returns true if the caller isn't allowed to make the request e.g DescribeTable without the
+// dynamodb:DescribeTable permission
+func isAccessDenied(err error) bool {
+	var aerr awserr.Error
+	return errors.As(err, &aerr) && aerr.Code() == "AccessDeniedException"
+}
+
+// IsThrottled returns true if the error occurred because dynamodb throttled the request
+func IsThrottled(err error) bool {
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) {
+		return false
+	}
+
+	switch aerr.Code() {
+	case dynamodb.ErrCodeProvisionedThroughputExceededException, dynamodb.ErrCodeRequestLimitExceeded, "ThrottlingException":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,305 @@
+package dyc
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LargeTableItemCount is the approximate item count at which Explain flags scans without a filter
+const LargeTableItemCount = 100000
+
+// LargeTableSizeBytes is the approximate table size at which Explain flags scans without a filter
+const LargeTableSizeBytes = 1 << 30
+
+// SingleItemOperation is the operation explained for builders with only a key set,
+// which could be used for GetItem, PutItem or DeleteItem
+const SingleItemOperation = "GetItem|PutItem|DeleteItem"
+
+// Explanation is a human readable rendering of the operation a builder would perform
+type Explanation struct {
+	// Operation is the dynamodb operation the builder would perform:
+	// UpdateItem if an update expression is set, Query if a key condition is set and Scan otherwise.
+	// builders with only a key set can be used for any single item operation, their operation is SingleItemOperation
+	Operation string
+	Table     string
+	Index     string
+	// Key is the rendered key of single item operations
+	Key string
+	// KeyCondition, Filter, Projection, Condition and Update are the rendered expressions
+	// with their attribute name and value placeholders substituted
+	KeyCondition string
+	Filter       string
+	Projection   string
+	Condition    string
+	Update       string
+	Limit        int
+	// Warnings contains likely problems with the operation
+	Warnings []string
+	// Err is the error the builder encountered while it was being built
+	Err error
+}
+
+// String renders the explanation over multiple lines
+func (e *Explanation) String() string {
+	var sb strings.Builder
+	sb.WriteString(e.Operation)
+	if e.Table != "" {
+		sb.WriteString(" " + e.Table)
+	}
+	sb.WriteString("\n")
+
+	for _, line := range []struct{ label, val string }{
+		{"index", e.Index},
+		{"key", e.Key},
+		{"key condition", e.KeyCondition},
+		{"filter", e.Filter},
+		{"projection", e.Projection},
+		{"condition", e.Condition},
+		{"update", e.Update},
+	} {
+		if line.val != "" {
+			sb.WriteString("  " + line.label + ": " + line.val + "\n")
+		}
+	}
+	if e.Limit > 0 {
+		sb.WriteString("  limit: " + strconv.Itoa(e.Limit) + "\n")
+	}
+	if e.Err != nil {
+		sb.WriteString("  error: " + e.Err.Error() + "\n")
+	}
+	if len(e.Warnings) > 0 {
+		sb.WriteString("warnings:\n")
+		for _, warning := range e.Warnings {
+			sb.WriteString("  - " + warning + "\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// Explain renders the operation the builder would perform and flags likely problems such as
+// scans without a filter on large tables, filters on key attributes and key conditions no index can serve.
+// Explain never calls dynamodb, checks that need the table schema only run if the schema was already
+// discovered by the client or the keys were set via WithPrimaryKeys
+func (s *Builder) Explain() *Explanation {
+	e := &Explanation{
+		Table: s.table,
+		Index: s.index,
+		Limit: s.limit,
+		Err:   s.err,
+	}
+
+	render := func(expr string) string {
+		if expr == "" {
+			return ""
+		}
+		return renderExpression(expr, s.cols, s.vals, renderValue)
+	}
+	e.KeyCondition = render(s.keyExpression)
+	e.Filter = render(s.filterExpresion)
+	e.Projection = render(s.selectedFields)
+	e.Condition = render(s.conditionExpression)
+	e.Update = render(s.updateExpression)
+	if len(s.keys) > 0 {
+		e.Key = renderMap(s.keys, renderValue)
+	}
+
+	switch {
+	case s.updateExpression != "":
+		e.Operation = "UpdateItem"
+	case s.keyExpression != "":
+		e.Operation = "Query"
+	case len(s.keys) > 0:
+		e.Operation = SingleItemOperation
+	default:
+		e.Operation = "Scan"
+	}
+
+	schema, described := s.knownSchema()
+	switch e.Operation {
+	case "Query":
+		e.Warnings = append(e.Warnings, s.queryWarnings(schema, described)...)
+	case "Scan":
+		e.Warnings = append(e.Warnings, s.scanWarnings(schema, described)...)
+	}
+
+	return e
+}
+
+// String returns the rendered explanation of the builder
+func (s *Builder) String() string {
+	return s.Explain().String()
+}
+
+// knownSchema returns the schema of the table if it is known without calling dynamodb.
+// described is true if the schema was described by dynamodb rather than built from the configured keys
+func (s *Builder) knownSchema() (schema *TableSchema, described bool) {
+	if s.customKeys || (s.client != nil && s.client.disableSchemaDiscovery) {
+		return &TableSchema{Name: s.table, Keys: s.primaryKeys}, false
+	}
+	if s.client == nil || s.table == "" {
+		return nil, false
+	}
+
+	return s.client.schemas.cached(s.table)
+}
+
+func (s *Builder) queryWarnings(schema *TableSchema, described bool) []string {
+	var warnings []string
+	keyAttrs := expressionAttributes(s.keyExpression, s.cols)
+	if schema != nil {
+		keys := schema.Keys
+		target := "the table"
+		if s.index != "" {
+			keys = schema.IndexKeys(s.index)
+			target = "index " + s.index
+		}
+
+		switch {
+		case s.index != "" && keys == nil && described:
+			warnings = append(warnings, "index "+s.index+" doesn't exist on table "+s.table)
+		case keys != nil:
+			if missing := without(keyAttrs, keys); len(missing) > 0 {
+				warning := "key condition uses " + strings.Join(missing, ", ") + " which isn't a key of " + target
+				if index := s.indexFor(schema, keyAttrs); index != "" {
+					warning += ", query index " + index + " instead"
+				} else {
+					warning += " and no index has it as a key"
+				}
+				warnings = append(warnings, warning)
+			}
+			if filtered := within(expressionAttributes(s.filterExpresion, s.cols), keys); len(filtered) > 0 {
+				warnings = append(warnings, "filter on key attribute "+strings.Join(filtered, ", ")+
+					" of "+target+" should be part of the key condition")
+			}
+		}
+	}
+
+	return warnings
+}
+
+func (s *Builder) scanWarnings(schema *TableSchema, described bool) []string {
+	var warnings []string
+	if s.filterExpresion == "" {
+		// tables of unknown size aren't flagged, most scans without a filter are on small tables
+		if described && (schema.ItemCount >= LargeTableItemCount || schema.TableSizeBytes >= LargeTableSizeBytes) {
+			warnings = append(warnings, "scan without a filter reads the entire table (~"+
+				strconv.FormatInt(schema.ItemCount, 10)+" items, ~"+strconv.FormatInt(schema.TableSizeBytes>>20, 10)+" MiB)")
+		}
+		return warnings
+	}
+
+	if schema == nil {
+		return warnings
+	}
+	filterAttrs := expressionAttributes(s.filterExpresion, s.cols)
+	if len(schema.Keys) > 0 && contains(filterAttrs, schema.Keys[0]) {
+		warnings = append(warnings, "filter on partition key "+schema.Keys[0]+" should be a key condition of a query")
+	} else if index := s.indexFor(schema, filterAttrs); index != "" {
+		warnings = append(warnings, "filter on partition key "+schema.IndexKeys(index)[0]+
+			" of index "+index+" should be a key condition of a query on the index")
+	}
+
+	return warnings
+}
+
+// indexFor returns the first index, sorted by name, whose partition key is referenced in attrs and whose keys contain
+// all key attributes referenced in attrs
+func (s *Builder) indexFor(schema *TableSchema, attrs []string) string {
+	names := make([]string, 0, len(schema.Indexes))
+	for name := range schema.Indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		keys := schema.Indexes[name]
+		if len(keys) == 0 || name == s.index || !contains(attrs, keys[0]) {
+			continue
+		}
+		if s.keyExpression == "" || len(without(attrs, keys)) == 0 {
+			return name
+		}
+	}
+
+	return ""
+}
+
+var identifierRegex = regexp.MustCompile(`#[A-Za-z0-9_]+|[A-Za-z_][A-Za-z0-9_]*`)
+
+// expressionAttributes returns the top level attributes referenced by an expression in the order they appear
+func expressionAttributes(expr string, names map[string]*string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, loc := range identifierRegex.FindAllStringIndex(expr, -1) {
+		if loc[0] > 0 && (expr[loc[0]-1] == ':' || expr[loc[0]-1] == '.') {
+			continue
+		}
+
+		token := expr[loc[0]:loc[1]]
+		if token[0] == '#' {
+			name, found := names[token]
+			if !found {
+				continue
+			}
+			token = *name
+		} else if isExpressionWord(token) {
+			continue
+		}
+
+		if !seen[token] {
+			seen[token] = true
+			result = append(result, token)
+		}
+	}
+
+	return result
+}
+
+// isExpressionWord determines if an unaliased identifier is part of the expression syntax.
+// reserved words are always aliased, so keywords and functions are never attributes
+func isExpressionWord(ident string) bool {
+	upper := strings.ToUpper(ident)
+	if _, found := expressionKeywords[upper]; found {
+		return true
+	}
+	_, found := expressionFunctions[upper]
+
+	return found
+}
+
+// without returns the attributes that aren't part of keys
+func without(attrs, keys []string) []string {
+	var result []string
+	for _, attr := range attrs {
+		if !contains(keys, attr) {
+			result = append(result, attr)
+		}
+	}
+
+	return result
+}
+
+// within returns the attributes that are part of keys
+func within(attrs, keys []string) []string {
+	var result []string
+	for _, attr := range attrs {
+		if contains(keys, attr) {
+			result = append(result, attr)
+		}
+	}
+
+	return result
+}
+
+func contains(list []string, val string) bool {
+	for _, item := range list {
+		if item == val {
+			return true
+		}
+	}
+
+	return false
+}
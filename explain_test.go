@@ -0,0 +1,111 @@
+//go:build unit
+// +build unit
+
+package dyc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder_Explain(t *testing.T) {
+	setupSized := func(itemCount, sizeBytes int64) *Client {
+		cli := NewClient(nil)
+		_, err := cli.schemas.get(context.Background(), "MyTable", func(ctx context.Context) (*TableSchema, error) {
+			return &TableSchema{
+				Name:           "MyTable",
+				Keys:           []string{"PK", "SK"},
+				Indexes:        map[string][]string{"GSI1": {"GSI1PK", "GSI1SK"}},
+				ItemCount:      itemCount,
+				TableSizeBytes: sizeBytes,
+			}, nil
+		})
+		require.NoError(t, err)
+
+		return cli
+	}
+	setup := func(itemCount int64) *Client {
+		return setupSized(itemCount, 0)
+	}
+
+	t.Run("should render a query", func(t *testing.T) {
+		b := setup(10).Builder().
+			Table("MyTable").
+			WhereKey("PK = ? AND begins_with(SK, ?)", "user#1", "order#").
+			Where("status = ? AND 'Total' > ?", "shipped", 10).
+			SelectFields("Total", "status").
+			Limit(5)
+
+		e := b.Explain()
+		require.Equal(t, "Query", e.Operation)
+		require.Equal(t, `(PK = "user#1" AND begins_with(SK, "order#"))`, e.KeyCondition)
+		require.Equal(t, `(status = "shipped" AND Total > 10)`, e.Filter)
+		require.Equal(t, "Total,status", e.Projection)
+		require.Empty(t, e.Warnings)
+		require.Equal(t, `Query MyTable
+  key condition: (PK = "user#1" AND begins_with(SK, "order#"))
+  filter: (status = "shipped" AND Total > 10)
+  projection: Total,status
+  limit: 5
+`, b.String())
+	})
+
+	t.Run("should flag filters on key attributes", func(t *testing.T) {
+		e := setup(10).Builder().Table("MyTable").WhereKey("PK = ?", "a").Where("SK > ?", "b").Explain()
+		require.Equal(t, []string{"filter on key attribute SK of the table should be part of the key condition"}, e.Warnings)
+	})
+
+	t.Run("should suggest an index for the key condition", func(t *testing.T) {
+		e := setup(10).Builder().Table("MyTable").WhereKey("GSI1PK = ?", "a").Explain()
+		require.Equal(t, []string{"key condition uses GSI1PK which isn't a key of the table, query index GSI1 instead"}, e.Warnings)
+
+		e = setup(10).Builder().Table("MyTable").WhereKey("Email = ?", "a").Explain()
+		require.Equal(t, []string{"key condition uses Email which isn't a key of the table and no index has it as a key"}, e.Warnings)
+
+		e = setup(10).Builder().Table("MyTable").Index("GSI2").WhereKey("GSI2PK = ?", "a").Explain()
+		require.Equal(t, []string{"index GSI2 doesn't exist on table MyTable"}, e.Warnings)
+
+		e = setup(10).Builder().Table("MyTable").Index("GSI1").WhereKey("GSI1PK = ?", "a").Explain()
+		require.Empty(t, e.Warnings)
+	})
+
+	t.Run("should flag scans without a filter on large tables", func(t *testing.T) {
+		e := setup(LargeTableItemCount).Builder().Table("MyTable").Explain()
+		require.Equal(t, "Scan", e.Operation)
+		require.Equal(t, []string{"scan without a filter reads the entire table (~100000 items, ~0 MiB)"}, e.Warnings)
+
+		e = setupSized(10, 2*LargeTableSizeBytes).Builder().Table("MyTable").Explain()
+		require.Equal(t, []string{"scan without a filter reads the entire table (~10 items, ~2048 MiB)"}, e.Warnings)
+
+		e = setup(10).Builder().Table("MyTable").Explain()
+		require.Empty(t, e.Warnings)
+
+		e = NewClient(nil).Builder().Table("Unknown").Explain()
+		require.Empty(t, e.Warnings)
+	})
+
+	t.Run("should flag scans filtering on partition keys", func(t *testing.T) {
+		e := setup(10).Builder().Table("MyTable").Where("GSI1PK = ? AND 'Age' > ?", "a", 3).Explain()
+		require.Equal(t, []string{"filter on partition key GSI1PK of index GSI1 should be a key condition of a query on the index"}, e.Warnings)
+
+		e = setup(10).Builder().Table("MyTable").Where("PK = ?", "a").Explain()
+		require.Equal(t, []string{"filter on partition key PK should be a key condition of a query"}, e.Warnings)
+	})
+
+	t.Run("should render item operations", func(t *testing.T) {
+		e := NewBuilder().Table("MyTable").Key("PK", "a", "SK", 1).Update("SET 'Count' = 'Count' + ?", 1).Condition("attribute_exists(PK)").Explain()
+		require.Equal(t, "UpdateItem", e.Operation)
+		require.Equal(t, `{"PK": "a", "SK": 1}`, e.Key)
+		require.Equal(t, "SET Count = Count + 1", e.Update)
+		require.Equal(t, "(attribute_exists(PK))", e.Condition)
+
+		e = NewBuilder().Table("MyTable").Key("PK", "a").Explain()
+		require.Equal(t, SingleItemOperation, e.Operation)
+
+		e = NewBuilder().Table("MyTable").Key("PK", "a").Condition("attribute_exists(PK)").Explain()
+		require.Equal(t, SingleItemOperation, e.Operation)
+		require.Equal(t, "GetItem|PutItem|DeleteItem MyTable\n  key: {\"PK\": \"a\"}\n  condition: (attribute_exists(PK))\n", e.String())
+	})
+}
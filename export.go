@@ -0,0 +1,114 @@
+package dyc
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// DefaultExportSegments is the amount of segments scanned in parallel by ExportTable unless set via ExportSegments
+const DefaultExportSegments = 4
+
+type exportConfig struct {
+	builder  *Builder
+	gzip     bool
+	segments int
+}
+
+// ExportOption allows you to configure an export
+type ExportOption func(c *exportConfig)
+
+// ExportFilter only exports the items matching the filter of the builder and restricts them to its selected fields.
+// the index of the builder is scanned if set, its table and key condition are ignored
+func ExportFilter(builder *Builder) ExportOption {
+	return func(c *exportConfig) {
+		c.builder = builder
+	}
+}
+
+// ExportGzip compresses the export with gzip, like dynamodb exports to s3 (.json.gz)
+func ExportGzip() ExportOption {
+	return func(c *exportConfig) {
+		c.gzip = true
+	}
+}
+
+// ExportSegments sets the amount of segments scanned in parallel. defaults to DefaultExportSegments
+func ExportSegments(segments int) ExportOption {
+	return func(c *exportConfig) {
+		c.segments = segments
+	}
+}
+
+// ExportTable writes all items of the table to w, one item per line in the provided format.
+// the table is scanned in parallel segments, so items are written in no particular order.
+// the amount of exported items is returned, w is never closed
+func (c *Client) ExportTable(ctx context.Context, table string, w io.Writer, format Format, opts ...ExportOption) (exported int64, err error) {
+	cfg := exportConfig{segments: DefaultExportSegments}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if format != DynamoDBJSON && format != JSONLines {
+		return 0, ErrUnsupportedFormat
+	}
+
+	input := dynamodb.ScanInput{}
+	if cfg.builder != nil {
+		if input, err = cfg.builder.ToScan(); err != nil {
+			return 0, err
+		}
+		input.Limit = nil
+		input.ExclusiveStartKey = nil
+		// dynamodb rejects scans with names or values that aren't used, e.g the ones of a key condition
+		input.ExpressionAttributeNames = usedNames(input.ExpressionAttributeNames, input.FilterExpression, input.ProjectionExpression)
+		input.ExpressionAttributeValues = usedValues(input.ExpressionAttributeValues, input.FilterExpression)
+	}
+	input.TableName = aws.String(table)
+	if cfg.segments < 1 {
+		cfg.segments = 1
+	}
+
+	ctx, done := c.startCall(ctx, Call{Method: "ExportTable", Table: table, Index: aws.StringValue(input.IndexName)})
+	defer done(&err)
+
+	var zw *gzip.Writer
+	if cfg.gzip {
+		zw = gzip.NewWriter(w)
+		w = zw
+	}
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+
+	// fn isn't called concurrently, so the encoder needs no synchronization
+	err = c.ParallelScanIterator(ctx, &input, cfg.segments, func(output *dynamodb.ScanOutput) error {
+		for _, item := range output.Items {
+			line, err := encodeItem(format, item)
+			if err != nil {
+				return err
+			}
+			if err := enc.Encode(line); err != nil {
+				return err
+			}
+			exported++
+		}
+		return nil
+	}, false)
+	if err != nil {
+		return exported, err
+	}
+
+	if err = bw.Flush(); err != nil {
+		return exported, err
+	}
+	if zw != nil {
+		err = zw.Close()
+	}
+
+	return exported, err
+}
@@ -0,0 +1,120 @@
+//go:build unit
+// +build unit
+
+package dyc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ExportTable(t *testing.T) {
+	item := Map{
+		"PK":    String("user#1"),
+		"Big":   &dynamodb.AttributeValue{N: aws.String("12345678901234567890")},
+		"Tags":  StringSet("a"),
+		"Attrs": {M: Map{"On": {BOOL: aws.Bool(true)}, "Raw": {B: []byte("hi")}, "Nil": {NULL: aws.Bool(true)}}},
+		"List":  IntList(1, 2),
+	}
+	exportClient := func(t *testing.T, check func(input *dynamodb.ScanInput)) *Client {
+		return stubClient(t, func(op *Operation) error {
+			input := op.Input.(*dynamodb.ScanInput)
+			if check != nil {
+				check(input)
+			}
+			op.Output.(*dynamodb.ScanOutput).Items = Maps{item}
+			return nil
+		})
+	}
+
+	t.Run("should export dynamodb json", func(t *testing.T) {
+		cli := exportClient(t, nil)
+		var buf bytes.Buffer
+		exported, err := cli.ExportTable(context.Background(), "MyTable", &buf, DynamoDBJSON, ExportSegments(1))
+		require.NoError(t, err)
+		require.Equal(t, int64(1), exported)
+		require.JSONEq(t, `{"Item":{
+			"PK":{"S":"user#1"},
+			"Big":{"N":"12345678901234567890"},
+			"Tags":{"SS":["a"]},
+			"Attrs":{"M":{"On":{"BOOL":true},"Raw":{"B":"aGk="},"Nil":{"NULL":true}}},
+			"List":{"L":[{"N":"1"},{"N":"2"}]}
+		}}`, buf.String())
+		require.True(t, strings.HasSuffix(buf.String(), "}\n"))
+	})
+
+	t.Run("should export plain json lines", func(t *testing.T) {
+		cli := exportClient(t, nil)
+		var buf bytes.Buffer
+		_, err := cli.ExportTable(context.Background(), "MyTable", &buf, JSONLines, ExportSegments(1))
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"PK":"user#1",
+			"Big":12345678901234567890,
+			"Tags":["a"],
+			"Attrs":{"On":true,"Raw":"aGk=","Nil":null},
+			"List":[1,2]
+		}`, buf.String())
+		require.Contains(t, buf.String(), `"Big":12345678901234567890`)
+	})
+
+	t.Run("should scan segments in parallel and compress", func(t *testing.T) {
+		var segments []int64
+		cli := exportClient(t, func(input *dynamodb.ScanInput) {
+			require.Equal(t, "MyTable", aws.StringValue(input.TableName))
+			require.Equal(t, int64(3), aws.Int64Value(input.TotalSegments))
+			segments = append(segments, aws.Int64Value(input.Segment))
+		})
+		var buf bytes.Buffer
+		exported, err := cli.ExportTable(context.Background(), "MyTable", &buf, DynamoDBJSON, ExportSegments(3), ExportGzip())
+		require.NoError(t, err)
+		require.Equal(t, int64(3), exported)
+		sort.Slice(segments, func(i, j int) bool { return segments[i] < segments[j] })
+		require.Equal(t, []int64{0, 1, 2}, segments)
+
+		zr, err := gzip.NewReader(&buf)
+		require.NoError(t, err)
+		data, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		require.Len(t, strings.Split(strings.TrimSpace(string(data)), "\n"), 3)
+	})
+
+	t.Run("should apply the builder filter and projection", func(t *testing.T) {
+		cli := exportClient(t, func(input *dynamodb.ScanInput) {
+			require.Equal(t, "OtherTable", aws.StringValue(input.TableName))
+			require.Equal(t, "(#1 = :0)", aws.StringValue(input.FilterExpression))
+			require.Equal(t, "PK", aws.StringValue(input.ProjectionExpression))
+			require.Nil(t, input.Limit)
+		})
+		filter := NewBuilder().Table("MyTable").Where("status = ?", "active").SelectFields("PK").Limit(1)
+		_, err := cli.ExportTable(context.Background(), "OtherTable", io.Discard, DynamoDBJSON, ExportFilter(filter), ExportSegments(1))
+		require.NoError(t, err)
+	})
+
+	t.Run("should only send the names and values used by the scan", func(t *testing.T) {
+		cli := exportClient(t, func(input *dynamodb.ScanInput) {
+			require.Equal(t, "GSI1", aws.StringValue(input.IndexName))
+			require.Equal(t, "(#2 = :1)", aws.StringValue(input.FilterExpression))
+			require.Equal(t, map[string]*string{"#2": aws.String("status")}, input.ExpressionAttributeNames)
+			require.Equal(t, map[string]*dynamodb.AttributeValue{":1": String("active")}, input.ExpressionAttributeValues)
+		})
+		filter := NewBuilder().Index("GSI1").WhereKey("'GSI1PK' = ?", "a").Where("status = ?", "active")
+		_, err := cli.ExportTable(context.Background(), "MyTable", io.Discard, DynamoDBJSON, ExportFilter(filter), ExportSegments(1))
+		require.NoError(t, err)
+	})
+
+	t.Run("should reject unsupported formats", func(t *testing.T) {
+		cli := exportClient(t, nil)
+		_, err := cli.ExportTable(context.Background(), "MyTable", io.Discard, Format(42))
+		require.ErrorIs(t, err, ErrUnsupportedFormat)
+	})
+}
@@ -30,3 +30,20 @@ func extractFields(data map[string]*dynamodb.AttributeValue, fields ...string) m
 
 	return result
 }
+
+// mergeKeys combines the provided key sets removing duplicates while preserving order
+func mergeKeys(keySets ...[]string) []string {
+	var result []string
+	seen := make(map[string]struct{})
+	for _, keys := range keySets {
+		for _, key := range keys {
+			if _, found := seen[key]; found {
+				continue
+			}
+			seen[key] = struct{}{}
+			result = append(result, key)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,280 @@
+package dyc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/pkg/errors"
+)
+
+// Format is the encoding of exported and imported items
+type Format int
+
+const (
+	// DynamoDBJSON encodes one item per line in the format of dynamodb exports to s3
+	// e.g {"Item":{"PK":{"S":"user#1"},"Age":{"N":"30"}}}
+	DynamoDBJSON Format = iota
+	// JSONLines encodes one item per line as plain json e.g {"PK":"user#1","Age":30}.
+	// numbers keep their precision, binary values are base64 encoded and sets become arrays
+	JSONLines
+	// CSV decodes items from csv with a header row naming the attributes, only supported by ImportTable.
+	// values are strings unless typed via ImportColumnTypes
+	CSV
+)
+
+// String returns the name of the format
+func (f Format) String() string {
+	switch f {
+	case DynamoDBJSON:
+		return "dynamodb-json"
+	case JSONLines:
+		return "jsonl"
+	case CSV:
+		return "csv"
+	}
+
+	return "unknown"
+}
+
+// dynamoJSONLine is a single line of a dynamodb json export
+type dynamoJSONLine struct {
+	Item map[string]interface{} `json:"Item"`
+}
+
+// encodeItem converts an item to the value encoded as a line of the format
+func encodeItem(format Format, item Map) (interface{}, error) {
+	switch format {
+	case DynamoDBJSON:
+		return dynamoJSONLine{Item: dynamoJSONMap(item)}, nil
+	case JSONLines:
+		return jsonMap(item), nil
+	}
+
+	return nil, ErrUnsupportedFormat
+}
+
+// MarshalItem encodes an item as a single line of the format without the trailing newline
+func MarshalItem(format Format, item Map) ([]byte, error) {
+	line, err := encodeItem(format, item)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(line); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// UnmarshalItem decodes an item from a single line of DynamoDBJSON or JSONLines
+func UnmarshalItem(format Format, data []byte) (Map, error) {
+	switch format {
+	case DynamoDBJSON:
+		return decodeDynamoJSON(data)
+	case JSONLines:
+		return decodeJSON(data)
+	}
+
+	return nil, ErrUnsupportedFormat
+}
+
+// dynamoJSONValue converts an attribute value to its dynamodb json representation e.g {"S": "hello"}
+func dynamoJSONValue(av *dynamodb.AttributeValue) map[string]interface{} {
+	switch {
+	case av == nil:
+		return map[string]interface{}{"NULL": true}
+	case av.S != nil:
+		return map[string]interface{}{"S": *av.S}
+	case av.N != nil:
+		return map[string]interface{}{"N": *av.N}
+	case av.B != nil:
+		return map[string]interface{}{"B": av.B}
+	case av.BOOL != nil:
+		return map[string]interface{}{"BOOL": *av.BOOL}
+	case av.SS != nil:
+		return map[string]interface{}{"SS": aws.StringValueSlice(av.SS)}
+	case av.NS != nil:
+		return map[string]interface{}{"NS": aws.StringValueSlice(av.NS)}
+	case av.BS != nil:
+		return map[string]interface{}{"BS": av.BS}
+	case av.L != nil:
+		list := make([]interface{}, 0, len(av.L))
+		for _, v := range av.L {
+			list = append(list, dynamoJSONValue(v))
+		}
+		return map[string]interface{}{"L": list}
+	case av.M != nil:
+		return map[string]interface{}{"M": dynamoJSONMap(av.M)}
+	}
+
+	return map[string]interface{}{"NULL": true}
+}
+
+func dynamoJSONMap(m Map) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[k] = dynamoJSONValue(v)
+	}
+
+	return result
+}
+
+// jsonValue converts an attribute value to plain json, numbers are kept as json.Number to preserve their precision
+func jsonValue(av *dynamodb.AttributeValue) interface{} {
+	switch {
+	case av == nil:
+		return nil
+	case av.S != nil:
+		return *av.S
+	case av.N != nil:
+		return json.Number(*av.N)
+	case av.B != nil:
+		return av.B
+	case av.BOOL != nil:
+		return *av.BOOL
+	case av.SS != nil:
+		return aws.StringValueSlice(av.SS)
+	case av.NS != nil:
+		list := make([]json.Number, 0, len(av.NS))
+		for _, n := range av.NS {
+			list = append(list, json.Number(aws.StringValue(n)))
+		}
+		return list
+	case av.BS != nil:
+		return av.BS
+	case av.L != nil:
+		list := make([]interface{}, 0, len(av.L))
+		for _, v := range av.L {
+			list = append(list, jsonValue(v))
+		}
+		return list
+	case av.M != nil:
+		return jsonMap(av.M)
+	}
+
+	return nil
+}
+
+func jsonMap(m Map) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[k] = jsonValue(v)
+	}
+
+	return result
+}
+
+// decodeDynamoJSON decodes a line of a dynamodb json export
+func decodeDynamoJSON(line []byte) (Map, error) {
+	var decoded struct {
+		Item Map `json:"Item"`
+	}
+	if err := json.Unmarshal(line, &decoded); err != nil {
+		return nil, errors.Wrap(ErrInvalidItem, err.Error())
+	}
+	if len(decoded.Item) == 0 {
+		return nil, errors.Wrap(ErrInvalidItem, "line has no Item")
+	}
+	for name, av := range decoded.Item {
+		if !hasType(av) {
+			return nil, errors.Wrapf(ErrInvalidItem, "attribute %s has no type", name)
+		}
+	}
+
+	return decoded.Item, nil
+}
+
+// decodeJSON decodes a plain json object, numbers are decoded as json.Number to preserve their precision
+func decodeJSON(line []byte) (Map, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	var decoded map[string]interface{}
+	if err := dec.Decode(&decoded); err != nil {
+		return nil, errors.Wrap(ErrInvalidItem, err.Error())
+	}
+	if len(decoded) == 0 {
+		return nil, errors.Wrap(ErrInvalidItem, "line has no attributes")
+	}
+
+	item := make(Map, len(decoded))
+	for k, v := range decoded {
+		item[k] = fromJSON(v)
+	}
+
+	return item, nil
+}
+
+// fromJSON converts a decoded plain json value to an attribute value, arrays become lists and objects maps
+func fromJSON(val interface{}) *dynamodb.AttributeValue {
+	switch v := val.(type) {
+	case string:
+		return &dynamodb.AttributeValue{S: aws.String(v)}
+	case json.Number:
+		return &dynamodb.AttributeValue{N: aws.String(v.String())}
+	case bool:
+		return &dynamodb.AttributeValue{BOOL: aws.Bool(v)}
+	case []interface{}:
+		list := make([]*dynamodb.AttributeValue, 0, len(v))
+		for _, elem := range v {
+			list = append(list, fromJSON(elem))
+		}
+		return &dynamodb.AttributeValue{L: list}
+	case map[string]interface{}:
+		m := make(Map, len(v))
+		for k, elem := range v {
+			m[k] = fromJSON(elem)
+		}
+		return &dynamodb.AttributeValue{M: m}
+	}
+
+	return &dynamodb.AttributeValue{NULL: aws.Bool(true)}
+}
+
+// csvValue converts a csv cell to an attribute value of the provided column type
+func csvValue(typ, cell string) (*dynamodb.AttributeValue, error) {
+	switch typ {
+	case "", "S":
+		return &dynamodb.AttributeValue{S: aws.String(cell)}, nil
+	case "N":
+		if _, err := strconv.ParseFloat(cell, 64); err != nil && !errors.Is(err, strconv.ErrRange) {
+			return nil, errors.Wrapf(ErrInvalidItem, "%q is not a number", cell)
+		}
+		return &dynamodb.AttributeValue{N: aws.String(cell)}, nil
+	case "BOOL":
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidItem, "%q is not a bool", cell)
+		}
+		return &dynamodb.AttributeValue{BOOL: aws.Bool(b)}, nil
+	case "B":
+		b, err := base64.StdEncoding.DecodeString(cell)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidItem, "%q is not base64", cell)
+		}
+		return &dynamodb.AttributeValue{B: b}, nil
+	case "JSON":
+		dec := json.NewDecoder(strings.NewReader(cell))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, errors.Wrapf(ErrInvalidItem, "%q is not json", cell)
+		}
+		return fromJSON(v), nil
+	}
+
+	return nil, errors.Wrapf(ErrUnsupportedType, "column type %s", typ)
+}
+
+// hasType determines if an attribute value has a value of any type set
+func hasType(av *dynamodb.AttributeValue) bool {
+	return av != nil && (av.S != nil || av.N != nil || av.B != nil || av.BOOL != nil || av.NULL != nil ||
+		av.SS != nil || av.NS != nil || av.BS != nil || av.L != nil || av.M != nil)
+}
@@ -0,0 +1,29 @@
+package idempotency
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrInProgress occurs if a call for the same key is still running. use errors.Is to check for it
+	ErrInProgress = errors.New("idempotent call is in progress")
+	// ErrLockExpired occurs if the in-progress marker was taken over before the result could be stored
+	ErrLockExpired = errors.New("in-progress marker expired before the result was stored")
+)
+
+// InProgressError occurs if a call for the same key is still running
+type InProgressError struct {
+	Key string
+	// StartedAt is when the running call started, it is zero if unknown
+	StartedAt time.Time
+}
+
+func (e *InProgressError) Error() string {
+	return ErrInProgress.Error() + ": " + e.Key
+}
+
+// Is allows errors.Is(err, ErrInProgress) to match
+func (e *InProgressError) Is(target error) bool {
+	return target == ErrInProgress
+}
@@ -0,0 +1,325 @@
+// Package idempotency provides request de-duplication backed by a dynamodb table.
+//
+// The first call for a key records an in-progress marker with a conditional put, runs the provided
+// function while renewing the marker and stores its result. Repeated calls for the same key return the stored result
+// until it expires, or an InProgressError while the first call is still running.
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+
+	"github.com/darwayne/dyc"
+	"github.com/darwayne/dyc/internal/coordination"
+)
+
+// DefaultTTLAttribute is the attribute containing the expiration of records in epoch seconds
+const DefaultTTLAttribute = "ExpiresAt"
+
+const (
+	statusInProgress = "IN_PROGRESS"
+	statusCompleted  = "COMPLETED"
+)
+
+// maxAttempts is how many times a call is attempted if the record it conflicted with disappears before it can be read
+const maxAttempts = 3
+
+// record is the item stored in dynamodb
+type record struct {
+	IdempotencyStatus string
+	Token             string
+	StartedAt         int64
+	LockExpiresAt     int64
+	Result            *dynamodb.AttributeValue
+}
+
+// Store de-duplicates calls using records stored in a single table
+type Store struct {
+	cli          *dyc.Client
+	table        string
+	keyNames     []string
+	ttlAttribute string
+	lockTimeout  time.Duration
+	now          func() time.Time
+}
+
+// Option allows you to configure the store
+type Option func(s *Store)
+
+// WithKeyNames sets the key attribute names of the table. by default they are discovered via the client
+func WithKeyNames(names ...string) Option {
+	return func(s *Store) {
+		s.keyNames = names
+	}
+}
+
+// WithTTLAttribute sets the attribute containing the expiration of records in epoch seconds.
+// time to live should be enabled on this attribute so expired records are removed
+func WithTTLAttribute(name string) Option {
+	return func(s *Store) {
+		s.ttlAttribute = name
+	}
+}
+
+// WithLockTimeout sets how long an in-progress marker blocks other calls for the same key without being renewed.
+// the marker is renewed by a heartbeat while fn runs, so markers left behind by crashed processes
+// can be taken over once the timeout has passed. defaults to 30 seconds
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(s *Store) {
+		s.lockTimeout = timeout
+	}
+}
+
+// New creates a store that keeps its records in the provided table
+func New(cli *dyc.Client, table string, opts ...Option) *Store {
+	s := &Store{
+		cli:          cli,
+		table:        table,
+		ttlAttribute: DefaultTTLAttribute,
+		lockTimeout:  30 * time.Second,
+		now:          time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Result is the stored result of an idempotent call
+type Result struct {
+	// Replayed is true if the result was stored by an earlier call and fn wasn't called
+	Replayed bool
+	value    *dynamodb.AttributeValue
+}
+
+// Unmarshal unmarshals the result into out, which must be a pointer
+func (r *Result) Unmarshal(out interface{}) error {
+	if r.value == nil {
+		return nil
+	}
+
+	return dynamodbattribute.Unmarshal(r.value, out)
+}
+
+// Idempotent runs fn once per key and stores its result for ttl.
+// the result of fn is marshaled with the aws sdk, use Result.Unmarshal to read it.
+// repeated calls for the same key return the stored result without calling fn,
+// or an InProgressError if the first call hasn't completed yet.
+// the context passed to fn is canceled if its in-progress marker can't be renewed before the lock timeout,
+// results aren't stored if fn returns an error, so the key can be retried
+func (s *Store) Idempotent(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) (interface{}, error)) (*Result, error) {
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		token, expires, err := s.start(ctx, key, ttl)
+		if err != nil {
+			return nil, err
+		}
+		if token != "" {
+			return s.run(ctx, key, token, expires, fn)
+		}
+
+		current, found, err := s.get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			continue
+		}
+		if current.IdempotencyStatus == statusCompleted {
+			return &Result{Replayed: true, value: current.Result}, nil
+		}
+
+		return nil, &InProgressError{Key: key, StartedAt: time.Unix(0, current.StartedAt)}
+	}
+
+	return nil, &InProgressError{Key: key}
+}
+
+// start records an in-progress marker for the key and returns its token and lock expiration.
+// an empty token is returned if another record is active
+func (s *Store) start(ctx context.Context, key string, ttl time.Duration) (string, time.Time, error) {
+	token, err := coordination.NewToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	b, keys, err := s.builder(ctx, key)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := s.now()
+	expires := now.Add(s.lockTimeout)
+	item, err := dynamodbattribute.MarshalMap(record{
+		IdempotencyStatus: statusInProgress,
+		Token:             token,
+		StartedAt:         now.UnixNano(),
+		LockExpiresAt:     expires.UnixNano(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	for k, v := range keys {
+		item[k] = v
+	}
+	item[s.ttlAttribute] = dyc.Int(int(now.Add(ttl).Unix()))
+
+	_, err = b.
+		Condition(`attribute_not_exists(IdempotencyStatus) OR '`+s.ttlAttribute+`' < :now OR (IdempotencyStatus = :inProgress AND LockExpiresAt < :nowNano)`, dyc.Params{
+			"now":        now.Unix(),
+			"nowNano":    now.UnixNano(),
+			"inProgress": statusInProgress,
+		}).
+		PutItem(ctx, item)
+	if dyc.IsConditionalCheckFailure(err) {
+		return "", time.Time{}, nil
+	}
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expires, nil
+}
+
+// run calls fn while renewing the in-progress marker and stores its result, the marker is removed if fn fails
+func (s *Store) run(ctx context.Context, key, token string, expires time.Time, fn func(ctx context.Context) (interface{}, error)) (*Result, error) {
+	b, _, err := s.builder(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	fnCtx, cancel := context.WithCancel(ctx)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		s.heartbeat(fnCtx, cancel, key, token, expires)
+	}()
+
+	output, err := fn(fnCtx)
+	cancel()
+	<-stopped
+	if err != nil {
+		_, _ = b.Condition(`Token = ?`, token).DeleteItem(context.Background())
+		return nil, err
+	}
+
+	stored, err := dynamodbattribute.Marshal(output)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = b.
+		Update(`SET IdempotencyStatus = ?, 'Result' = ? REMOVE LockExpiresAt`, statusCompleted, stored).
+		Condition(`Token = ?`, token).
+		UpdateItem(ctx)
+	if dyc.IsConditionalCheckFailure(err) {
+		return nil, ErrLockExpired
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{value: stored}, nil
+}
+
+// heartbeat renews the in-progress marker until ctx is done.
+// cancel is called once the marker expires without being renewed or was taken over
+func (s *Store) heartbeat(ctx context.Context, cancel context.CancelFunc, key, token string, expires time.Time) {
+	deadline := time.AfterFunc(expires.Sub(s.now()), cancel)
+	defer deadline.Stop()
+
+	ticker := time.NewTicker(s.lockTimeout / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		renewed, err := s.renew(ctx, key, token, expires)
+		if dyc.IsConditionalCheckFailure(err) {
+			cancel()
+			return
+		}
+		// transient failures are retried on the next tick, the deadline cancels fn once the marker expires.
+		// the marker may also have expired while a successful renewal was in flight
+		if err == nil {
+			if ctx.Err() != nil || !deadline.Stop() {
+				return
+			}
+			expires = renewed
+			deadline.Reset(expires.Sub(s.now()))
+		}
+	}
+}
+
+// renew extends the lock expiration of the in-progress marker.
+// the request times out well before the current expiration so a slow renewal can't keep fn running past it
+func (s *Store) renew(ctx context.Context, key, token string, current time.Time) (time.Time, error) {
+	timeout := current.Sub(s.now()) / 2
+	if interval := s.lockTimeout / 3; timeout > interval {
+		timeout = interval
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	b, _, err := s.builder(ctx, key)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	expires := s.now().Add(s.lockTimeout)
+	_, err = b.
+		Update(`SET LockExpiresAt = ?`, expires.UnixNano()).
+		Condition(`Token = ? AND IdempotencyStatus = ?`, token, statusInProgress).
+		UpdateItem(ctx)
+
+	return expires, err
+}
+
+// get returns the active record for the key, expired records are treated as missing
+func (s *Store) get(ctx context.Context, key string) (record, bool, error) {
+	var current record
+	b, _, err := s.builder(ctx, key)
+	if err != nil {
+		return current, false, err
+	}
+
+	output, err := b.ConsistentRead(true).GetItem(ctx)
+	if err != nil || len(output.Item) == 0 {
+		return current, false, err
+	}
+
+	var expires int64
+	if val := output.Item[s.ttlAttribute]; val != nil {
+		if err := dynamodbattribute.Unmarshal(val, &expires); err != nil {
+			return current, false, err
+		}
+	}
+	if expires < s.now().Unix() {
+		return current, false, nil
+	}
+
+	err = dynamodbattribute.UnmarshalMap(output.Item, &current)
+
+	return current, err == nil, err
+}
+
+func (s *Store) builder(ctx context.Context, key string) (*dyc.Builder, dyc.Map, error) {
+	keys, err := coordination.ResolveKey(ctx, s.cli, s.table, s.keyNames, "dyc#idempotency#"+key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, err := coordination.Builder(s.cli, s.table, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return b, coordination.KeyMap(keys), nil
+}
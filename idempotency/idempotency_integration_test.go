@@ -0,0 +1,144 @@
+//go:build integration
+// +build integration
+
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/darwayne/dyc"
+	"github.com/darwayne/dyc/internal/testing/dynamotest"
+)
+
+type receipt struct {
+	ID     string
+	Amount int
+}
+
+func setup(t *testing.T) (*dyc.Client, string) {
+	t.Helper()
+	t.Parallel()
+	table, db := dynamotest.SetupTestTable(context.Background(), t, "idempotency", dynamotest.DefaultSchema())
+
+	return dyc.NewClient(db), table
+}
+
+func testCtx(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestStore_Idempotent(t *testing.T) {
+	t.Run("should only run once per key", func(t *testing.T) {
+		cli, table := setup(t)
+		ctx := testCtx(t)
+		store := New(cli, table)
+
+		calls := 0
+		charge := func(ctx context.Context) (interface{}, error) {
+			calls++
+			return receipt{ID: "r1", Amount: calls * 100}, nil
+		}
+
+		result, err := store.Idempotent(ctx, "payment#1", time.Hour, charge)
+		require.NoError(t, err)
+		require.False(t, result.Replayed)
+		var first receipt
+		require.NoError(t, result.Unmarshal(&first))
+		require.Equal(t, receipt{ID: "r1", Amount: 100}, first)
+
+		result, err = store.Idempotent(ctx, "payment#1", time.Hour, charge)
+		require.NoError(t, err)
+		require.True(t, result.Replayed)
+		var second receipt
+		require.NoError(t, result.Unmarshal(&second))
+		require.Equal(t, first, second)
+		require.Equal(t, 1, calls)
+
+		_, err = store.Idempotent(ctx, "payment#2", time.Hour, charge)
+		require.NoError(t, err)
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("should return an in progress error while running", func(t *testing.T) {
+		cli, table := setup(t)
+		ctx := testCtx(t)
+		store := New(cli, table)
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		done := make(chan error, 1)
+		go func() {
+			_, err := store.Idempotent(ctx, "payment#1", time.Hour, func(ctx context.Context) (interface{}, error) {
+				close(started)
+				<-release
+				return "ok", nil
+			})
+			done <- err
+		}()
+
+		<-started
+		_, err := store.Idempotent(ctx, "payment#1", time.Hour, func(ctx context.Context) (interface{}, error) {
+			t.Fatal("should not be called")
+			return nil, nil
+		})
+		require.True(t, errors.Is(err, ErrInProgress))
+
+		close(release)
+		require.NoError(t, <-done)
+
+		result, err := store.Idempotent(ctx, "payment#1", time.Hour, nil)
+		require.NoError(t, err)
+		var value string
+		require.NoError(t, result.Unmarshal(&value))
+		require.Equal(t, "ok", value)
+	})
+
+	t.Run("should allow retries after a failure", func(t *testing.T) {
+		cli, table := setup(t)
+		ctx := testCtx(t)
+		store := New(cli, table)
+
+		failure := errors.New("declined")
+		_, err := store.Idempotent(ctx, "payment#1", time.Hour, func(ctx context.Context) (interface{}, error) {
+			return nil, failure
+		})
+		require.Equal(t, failure, err)
+
+		result, err := store.Idempotent(ctx, "payment#1", time.Hour, func(ctx context.Context) (interface{}, error) {
+			return "ok", nil
+		})
+		require.NoError(t, err)
+		var value string
+		require.NoError(t, result.Unmarshal(&value))
+		require.Equal(t, "ok", value)
+	})
+
+	t.Run("should run again once the result expires", func(t *testing.T) {
+		cli, table := setup(t)
+		ctx := testCtx(t)
+		store := New(cli, table)
+
+		calls := 0
+		fn := func(ctx context.Context) (interface{}, error) {
+			calls++
+			return calls, nil
+		}
+
+		_, err := store.Idempotent(ctx, "payment#1", 0, fn)
+		require.NoError(t, err)
+		store.now = func() time.Time { return time.Now().Add(2 * time.Second) }
+
+		result, err := store.Idempotent(ctx, "payment#1", time.Hour, fn)
+		require.NoError(t, err)
+		var value int
+		require.NoError(t, result.Unmarshal(&value))
+		require.Equal(t, 2, value)
+	})
+}
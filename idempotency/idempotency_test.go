@@ -0,0 +1,100 @@
+//go:build unit
+// +build unit
+
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/darwayne/dyc"
+	"github.com/darwayne/dyc/internal/testing/dynamotest"
+)
+
+func TestInProgressError(t *testing.T) {
+	var err error = &InProgressError{Key: "payment#1"}
+	require.True(t, errors.Is(err, ErrInProgress))
+	require.Equal(t, "idempotent call is in progress: payment#1", err.Error())
+
+	var inProgress *InProgressError
+	require.True(t, errors.As(err, &inProgress))
+	require.Equal(t, "payment#1", inProgress.Key)
+}
+
+func TestStore_builder(t *testing.T) {
+	s := New(dyc.NewClient(nil), "MyTable", WithKeyNames("PK", "SK"))
+	b, keys, err := s.builder(context.Background(), "payment#1")
+	require.NoError(t, err)
+
+	expected := dyc.Map{
+		"PK": dyc.String("dyc#idempotency#payment#1"),
+		"SK": dyc.String("dyc#idempotency#payment#1"),
+	}
+	require.Equal(t, expected, keys)
+
+	input, err := b.ToGet()
+	require.NoError(t, err)
+	require.Equal(t, expected, input.Key)
+}
+
+func TestStore_Idempotent(t *testing.T) {
+	const lockTimeout = 200 * time.Millisecond
+
+	// stub records markers and results, renewals of the marker are answered with renew
+	stub := func(t *testing.T, renew func() error) *dyc.Client {
+		return dynamotest.StubClient(t, func(ctx context.Context, op *dyc.Operation) error {
+			input, ok := op.Input.(*dynamodb.UpdateItemInput)
+			if !ok || strings.Contains(aws.StringValue(input.UpdateExpression), "REMOVE") {
+				return nil
+			}
+			return renew()
+		})
+	}
+
+	t.Run("should renew the marker while fn runs", func(t *testing.T) {
+		var renewals int32
+		store := New(stub(t, func() error {
+			atomic.AddInt32(&renewals, 1)
+			return nil
+		}), "MyTable", WithKeyNames("PK"), WithLockTimeout(lockTimeout))
+
+		result, err := store.Idempotent(context.Background(), "payment#1", time.Hour, func(ctx context.Context) (interface{}, error) {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(3 * lockTimeout):
+				return "ok", nil
+			}
+		})
+		require.NoError(t, err)
+		require.False(t, result.Replayed)
+		require.Greater(t, atomic.LoadInt32(&renewals), int32(3))
+
+		var value string
+		require.NoError(t, result.Unmarshal(&value))
+		require.Equal(t, "ok", value)
+	})
+
+	t.Run("should cancel fn once the marker is taken over", func(t *testing.T) {
+		store := New(stub(t, func() error {
+			return awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "taken over", nil)
+		}), "MyTable", WithKeyNames("PK"), WithLockTimeout(lockTimeout))
+
+		started := time.Now()
+		_, err := store.Idempotent(context.Background(), "payment#1", time.Hour, func(ctx context.Context) (interface{}, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		require.Equal(t, context.Canceled, err)
+		require.Less(t, time.Since(started), lockTimeout)
+	})
+}
@@ -0,0 +1,336 @@
+package dyc
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/pkg/errors"
+)
+
+// DefaultImportWorkers is the amount of batches written concurrently by ImportTable unless set via ImportWorkers
+const DefaultImportWorkers = 4
+
+// importBatchSize is the maximum amount of items written by a single BatchWriteItem request
+const importBatchSize = 25
+
+// importRetries is the amount of times a throttled batch is retried before the import fails
+const importRetries = 5
+
+// importBackoff is the delay before the first retry of a throttled batch, it doubles with every retry
+var importBackoff = 500 * time.Millisecond
+
+type importConfig struct {
+	gzip        bool
+	workers     int
+	columnTypes map[string]string
+}
+
+// ImportOption allows you to configure an import
+type ImportOption func(c *importConfig)
+
+// ImportGzip decompresses the input with gzip e.g to import a compressed export
+func ImportGzip() ImportOption {
+	return func(c *importConfig) {
+		c.gzip = true
+	}
+}
+
+// ImportWorkers sets the amount of batches written concurrently. defaults to DefaultImportWorkers
+func ImportWorkers(workers int) ImportOption {
+	return func(c *importConfig) {
+		c.workers = workers
+	}
+}
+
+// ImportColumnTypes sets the types of csv columns keyed by column name, columns without a type are strings.
+// supported types are S, N, BOOL, B (base64 encoded) and JSON (lists and maps from plain json)
+func ImportColumnTypes(types map[string]string) ImportOption {
+	return func(c *importConfig) {
+		c.columnTypes = types
+	}
+}
+
+// RejectedRow is a row that wasn't imported
+type RejectedRow struct {
+	// Line is the line of the row in the input, starting at 1
+	Line int
+	Err  error
+}
+
+// ImportResult contains the outcome of an import
+type ImportResult struct {
+	// Imported is the amount of items written to the table
+	Imported int64
+	// Rejected contains the rows that couldn't be decoded, were missing key attributes or were rejected by dynamodb
+	Rejected []RejectedRow
+}
+
+type importRow struct {
+	line int
+	item Map
+}
+
+// ImportTable writes the items read from r to the table, one item per line in the provided format.
+// items are written in batches of 25 by concurrent workers, throttled batches are retried with backoff.
+//
+// rows that can't be decoded or are missing a key attribute of the table are rejected without stopping the import,
+// as are items dynamodb rejects as invalid. the table keys are discovered via the table schema unless
+// schema discovery is disabled, in which case PK and SK are required. the import stops on any other error
+func (c *Client) ImportTable(ctx context.Context, table string, r io.Reader, format Format, opts ...ImportOption) (result ImportResult, err error) {
+	cfg := importConfig{workers: DefaultImportWorkers}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if format != DynamoDBJSON && format != JSONLines && format != CSV {
+		return result, ErrUnsupportedFormat
+	}
+	for _, typ := range cfg.columnTypes {
+		if _, err := csvValue(typ, ""); errors.Is(err, ErrUnsupportedType) {
+			return result, err
+		}
+	}
+	if cfg.workers < 1 {
+		cfg.workers = 1
+	}
+
+	ctx, done := c.startCall(ctx, Call{Method: "ImportTable", Table: table})
+	defer done(&err)
+
+	keys, err := c.TableKeys(ctx, table)
+	if err != nil {
+		return result, err
+	}
+	if cfg.gzip {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return result, err
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	var workerErr error
+	reject := func(rejected ...RejectedRow) {
+		mu.Lock()
+		result.Rejected = append(result.Rejected, rejected...)
+		mu.Unlock()
+	}
+
+	batches := make(chan []importRow, cfg.workers)
+	var wg sync.WaitGroup
+	wg.Add(cfg.workers)
+	for i := 0; i < cfg.workers; i++ {
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				imported, rejected, err := c.importBatch(ctx, table, batch)
+				mu.Lock()
+				result.Imported += int64(imported)
+				result.Rejected = append(result.Rejected, rejected...)
+				if err != nil && workerErr == nil {
+					workerErr = err
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	batch := make([]importRow, 0, importBatchSize)
+	readErr := readRows(r, format, cfg.columnTypes, func(row importRow, rowErr error) error {
+		if rowErr == nil {
+			rowErr = validateKeys(row.item, keys)
+		}
+		if rowErr != nil {
+			reject(RejectedRow{Line: row.line, Err: rowErr})
+			return nil
+		}
+
+		batch = append(batch, row)
+		if len(batch) < importBatchSize {
+			return nil
+		}
+		select {
+		case batches <- batch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		batch = make([]importRow, 0, importBatchSize)
+
+		return nil
+	})
+	if readErr == nil && len(batch) > 0 {
+		select {
+		case batches <- batch:
+		case <-ctx.Done():
+			readErr = ctx.Err()
+		}
+	}
+	close(batches)
+	wg.Wait()
+
+	sortRejected(result.Rejected)
+	if workerErr != nil {
+		return result, workerErr
+	}
+
+	return result, readErr
+}
+
+// importBatch writes a batch of rows. if dynamodb rejects the batch as invalid the rows are written one by one,
+// so only the invalid rows are rejected
+func (c *Client) importBatch(ctx context.Context, table string, rows []importRow) (imported int, rejected []RejectedRow, err error) {
+	requests := make([]*dynamodb.WriteRequest, 0, len(rows))
+	for _, row := range rows {
+		requests = append(requests, &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: row.item}})
+	}
+
+	backoff := importBackoff
+	for attempt := 0; ; attempt++ {
+		imported, err = c.BatchWriter(ctx, table, requests...)
+		if err == nil || !IsThrottled(err) || attempt == importRetries {
+			break
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return 0, nil, ctx.Err()
+		}
+		backoff *= 2
+	}
+	if err == nil || !isValidationError(err) {
+		return imported, nil, err
+	}
+
+	imported = 0
+	for _, row := range rows {
+		_, err := c.putItem(ctx, &dynamodb.PutItemInput{
+			TableName:              aws.String(table),
+			Item:                   row.item,
+			ReturnConsumedCapacity: c.capacityMode(ctx, nil),
+		})
+		switch {
+		case err == nil:
+			imported++
+		case isValidationError(err):
+			rejected = append(rejected, RejectedRow{Line: row.line, Err: err})
+		default:
+			return imported, rejected, err
+		}
+	}
+
+	return imported, rejected, nil
+}
+
+// readRows decodes the rows of r and calls fn with every row or the reason it couldn't be decoded.
+// reading stops if fn returns an error
+func readRows(r io.Reader, format Format, columnTypes map[string]string, fn func(row importRow, err error) error) error {
+	if format == CSV {
+		return readCSV(r, columnTypes, fn)
+	}
+
+	decode := decodeDynamoJSON
+	if format == JSONLines {
+		decode = decodeJSON
+	}
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 {
+			item, decodeErr := decode(trimmed)
+			if fnErr := fn(importRow{line: line, item: item}, decodeErr); fnErr != nil {
+				return fnErr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// readCSV decodes csv rows, the first row names the attributes. empty cells are omitted from the item
+func readCSV(r io.Reader, columnTypes map[string]string, fn func(row importRow, err error) error) error {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	header = append([]string(nil), header...)
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			if fnErr := fn(importRow{line: parseErr.StartLine}, errors.Wrap(ErrInvalidItem, err.Error())); fnErr != nil {
+				return fnErr
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		line, _ := cr.FieldPos(0)
+		item := make(Map, len(record))
+		var rowErr error
+		for idx, cell := range record {
+			if cell == "" {
+				continue
+			}
+			var av *dynamodb.AttributeValue
+			if av, rowErr = csvValue(columnTypes[header[idx]], cell); rowErr != nil {
+				rowErr = errors.Wrapf(rowErr, "column %s", header[idx])
+				break
+			}
+			item[header[idx]] = av
+		}
+		if fnErr := fn(importRow{line: line, item: item}, rowErr); fnErr != nil {
+			return fnErr
+		}
+	}
+}
+
+// validateKeys ensures the item has a non empty string, number or binary value for every key
+func validateKeys(item Map, keys []string) error {
+	for _, key := range keys {
+		av, found := item[key]
+		switch {
+		case !found:
+			return errors.Wrapf(ErrInvalidItem, "missing key attribute %s", key)
+		case av.S != nil && *av.S != "", av.N != nil, len(av.B) > 0:
+		default:
+			return errors.Wrapf(ErrInvalidItem, "key attribute %s must be a non empty string, number or binary", key)
+		}
+	}
+
+	return nil
+}
+
+func sortRejected(rows []RejectedRow) {
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].Line < rows[j].Line
+	})
+}
@@ -0,0 +1,200 @@
+//go:build unit
+// +build unit
+
+package dyc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+// importClient returns a client storing the items written by batch and put requests in items keyed by PK
+func importClient(t *testing.T, items map[string]Map, respond func(op *Operation) error) *Client {
+	return stubClient(t, func(op *Operation) error {
+		if respond != nil {
+			if err := respond(op); err != nil {
+				return err
+			}
+		}
+		switch input := op.Input.(type) {
+		case *dynamodb.BatchWriteItemInput:
+			require.LessOrEqual(t, len(input.RequestItems["MyTable"]), 25)
+			for _, req := range input.RequestItems["MyTable"] {
+				items[aws.StringValue(req.PutRequest.Item["PK"].S)] = req.PutRequest.Item
+			}
+		case *dynamodb.PutItemInput:
+			items[aws.StringValue(input.Item["PK"].S)] = input.Item
+		}
+		return nil
+	})
+}
+
+func TestClient_ImportTable(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should import dynamodb json exports", func(t *testing.T) {
+		exported := Map{
+			"PK":    String("a"),
+			"SK":    &dynamodb.AttributeValue{N: aws.String("12345678901234567890")},
+			"Tags":  StringSet("x", "y"),
+			"Raw":   {B: []byte("hi")},
+			"Attrs": {M: Map{"On": {BOOL: aws.Bool(true)}, "List": IntList(1)}},
+		}
+		export := stubClient(t, func(op *Operation) error {
+			op.Output.(*dynamodb.ScanOutput).Items = Maps{exported}
+			return nil
+		})
+		var buf bytes.Buffer
+		_, err := export.ExportTable(ctx, "MyTable", &buf, DynamoDBJSON, ExportSegments(1), ExportGzip())
+		require.NoError(t, err)
+
+		items := make(map[string]Map)
+		result, err := importClient(t, items, nil).ImportTable(ctx, "MyTable", &buf, DynamoDBJSON, ImportGzip())
+		require.NoError(t, err)
+		require.Equal(t, ImportResult{Imported: 1}, result)
+		require.Equal(t, exported, items["a"])
+	})
+
+	t.Run("should import json lines in batches and reject invalid rows", func(t *testing.T) {
+		var lines []string
+		for i := 0; i < 60; i++ {
+			lines = append(lines, `{"PK":"`+strconv.Itoa(i)+`","SK":1,"Age":30.5,"Meta":{"Tags":["a"],"Nil":null}}`)
+		}
+		lines = append(lines, `{"PK":"missing sort key"}`, ``, `not json`, `{"PK":"","SK":"empty"}`)
+
+		items := make(map[string]Map)
+		batches := 0
+		cli := importClient(t, items, func(op *Operation) error {
+			if _, ok := op.Input.(*dynamodb.BatchWriteItemInput); ok {
+				batches++
+			}
+			return nil
+		})
+		result, err := cli.ImportTable(ctx, "MyTable", strings.NewReader(strings.Join(lines, "\n")), JSONLines, ImportWorkers(2))
+		require.NoError(t, err)
+		require.Equal(t, int64(60), result.Imported)
+		require.Equal(t, 3, batches)
+		require.Len(t, items, 60)
+		require.Equal(t, Map{
+			"PK":   String("0"),
+			"SK":   Int(1),
+			"Age":  {N: aws.String("30.5")},
+			"Meta": {M: Map{"Tags": StringList("a"), "Nil": {NULL: aws.Bool(true)}}},
+		}, items["0"])
+
+		require.Len(t, result.Rejected, 3)
+		for idx, line := range []int{61, 63, 64} {
+			require.Equal(t, line, result.Rejected[idx].Line)
+			require.ErrorIs(t, result.Rejected[idx].Err, ErrInvalidItem)
+		}
+	})
+
+	t.Run("should import typed csv", func(t *testing.T) {
+		input := "PK,SK,Age,Active,Data,Note\n" +
+			"a,1,30,true,\"{\"\"Tags\"\":[\"\"x\"\"]}\",\n" +
+			"b,2,old,false,,hello\n" +
+			"c,3\n"
+
+		items := make(map[string]Map)
+		result, err := importClient(t, items, nil).ImportTable(ctx, "MyTable", strings.NewReader(input), CSV,
+			ImportColumnTypes(map[string]string{"Age": "N", "Active": "BOOL", "Data": "JSON"}))
+		require.NoError(t, err)
+		require.Equal(t, int64(1), result.Imported)
+		require.Equal(t, Map{
+			"PK":     String("a"),
+			"SK":     String("1"),
+			"Age":    Int(30),
+			"Active": {BOOL: aws.Bool(true)},
+			"Data":   {M: Map{"Tags": StringList("x")}},
+		}, items["a"])
+
+		require.Len(t, result.Rejected, 2)
+		require.Equal(t, 3, result.Rejected[0].Line)
+		require.Contains(t, result.Rejected[0].Err.Error(), "column Age")
+		require.Equal(t, 4, result.Rejected[1].Line)
+	})
+
+	t.Run("should reject items dynamodb considers invalid", func(t *testing.T) {
+		invalid := awserr.New("ValidationException", "item too large", nil)
+		items := make(map[string]Map)
+		cli := importClient(t, items, func(op *Operation) error {
+			switch input := op.Input.(type) {
+			case *dynamodb.BatchWriteItemInput:
+				return invalid
+			case *dynamodb.PutItemInput:
+				if aws.StringValue(input.Item["PK"].S) == "b" {
+					return invalid
+				}
+			}
+			return nil
+		})
+
+		input := `{"PK":"a","SK":"1"}` + "\n" + `{"PK":"b","SK":"1"}` + "\n" + `{"PK":"c","SK":"1"}`
+		result, err := cli.ImportTable(ctx, "MyTable", strings.NewReader(input), JSONLines)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), result.Imported)
+		require.Equal(t, []RejectedRow{{Line: 2, Err: invalid}}, result.Rejected)
+		require.Len(t, items, 2)
+	})
+
+	t.Run("should retry throttled batches and stop on other errors", func(t *testing.T) {
+		defer func(backoff time.Duration) { importBackoff = backoff }(importBackoff)
+		importBackoff = time.Millisecond
+
+		throttled := awserr.New(dynamodb.ErrCodeProvisionedThroughputExceededException, "slow down", nil)
+		calls := 0
+		items := make(map[string]Map)
+		cli := importClient(t, items, func(op *Operation) error {
+			calls++
+			if calls < 3 {
+				return throttled
+			}
+			return nil
+		})
+		result, err := cli.ImportTable(ctx, "MyTable", strings.NewReader(`{"PK":"a","SK":"1"}`), JSONLines)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), result.Imported)
+		require.Equal(t, 3, calls)
+
+		boom := awserr.New("InternalServerError", "boom", nil)
+		cli = importClient(t, items, func(op *Operation) error {
+			return boom
+		})
+		_, err = cli.ImportTable(ctx, "MyTable", strings.NewReader(`{"PK":"a","SK":"1"}`), JSONLines)
+		require.ErrorIs(t, err, boom)
+	})
+
+	t.Run("should reject unsupported formats and column types", func(t *testing.T) {
+		cli := importClient(t, nil, nil)
+		_, err := cli.ImportTable(ctx, "MyTable", strings.NewReader(""), Format(42))
+		require.ErrorIs(t, err, ErrUnsupportedFormat)
+
+		_, err = cli.ImportTable(ctx, "MyTable", strings.NewReader(""), CSV, ImportColumnTypes(map[string]string{"Age": "INT"}))
+		require.ErrorIs(t, err, ErrUnsupportedType)
+	})
+
+	t.Run("should fail on invalid gzip input", func(t *testing.T) {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, _ = zw.Write([]byte(`{"PK":"a","SK":"1"}`))
+		require.NoError(t, zw.Close())
+
+		cli := importClient(t, make(map[string]Map), nil)
+		_, err := cli.ImportTable(ctx, "MyTable", strings.NewReader("plain"), JSONLines, ImportGzip())
+		require.Error(t, err)
+
+		result, err := cli.ImportTable(ctx, "MyTable", &buf, JSONLines, ImportGzip())
+		require.NoError(t, err)
+		require.Equal(t, int64(1), result.Imported)
+	})
+}
@@ -0,0 +1,75 @@
+// Package coordination contains helpers shared by the packages that coordinate processes
+// through items of a dynamodb table: lock, election, migrate, queue and idempotency.
+package coordination
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strconv"
+
+	"github.com/darwayne/dyc"
+)
+
+// ErrNoKeys occurs if an item key has no key attributes
+var ErrNoKeys = errors.New("no key attributes")
+
+// ResolveKey returns the key of an item whose key attributes all contain value as alternating key names and values.
+// the key attribute names are resolved via Client.TableKeys unless names are provided
+func ResolveKey(ctx context.Context, cli *dyc.Client, table string, names []string, value string) ([]interface{}, error) {
+	if len(names) == 0 {
+		var err error
+		if names, err = cli.TableKeys(ctx, table); err != nil {
+			return nil, err
+		}
+	}
+	if len(names) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	keys := make([]interface{}, 0, len(names)*2)
+	for _, name := range names {
+		keys = append(keys, name, value)
+	}
+
+	return keys, nil
+}
+
+// Builder returns a builder for the item of the table identified by keys. ErrNoKeys is returned if keys is empty
+func Builder(cli *dyc.Client, table string, keys []interface{}) (*dyc.Builder, error) {
+	if len(keys) < 2 {
+		return nil, ErrNoKeys
+	}
+
+	return cli.Builder().
+		Table(table).
+		Key(keys[0].(string), keys[1], keys[2:]...), nil
+}
+
+// KeyMap returns keys as the key attributes of an item
+func KeyMap(keys []interface{}) dyc.Map {
+	result := make(dyc.Map, len(keys)/2)
+	for i := 0; i < len(keys); i += 2 {
+		result[keys[i].(string)] = dyc.String(keys[i+1].(string))
+	}
+
+	return result
+}
+
+// NewToken returns a random token used to identify owners, claims and messages
+func NewToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// DefaultOwner returns the hostname and pid of the process
+func DefaultOwner() string {
+	host, _ := os.Hostname()
+	return host + ":" + strconv.Itoa(os.Getpid())
+}
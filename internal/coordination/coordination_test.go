@@ -0,0 +1,74 @@
+//go:build unit
+// +build unit
+
+package coordination
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/darwayne/dyc"
+)
+
+func TestResolveKey(t *testing.T) {
+	cli := dyc.NewClient(nil)
+	keys, err := ResolveKey(context.Background(), cli, "MyTable", []string{"PK", "SK"}, "dyc#lock#jobs")
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"PK", "dyc#lock#jobs", "SK", "dyc#lock#jobs"}, keys)
+
+	expected := dyc.Map{"PK": dyc.String("dyc#lock#jobs"), "SK": dyc.String("dyc#lock#jobs")}
+	require.Equal(t, expected, KeyMap(keys))
+
+	b, err := Builder(cli, "MyTable", keys)
+	require.NoError(t, err)
+	input, err := b.ToGet()
+	require.NoError(t, err)
+	require.Equal(t, expected, input.Key)
+
+	_, err = Builder(cli, "MyTable", nil)
+	require.Equal(t, ErrNoKeys, err)
+}
+
+func TestResolveKey_Discovery(t *testing.T) {
+	t.Run("should fall back to PK,SK without schema discovery", func(t *testing.T) {
+		cli := dyc.NewClient(nil, dyc.WithoutSchemaDiscovery())
+		keys, err := ResolveKey(context.Background(), cli, "MyTable", nil, "dyc#lock#jobs")
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{"PK", "dyc#lock#jobs", "SK", "dyc#lock#jobs"}, keys)
+	})
+
+	t.Run("should fall back to PK,SK when DescribeTable is denied", func(t *testing.T) {
+		sess, err := session.NewSession(&aws.Config{
+			Region:      aws.String("us-east-1"),
+			Endpoint:    aws.String("http://127.0.0.1:1"),
+			Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+			MaxRetries:  aws.Int(0),
+		})
+		require.NoError(t, err)
+		cli := dyc.NewClient(dynamodb.New(sess))
+		cli.Use(func(next dyc.Handler) dyc.Handler {
+			return func(ctx context.Context, op *dyc.Operation) error {
+				return awserr.New("AccessDeniedException", "not authorized to perform: dynamodb:DescribeTable", nil)
+			}
+		})
+		keys, err := ResolveKey(context.Background(), cli, "MyTable", nil, "dyc#lock#jobs")
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{"PK", "dyc#lock#jobs", "SK", "dyc#lock#jobs"}, keys)
+	})
+}
+
+func TestNewToken(t *testing.T) {
+	first, err := NewToken()
+	require.NoError(t, err)
+	second, err := NewToken()
+	require.NoError(t, err)
+	require.Len(t, first, 32)
+	require.NotEqual(t, first, second)
+}
@@ -1,10 +1,10 @@
 package dynamotest
 
 import (
-	"sort"
-
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/darwayne/dyc"
 )
 
 // DefaultThroughput includes some basic throughput capacity
@@ -13,162 +13,35 @@ var DefaultThroughput = &dynamodb.ProvisionedThroughput{
 	WriteCapacityUnits: aws.Int64(25),
 }
 
-// DefaultKeySchema includes a default PK, SK based key schema
-func DefaultKeySchema() []*dynamodb.KeySchemaElement {
-	return toDynamoKeySchema(map[string]string{
-		"PK": "HASH",
-		"SK": "RANGE",
-	})
-}
-
-// DefaultDefinitions contains all the default attribute definitions
-func DefaultDefinitions() []*dynamodb.AttributeDefinition {
-	return toDynamoAttrs(map[string]string{
-		"PK":      "S",
-		"SK":      "S",
-		"TYP":     "S",
-		"GSI1PK":  "S",
-		"GSI1SK":  "S",
-		"GSI2PK":  "S",
-		"GSI2SK":  "S",
-		"GSI3PK":  "S",
-		"GSI3SK":  "S",
-		"GSI1PKS": "S",
-		"GSI1SKN": "N",
-		"GSI2PKS": "S",
-		"GSI2SKN": "N",
-		"GSI3PKS": "S",
-		"GSI3SKN": "N",
-	})
-}
-
-// DefaultGSIConfiguration contains some generic GSI keys
-func DefaultGSIConfiguration() []*dynamodb.GlobalSecondaryIndex {
-	return []*dynamodb.GlobalSecondaryIndex{
-		{
-			IndexName: aws.String("TYPE"),
-			KeySchema: toDynamoKeySchema(map[string]string{
-				"TYP": "HASH",
-			}),
-			Projection: &dynamodb.Projection{
-				ProjectionType: aws.String("ALL"),
-			},
-			ProvisionedThroughput: DefaultThroughput,
-		},
-		{
-			IndexName: aws.String("GSI1"),
-			KeySchema: toDynamoKeySchema(map[string]string{
-				"GSI1PK": "HASH",
-				"GSI1SK": "RANGE",
-			}),
-			Projection: &dynamodb.Projection{
-				ProjectionType: aws.String("ALL"),
-			},
-			ProvisionedThroughput: DefaultThroughput,
-		},
-		{
-			IndexName: aws.String("GSI2"),
-			KeySchema: toDynamoKeySchema(map[string]string{
-				"GSI2PK": "HASH",
-				"GSI2SK": "RANGE",
-			}),
-			Projection: &dynamodb.Projection{
-				ProjectionType: aws.String("ALL"),
-			},
-			ProvisionedThroughput: DefaultThroughput,
-		},
-		{
-			IndexName: aws.String("GSI3"),
-			KeySchema: toDynamoKeySchema(map[string]string{
-				"GSI3PK": "HASH",
-				"GSI3SK": "RANGE",
-			}),
-			Projection: &dynamodb.Projection{
-				ProjectionType: aws.String("ALL"),
-			},
-			ProvisionedThroughput: DefaultThroughput,
-		},
-		// == number based GSIs with hash as string and sort key as number
-		{
-			IndexName: aws.String("GSI1SKN"),
-			KeySchema: toDynamoKeySchema(map[string]string{
-				"GSI1PKS": "HASH",
-				"GSI1SKN": "RANGE",
-			}),
-			Projection: &dynamodb.Projection{
-				ProjectionType: aws.String("ALL"),
-			},
-			ProvisionedThroughput: DefaultThroughput,
-		},
-		{
-			IndexName: aws.String("GSI2SKN"),
-			KeySchema: toDynamoKeySchema(map[string]string{
-				"GSI2PKS": "HASH",
-				"GSI2SKN": "RANGE",
-			}),
-			Projection: &dynamodb.Projection{
-				ProjectionType: aws.String("ALL"),
-			},
-			ProvisionedThroughput: DefaultThroughput,
-		},
-		{
-			IndexName: aws.String("GSI3SKN"),
-			KeySchema: toDynamoKeySchema(map[string]string{
-				"GSI3PKS": "HASH",
-				"GSI3SKN": "RANGE",
-			}),
-			Projection: &dynamodb.Projection{
-				ProjectionType: aws.String("ALL"),
-			},
-			ProvisionedThroughput: DefaultThroughput,
+// DefaultSchema includes a default PK, SK based key schema and some generic GSIs.
+// the table name is set by SetupTestTable
+func DefaultSchema() dyc.TableSpec {
+	return dyc.TableSpec{
+		PartitionKey: stringKey("PK"),
+		SortKey:      stringKeyRef("SK"),
+		Throughput:   DefaultThroughput,
+		GlobalIndexes: []dyc.IndexSpec{
+			{Name: "TYPE", PartitionKey: stringKey("TYP")},
+			{Name: "GSI1", PartitionKey: stringKey("GSI1PK"), SortKey: stringKeyRef("GSI1SK")},
+			{Name: "GSI2", PartitionKey: stringKey("GSI2PK"), SortKey: stringKeyRef("GSI2SK")},
+			{Name: "GSI3", PartitionKey: stringKey("GSI3PK"), SortKey: stringKeyRef("GSI3SK")},
+			// == number based GSIs with hash as string and sort key as number
+			{Name: "GSI1SKN", PartitionKey: stringKey("GSI1PKS"), SortKey: numberKeyRef("GSI1SKN")},
+			{Name: "GSI2SKN", PartitionKey: stringKey("GSI2PKS"), SortKey: numberKeyRef("GSI2SKN")},
+			{Name: "GSI3SKN", PartitionKey: stringKey("GSI3PKS"), SortKey: numberKeyRef("GSI3SKN")},
 		},
 	}
 }
 
-// DefaultSchema to use
-func DefaultSchema() Schema {
-	return Schema{
-		KeySchema: DefaultKeySchema(),
-		Attrs:     DefaultDefinitions(),
-		GSI:       DefaultGSIConfiguration(),
-	}
+func stringKey(name string) dyc.KeyAttribute {
+	return dyc.KeyAttribute{Name: name, Type: dynamodb.ScalarAttributeTypeS}
 }
 
-// Schema represents a dynamodb schema
-type Schema struct {
-	KeySchema []*dynamodb.KeySchemaElement
-	Attrs     []*dynamodb.AttributeDefinition
-	GSI       []*dynamodb.GlobalSecondaryIndex
-}
-
-func toDynamoKeySchema(keySchema map[string]string) []*dynamodb.KeySchemaElement {
-	result := make([]*dynamodb.KeySchemaElement, 0, len(keySchema))
-	for k, v := range keySchema {
-		result = append(result, &dynamodb.KeySchemaElement{
-			AttributeName: aws.String(k),
-			KeyType:       aws.String(v),
-		})
-	}
-
-	sort.Slice(result, func(i, j int) bool {
-		return *result[i].KeyType < *result[j].KeyType
-	})
-
-	return result
+func stringKeyRef(name string) *dyc.KeyAttribute {
+	key := stringKey(name)
+	return &key
 }
 
-func toDynamoAttrs(attrs map[string]string) []*dynamodb.AttributeDefinition {
-	result := make([]*dynamodb.AttributeDefinition, 0, len(attrs))
-	for k, v := range attrs {
-		result = append(result, &dynamodb.AttributeDefinition{
-			AttributeName: aws.String(k),
-			AttributeType: aws.String(v),
-		})
-	}
-
-	sort.Slice(result, func(i, j int) bool {
-		return *result[i].AttributeName < *result[j].AttributeName
-	})
-
-	return result
+func numberKeyRef(name string) *dyc.KeyAttribute {
+	return &dyc.KeyAttribute{Name: name, Type: dynamodb.ScalarAttributeTypeN}
 }
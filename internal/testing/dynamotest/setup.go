@@ -14,8 +14,9 @@ import (
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/stretchr/testify/require"
+
+	"github.com/darwayne/dyc"
 )
 
 func endpoint() string {
@@ -30,19 +31,34 @@ func endpoint() string {
 }
 
 // SetupTestTable sets up a table that will have random characters
-// appended to the table name to avoid conflicts between tests with the same table name
-func SetupTestTable(parentCtx context.Context, t *testing.T, tableName string, schema Schema) (string, *dynamodb.DynamoDB) {
+// appended to the table name to avoid conflicts between tests with the same table name.
+// the table is created from the spec via dyc.Client.EnsureTable
+func SetupTestTable(parentCtx context.Context, t *testing.T, tableName string, spec dyc.TableSpec) (string, *dynamodb.DynamoDB) {
 	t.Helper()
 	ctx, cancel := context.WithTimeout(parentCtx, 30*time.Second)
 	defer cancel()
 
 	endpoint := endpoint()
 	db := setupDynamoSession(t, endpoint)
-	table := setupDynamoTable(ctx, t, db, tableName, schema)
+	table := setupDynamoTable(ctx, t, db, tableName, spec)
 
 	return table, db
 }
 
+// SetupTestDB sets up a dynamodb client pointing to the test endpoint without creating a table
+func SetupTestDB(t *testing.T) *dynamodb.DynamoDB {
+	t.Helper()
+	return setupDynamoSession(t, endpoint())
+}
+
+// UniqueTableName appends random characters to the table name to avoid conflicts between tests
+func UniqueTableName(t *testing.T, tableName string) string {
+	t.Helper()
+	hash := md5.Sum(randomBytes(t, 32))
+
+	return fmt.Sprintf("%s-%s", tableName, hex.EncodeToString(hash[:]))
+}
+
 func setupDynamoSession(t *testing.T, endpoint string) *dynamodb.DynamoDB {
 	t.Helper()
 	sess, err := session.NewSession(&aws.Config{
@@ -57,32 +73,15 @@ func setupDynamoSession(t *testing.T, endpoint string) *dynamodb.DynamoDB {
 	return dynamodb.New(sess)
 }
 
-func setupDynamoTable(ctx context.Context, t *testing.T, db dynamodbiface.DynamoDBAPI, tableName string, schema Schema) string {
+func setupDynamoTable(ctx context.Context, t *testing.T, db *dynamodb.DynamoDB, tableName string, spec dyc.TableSpec) string {
 	t.Helper()
 
-	hash := md5.Sum(randomBytes(t, 32))
-	id := hex.EncodeToString(hash[:])
-
-	table := fmt.Sprintf("%s-%s", tableName, id)
-
-	err := createDynamoTable(ctx, db, table, schema)
+	spec.Name = UniqueTableName(t, tableName)
+	_, err := dyc.NewClient(db).EnsureTable(ctx, spec)
 
 	require.NoError(t, err, "error while creating table")
 
-	return table
-}
-
-// Sets up a dynamo table with the provided attribute
-func createDynamoTable(ctx context.Context, db dynamodbiface.DynamoDBAPI, table string, schema Schema) error {
-	_, err := db.CreateTable(&dynamodb.CreateTableInput{
-		TableName:              &table,
-		AttributeDefinitions:   schema.Attrs,
-		KeySchema:              schema.KeySchema,
-		ProvisionedThroughput:  DefaultThroughput,
-		GlobalSecondaryIndexes: schema.GSI,
-	})
-
-	return err
+	return spec.Name
 }
 
 func randomBytes(t *testing.T, n int) []byte {
@@ -0,0 +1,36 @@
+package dynamotest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/darwayne/dyc"
+)
+
+// StubClient returns a client whose requests are answered by respond instead of dynamodb.
+// respond receives the context of the request and the operation whose Output it should fill
+func StubClient(t *testing.T, respond func(ctx context.Context, op *dyc.Operation) error) *dyc.Client {
+	t.Helper()
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String("http://127.0.0.1:1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		MaxRetries:  aws.Int(0),
+	})
+	require.NoError(t, err)
+
+	cli := dyc.NewClient(dynamodb.New(sess), dyc.WithoutSchemaDiscovery())
+	cli.Use(func(next dyc.Handler) dyc.Handler {
+		return func(ctx context.Context, op *dyc.Operation) error {
+			return respond(ctx, op)
+		}
+	})
+
+	return cli
+}
@@ -0,0 +1,265 @@
+package dyc
+
+import (
+	"strings"
+	"text/scanner"
+)
+
+// PartitionKey sets the partition key condition for a query
+// e.g PartitionKey("PK", "user#1")
+// note: the partition key can only be set once
+func (s *Builder) PartitionKey(name string, value interface{}) *Builder {
+	return s.update(func() {
+		if s.partitionKey != "" {
+			s.err = ErrInvalidKeyCondition
+			return
+		}
+		s.partitionKey = name
+		s.addExpression(&s.keyExpression, "AND", quoteName(name)+" = ?", value)
+	})
+}
+
+// SortKeyEquals adds a sort key condition where the sort key equals the provided value
+func (s *Builder) SortKeyEquals(name string, value interface{}) *Builder {
+	return s.sortKeyCondition(name, quoteName(name)+" = ?", value)
+}
+
+// SortKeyLessThan adds a sort key condition where the sort key is less than the provided value
+func (s *Builder) SortKeyLessThan(name string, value interface{}) *Builder {
+	return s.sortKeyCondition(name, quoteName(name)+" < ?", value)
+}
+
+// SortKeyLessThanOrEqual adds a sort key condition where the sort key is less than or equal to the provided value
+func (s *Builder) SortKeyLessThanOrEqual(name string, value interface{}) *Builder {
+	return s.sortKeyCondition(name, quoteName(name)+" <= ?", value)
+}
+
+// SortKeyGreaterThan adds a sort key condition where the sort key is greater than the provided value
+func (s *Builder) SortKeyGreaterThan(name string, value interface{}) *Builder {
+	return s.sortKeyCondition(name, quoteName(name)+" > ?", value)
+}
+
+// SortKeyGreaterThanOrEqual adds a sort key condition where the sort key is greater than or equal to the provided value
+func (s *Builder) SortKeyGreaterThanOrEqual(name string, value interface{}) *Builder {
+	return s.sortKeyCondition(name, quoteName(name)+" >= ?", value)
+}
+
+// SortKeyBetween adds a sort key condition where the sort key is between start and end (inclusive)
+func (s *Builder) SortKeyBetween(name string, start, end interface{}) *Builder {
+	return s.sortKeyCondition(name, quoteName(name)+" BETWEEN ? AND ?", start, end)
+}
+
+// SortKeyBeginsWith adds a sort key condition where the sort key begins with the provided prefix
+func (s *Builder) SortKeyBeginsWith(name string, prefix interface{}) *Builder {
+	return s.sortKeyCondition(name, "begins_with("+quoteName(name)+", ?)", prefix)
+}
+
+// sortKeyCondition adds the sort key condition ensuring only a single sort key condition is set
+func (s *Builder) sortKeyCondition(name, query string, vals ...interface{}) *Builder {
+	return s.update(func() {
+		if s.err = s.setSortKey(name); s.err != nil {
+			return
+		}
+		s.addExpression(&s.keyExpression, "AND", query, vals...)
+	})
+}
+
+// setSortKey records the sort key of a condition, the partition key has to be set first
+// and only a single sort key condition is allowed
+func (s *Builder) setSortKey(name string) error {
+	if s.untrackedKeys {
+		return nil
+	}
+	if s.partitionKey == "" || s.sortKey != "" || name == s.partitionKey {
+		return ErrInvalidKeyCondition
+	}
+	s.sortKey = name
+
+	return nil
+}
+
+// trackKeyConditions records the keys used by the conditions of a WhereKey query the same way the key condition
+// helpers do. if the partition key isn't set yet the equality condition on a known partition key sets it,
+// if the keys aren't known the partition key is only inferred from a query with a single equality condition.
+// keys of queries that remain ambiguous aren't tracked, dynamodb validates them instead
+func (s *Builder) trackKeyConditions(conditions []keyCondition) error {
+	if s.untrackedKeys {
+		return nil
+	}
+
+	partition := -1
+	if s.partitionKey == "" {
+		known := s.knownPartitionKeys()
+		var equalities, matches []int
+		for idx, cond := range conditions {
+			if !cond.equality {
+				continue
+			}
+			equalities = append(equalities, idx)
+			if contains(known, cond.name) {
+				matches = append(matches, idx)
+			}
+		}
+
+		switch {
+		case len(matches) == 1:
+			partition = matches[0]
+		case len(equalities) == 1:
+			partition = equalities[0]
+		case len(equalities) > 1:
+			s.untrackedKeys = true
+			return nil
+		}
+		if partition >= 0 {
+			s.partitionKey = conditions[partition].name
+		}
+	}
+
+	for idx, cond := range conditions {
+		if idx == partition {
+			continue
+		}
+		if err := s.setSortKey(cond.name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// knownPartitionKeys returns the partition keys the builder could be querying without calling dynamodb.
+// if the index isn't set yet the partition keys of the table and all of its known indexes are returned
+func (s *Builder) knownPartitionKeys() []string {
+	if s.index != "" && len(s.indexKeys) > 0 {
+		return s.indexKeys[:1]
+	}
+
+	var keys []string
+	schema, _ := s.knownSchema()
+	if schema != nil {
+		if s.index != "" {
+			if indexKeys := schema.IndexKeys(s.index); len(indexKeys) > 0 {
+				return indexKeys[:1]
+			}
+		}
+		if len(schema.Keys) > 0 {
+			keys = append(keys, schema.Keys[0])
+		}
+		for _, indexKeys := range schema.Indexes {
+			if len(indexKeys) > 0 {
+				keys = append(keys, indexKeys[0])
+			}
+		}
+	}
+	if len(s.indexKeys) > 0 {
+		keys = append(keys, s.indexKeys[0])
+	}
+
+	return keys
+}
+
+func quoteName(name string) string {
+	return "'" + name + "'"
+}
+
+// keyCondition is a single condition of a key condition expression
+type keyCondition struct {
+	// name of the key attribute the condition applies to
+	name string
+	// equality is set for conditions that could be the partition key condition e.g PK = ?
+	equality bool
+}
+
+// parseKeyCondition splits a key condition expression into its conditions using the scanner rules of Builder.
+// operators and functions dynamodb doesn't allow in key condition expressions are rejected
+func parseKeyCondition(query string) ([]keyCondition, error) {
+	var sc scanner.Scanner
+	sc.Init(strings.NewReader(query))
+	sc.Error = func(s *scanner.Scanner, msg string) {
+	}
+
+	var (
+		conditions []keyCondition
+		current    keyCondition
+		between    bool
+		beginsWith bool
+		equals     bool
+	)
+	closeCondition := func() error {
+		if current.name == "" {
+			return ErrInvalidKeyCondition
+		}
+		current.equality = equals && !between && !beginsWith
+		conditions = append(conditions, current)
+		current, between, beginsWith, equals = keyCondition{}, false, false, false
+		return nil
+	}
+	setName := func(name string) {
+		if current.name == "" {
+			current.name = name
+		}
+	}
+
+	var prev rune
+	pendingAnd := false
+	for tok := sc.Scan(); tok != scanner.EOF; prev, tok = tok, sc.Scan() {
+		switch tok {
+		case '>':
+			if prev == '<' {
+				return nil, ErrInvalidKeyCondition
+			}
+			continue
+		case '=':
+			if prev != '<' && prev != '>' {
+				equals = true
+			}
+			continue
+		case scanner.Char:
+			setName(strings.Trim(sc.TokenText(), "'"))
+			continue
+		case scanner.Ident:
+		default:
+			continue
+		}
+
+		if prev == ':' {
+			continue
+		}
+		if prev == '#' {
+			setName("#" + sc.TokenText())
+			continue
+		}
+
+		word := strings.ToUpper(sc.TokenText())
+		switch word {
+		case "OR", "NOT", "IN":
+			return nil, ErrInvalidKeyCondition
+		case "BETWEEN":
+			between, pendingAnd = true, true
+			continue
+		case "AND":
+			if pendingAnd {
+				pendingAnd = false
+				continue
+			}
+			if err := closeCondition(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if _, found := expressionFunctions[word]; found && sc.Peek() == '(' {
+			if word != "BEGINS_WITH" {
+				return nil, ErrInvalidKeyCondition
+			}
+			beginsWith = true
+			continue
+		}
+		setName(sc.TokenText())
+	}
+	if err := closeCondition(); err != nil {
+		return nil, err
+	}
+
+	return conditions, nil
+}
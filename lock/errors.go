@@ -0,0 +1,10 @@
+package lock
+
+import "errors"
+
+var (
+	// ErrLockHeld occurs if the lock is currently held by someone else
+	ErrLockHeld = errors.New("lock is held by another owner")
+	// ErrNotOwner occurs if a lock is released after its lease was lost
+	ErrNotOwner = errors.New("lock is no longer owned")
+)
@@ -0,0 +1,339 @@
+// Package lock provides lease based distributed locks stored in a dynamodb table.
+//
+// A lock is held by writing an item with an owner token and a lease expiration.
+// While held, the lease is renewed by a background heartbeat. The context returned by
+// Lock.Context is canceled once the lease expires without being renewed or is taken over,
+// so work can be stopped before someone else acquires the lock.
+//
+// note: lease expiration is compared using the local clock of each process,
+// so lease durations should be comfortably larger than expected clock skew
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/darwayne/dyc"
+	"github.com/darwayne/dyc/internal/coordination"
+)
+
+// DefaultPrefix is prepended to lock names to build the key of the lock item
+const DefaultPrefix = "dyc#lock#"
+
+// Client acquires locks stored in a single table
+type Client struct {
+	cli           *dyc.Client
+	table         string
+	keyNames      []string
+	prefix        string
+	owner         string
+	ttlAttribute  string
+	leaseDuration time.Duration
+	heartbeat     time.Duration
+	retryInterval time.Duration
+	now           func() time.Time
+}
+
+// Option allows you to configure the lock client
+type Option func(c *Client)
+
+// WithKeyNames sets the key attribute names of the lock table. by default they are discovered via the client
+func WithKeyNames(names ...string) Option {
+	return func(c *Client) {
+		c.keyNames = names
+	}
+}
+
+// WithPrefix sets the prefix prepended to lock names when building the key of the lock item
+func WithPrefix(prefix string) Option {
+	return func(c *Client) {
+		c.prefix = prefix
+	}
+}
+
+// WithOwner sets the name used to identify this process as the lock holder. defaults to the hostname and pid
+func WithOwner(owner string) Option {
+	return func(c *Client) {
+		c.owner = owner
+	}
+}
+
+// WithLeaseDuration sets how long a lock is held without being renewed. defaults to 20 seconds
+func WithLeaseDuration(duration time.Duration) Option {
+	return func(c *Client) {
+		c.leaseDuration = duration
+	}
+}
+
+// WithHeartbeat sets how often held leases are renewed. defaults to a third of the lease duration
+func WithHeartbeat(interval time.Duration) Option {
+	return func(c *Client) {
+		c.heartbeat = interval
+	}
+}
+
+// WithRetryInterval sets how often Acquire retries while a lock is held by someone else. defaults to 1 second
+func WithRetryInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		c.retryInterval = interval
+	}
+}
+
+// WithTTLAttribute sets an attribute that will contain the lease expiration in epoch seconds
+// so abandoned locks can be cleaned up by dynamodb's time to live
+func WithTTLAttribute(name string) Option {
+	return func(c *Client) {
+		c.ttlAttribute = name
+	}
+}
+
+// New creates a lock client storing locks in the provided table
+func New(cli *dyc.Client, table string, opts ...Option) *Client {
+	c := &Client{
+		cli:           cli,
+		table:         table,
+		prefix:        DefaultPrefix,
+		owner:         coordination.DefaultOwner(),
+		leaseDuration: 20 * time.Second,
+		retryInterval: time.Second,
+		now:           time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.heartbeat <= 0 {
+		c.heartbeat = c.leaseDuration / 3
+	}
+
+	return c
+}
+
+// Lock is a held lock
+type Lock struct {
+	client  *Client
+	name    string
+	token   string
+	keys    []interface{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{}
+	mu      sync.Mutex
+	expires time.Time
+	// lost is set once the lease expired or was taken over, as opposed to ctx being canceled by Release
+	lost bool
+	// deadline cancels ctx when the lease expires, it is reset on every renewal
+	deadline *time.Timer
+}
+
+// TryAcquire attempts to acquire the named lock once. ErrLockHeld is returned if someone else holds the lock
+func (c *Client) TryAcquire(ctx context.Context, name string) (*Lock, error) {
+	keys, err := c.key(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	b, err := c.builder(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := coordination.NewToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := c.now()
+	expires := now.Add(c.leaseDuration)
+	_, err = b.
+		Update(c.setLease("Owner = :token, OwnerName = :owner, "), c.leaseParams(expires, dyc.Params{
+			"token": token,
+			"owner": c.owner,
+		})).
+		Condition(`attribute_not_exists(Owner) OR LeaseExpiresAt < ?`, now.UnixNano()).
+		UpdateItem(ctx)
+	if dyc.IsConditionalCheckFailure(err) {
+		return nil, ErrLockHeld
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	lockCtx, cancel := context.WithCancel(context.Background())
+	l := &Lock{
+		client:  c,
+		name:    name,
+		token:   token,
+		keys:    keys,
+		ctx:     lockCtx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		expires: expires,
+	}
+	l.deadline = time.AfterFunc(expires.Sub(c.now()), l.lose)
+	go l.heartbeat()
+
+	return l, nil
+}
+
+// Acquire waits until the named lock is acquired or the context is done
+func (c *Client) Acquire(ctx context.Context, name string) (*Lock, error) {
+	ticker := time.NewTicker(c.retryInterval)
+	defer ticker.Stop()
+
+	for {
+		l, err := c.TryAcquire(ctx, name)
+		if err != ErrLockHeld {
+			return l, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Name returns the name of the lock
+func (l *Lock) Name() string {
+	return l.name
+}
+
+// Context returns a context that is canceled once the lock is released or the lease is lost
+func (l *Lock) Context() context.Context {
+	return l.ctx
+}
+
+// Release stops renewing the lease and removes the lock.
+// ErrNotOwner is returned if the lease was lost before the lock was released, even if no one else acquired it since
+func (l *Lock) Release(ctx context.Context) error {
+	l.stop()
+
+	b, err := l.client.builder(l.keys)
+	if err != nil {
+		return err
+	}
+	_, err = b.
+		Condition(`Owner = ?`, l.token).
+		DeleteItem(ctx)
+	if dyc.IsConditionalCheckFailure(err) {
+		return ErrNotOwner
+	}
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.lost {
+		return ErrNotOwner
+	}
+
+	return nil
+}
+
+// lose marks the lease as lost and cancels the lock context
+func (l *Lock) lose() {
+	l.mu.Lock()
+	l.lost = true
+	l.mu.Unlock()
+	l.cancel()
+}
+
+// stop cancels the lock context and waits for the heartbeat to exit
+func (l *Lock) stop() {
+	l.cancel()
+	<-l.done
+}
+
+func (l *Lock) heartbeat() {
+	defer close(l.done)
+	defer l.deadline.Stop()
+	defer l.cancel()
+
+	ticker := time.NewTicker(l.client.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// transient failures are retried on the next tick, the deadline cancels the lock once the lease expires
+		if err := l.renew(); dyc.IsConditionalCheckFailure(err) {
+			l.lose()
+			return
+		}
+	}
+}
+
+// renew extends the lease. the request times out well before the current lease expires
+// so a slow renewal can't keep the lock context alive past the lease
+func (l *Lock) renew() error {
+	l.mu.Lock()
+	remaining := l.expires.Sub(l.client.now())
+	l.mu.Unlock()
+
+	timeout := remaining / 2
+	if timeout > l.client.heartbeat {
+		timeout = l.client.heartbeat
+	}
+	ctx, cancel := context.WithTimeout(l.ctx, timeout)
+	defer cancel()
+
+	b, err := l.client.builder(l.keys)
+	if err != nil {
+		return err
+	}
+	expires := l.client.now().Add(l.client.leaseDuration)
+	_, err = b.
+		Update(l.client.setLease(""), l.client.leaseParams(expires, nil)).
+		Condition(`Owner = ?`, l.token).
+		UpdateItem(ctx)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// the lease may have expired while the renewal was in flight, the lock is lost even if the renewal succeeded
+	if l.ctx.Err() == nil && l.deadline.Stop() {
+		l.expires = expires
+		l.deadline.Reset(expires.Sub(l.client.now()))
+	}
+
+	return nil
+}
+
+// setLease builds the SET expression for the lease, prefix is added to the assignments
+func (c *Client) setLease(prefix string) string {
+	query := "SET " + prefix + "LeaseExpiresAt = :expires"
+	if c.ttlAttribute != "" {
+		query += ", '" + c.ttlAttribute + "' = :ttl"
+	}
+
+	return query
+}
+
+func (c *Client) leaseParams(expires time.Time, params dyc.Params) dyc.Params {
+	if params == nil {
+		params = dyc.Params{}
+	}
+	params["expires"] = expires.UnixNano()
+	if c.ttlAttribute != "" {
+		params["ttl"] = expires.Unix()
+	}
+
+	return params
+}
+
+func (c *Client) builder(keys []interface{}) (*dyc.Builder, error) {
+	return coordination.Builder(c.cli, c.table, keys)
+}
+
+// key returns the key of the lock item as alternating key names and values
+func (c *Client) key(ctx context.Context, name string) ([]interface{}, error) {
+	return coordination.ResolveKey(ctx, c.cli, c.table, c.keyNames, c.prefix+name)
+}
@@ -0,0 +1,146 @@
+//go:build integration
+// +build integration
+
+package lock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/darwayne/dyc"
+	"github.com/darwayne/dyc/internal/testing/dynamotest"
+)
+
+func setup(t *testing.T) (*dyc.Client, string) {
+	t.Helper()
+	t.Parallel()
+	table, db := dynamotest.SetupTestTable(context.Background(), t, "lock", dynamotest.DefaultSchema())
+
+	return dyc.NewClient(db), table
+}
+
+func testCtx(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestClient(t *testing.T) {
+	t.Run("TryAcquire", func(t *testing.T) {
+		t.Run("should only allow a single holder", func(t *testing.T) {
+			cli, table := setup(t)
+			ctx := testCtx(t)
+			locks := New(cli, table)
+
+			l, err := locks.TryAcquire(ctx, "jobs")
+			require.NoError(t, err)
+
+			_, err = locks.TryAcquire(ctx, "jobs")
+			require.Equal(t, ErrLockHeld, err)
+
+			other, err := locks.TryAcquire(ctx, "other")
+			require.NoError(t, err)
+			require.NoError(t, other.Release(ctx))
+
+			require.NoError(t, l.Release(ctx))
+			require.Error(t, l.Context().Err())
+
+			l, err = locks.TryAcquire(ctx, "jobs")
+			require.NoError(t, err)
+			require.NoError(t, l.Release(ctx))
+		})
+
+		t.Run("should take over expired leases", func(t *testing.T) {
+			cli, table := setup(t)
+			ctx := testCtx(t)
+			locks := New(cli, table, WithLeaseDuration(time.Second), WithHeartbeat(time.Hour))
+
+			first, err := locks.TryAcquire(ctx, "jobs")
+			require.NoError(t, err)
+
+			time.Sleep(1100 * time.Millisecond)
+			second, err := locks.TryAcquire(ctx, "jobs")
+			require.NoError(t, err)
+
+			require.Equal(t, ErrNotOwner, first.Release(ctx))
+			require.NoError(t, second.Release(ctx))
+		})
+	})
+
+	t.Run("Acquire", func(t *testing.T) {
+		t.Run("should wait for release", func(t *testing.T) {
+			cli, table := setup(t)
+			ctx := testCtx(t)
+			locks := New(cli, table, WithRetryInterval(50*time.Millisecond))
+
+			l, err := locks.TryAcquire(ctx, "jobs")
+			require.NoError(t, err)
+			go func() {
+				time.Sleep(200 * time.Millisecond)
+				_ = l.Release(context.Background())
+			}()
+
+			next, err := locks.Acquire(ctx, "jobs")
+			require.NoError(t, err)
+			require.NoError(t, next.Release(ctx))
+		})
+
+		t.Run("should respect context cancellation", func(t *testing.T) {
+			cli, table := setup(t)
+			ctx := testCtx(t)
+			locks := New(cli, table, WithRetryInterval(50*time.Millisecond))
+
+			l, err := locks.TryAcquire(ctx, "jobs")
+			require.NoError(t, err)
+			defer l.Release(ctx)
+
+			waitCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+			defer cancel()
+			_, err = locks.Acquire(waitCtx, "jobs")
+			require.Equal(t, context.DeadlineExceeded, err)
+		})
+	})
+
+	t.Run("heartbeat", func(t *testing.T) {
+		t.Run("should keep the lease alive", func(t *testing.T) {
+			cli, table := setup(t)
+			ctx := testCtx(t)
+			locks := New(cli, table, WithLeaseDuration(time.Second), WithHeartbeat(200*time.Millisecond))
+
+			l, err := locks.TryAcquire(ctx, "jobs")
+			require.NoError(t, err)
+
+			time.Sleep(1500 * time.Millisecond)
+			_, err = locks.TryAcquire(ctx, "jobs")
+			require.Equal(t, ErrLockHeld, err)
+			require.NoError(t, l.Context().Err())
+			require.NoError(t, l.Release(ctx))
+		})
+
+		t.Run("should cancel the lock context once the lease is lost", func(t *testing.T) {
+			cli, table := setup(t)
+			ctx := testCtx(t)
+			locks := New(cli, table, WithLeaseDuration(time.Second), WithHeartbeat(200*time.Millisecond))
+
+			l, err := locks.TryAcquire(ctx, "jobs")
+			require.NoError(t, err)
+
+			keys, err := locks.key(ctx, "jobs")
+			require.NoError(t, err)
+			b, err := locks.builder(keys)
+			require.NoError(t, err)
+			_, err = b.DeleteItem(ctx)
+			require.NoError(t, err)
+
+			select {
+			case <-l.Context().Done():
+			case <-ctx.Done():
+				t.Fatal("lock context was not canceled")
+			}
+			require.Equal(t, ErrNotOwner, l.Release(ctx))
+		})
+	})
+}
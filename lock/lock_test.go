@@ -0,0 +1,139 @@
+//go:build unit
+// +build unit
+
+package lock
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/darwayne/dyc"
+	"github.com/darwayne/dyc/internal/testing/dynamotest"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("should default heartbeat to a third of the lease", func(t *testing.T) {
+		c := New(nil, "MyTable", WithLeaseDuration(30*time.Second))
+		require.Equal(t, 10*time.Second, c.heartbeat)
+	})
+
+	t.Run("should respect explicit heartbeat", func(t *testing.T) {
+		c := New(nil, "MyTable", WithHeartbeat(time.Second))
+		require.Equal(t, time.Second, c.heartbeat)
+	})
+}
+
+func TestClient_key(t *testing.T) {
+	c := New(nil, "MyTable", WithKeyNames("PK", "SK"), WithPrefix("locks#"))
+	keys, err := c.key(context.Background(), "jobs")
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"PK", "locks#jobs", "SK", "locks#jobs"}, keys)
+}
+
+func TestClient_setLease(t *testing.T) {
+	expires := time.Unix(100, 0)
+
+	t.Run("without ttl attribute", func(t *testing.T) {
+		c := New(nil, "MyTable")
+		require.Equal(t, "SET LeaseExpiresAt = :expires", c.setLease(""))
+		require.Equal(t, dyc.Params{"expires": expires.UnixNano()}, c.leaseParams(expires, nil))
+	})
+
+	t.Run("with ttl attribute", func(t *testing.T) {
+		c := New(nil, "MyTable", WithTTLAttribute("ExpiresAt"))
+		require.Equal(t, "SET Owner = :token, LeaseExpiresAt = :expires, 'ExpiresAt' = :ttl", c.setLease("Owner = :token, "))
+		require.Equal(t, dyc.Params{
+			"expires": expires.UnixNano(),
+			"ttl":     int64(100),
+		}, c.leaseParams(expires, nil))
+	})
+}
+
+func TestLock_Context(t *testing.T) {
+	const lease = 200 * time.Millisecond
+
+	t.Run("should be canceled when the lease expires while renewals hang", func(t *testing.T) {
+		var updates int32
+		cli := dynamotest.StubClient(t, func(ctx context.Context, op *dyc.Operation) error {
+			if atomic.AddInt32(&updates, 1) == 1 {
+				return nil
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		locks := New(cli, "MyTable", WithKeyNames("PK"), WithLeaseDuration(lease), WithHeartbeat(lease/4))
+
+		started := time.Now()
+		l, err := locks.TryAcquire(context.Background(), "jobs")
+		require.NoError(t, err)
+
+		select {
+		case <-l.Context().Done():
+			require.Less(t, time.Since(started), lease+lease/4)
+		case <-time.After(2 * lease):
+			t.Fatal("lock context outlived its lease")
+		}
+		require.Greater(t, atomic.LoadInt32(&updates), int32(1))
+	})
+
+	t.Run("should stay alive while renewals succeed", func(t *testing.T) {
+		cli := dynamotest.StubClient(t, func(ctx context.Context, op *dyc.Operation) error {
+			return nil
+		})
+		locks := New(cli, "MyTable", WithKeyNames("PK"), WithLeaseDuration(lease), WithHeartbeat(lease/4))
+
+		l, err := locks.TryAcquire(context.Background(), "jobs")
+		require.NoError(t, err)
+
+		time.Sleep(2 * lease)
+		require.NoError(t, l.Context().Err())
+		require.NoError(t, l.Release(context.Background()))
+		require.Error(t, l.Context().Err())
+	})
+}
+
+func TestLock_Release(t *testing.T) {
+	const lease = 200 * time.Millisecond
+
+	t.Run("should return ErrNotOwner once the lease expired", func(t *testing.T) {
+		var updates int32
+		cli := dynamotest.StubClient(t, func(ctx context.Context, op *dyc.Operation) error {
+			// the lock is acquired, every renewal after that hangs. the delete succeeds since no one took the lock
+			if op.Name != "UpdateItem" || atomic.AddInt32(&updates, 1) == 1 {
+				return nil
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		locks := New(cli, "MyTable", WithKeyNames("PK"), WithLeaseDuration(lease), WithHeartbeat(lease/4))
+
+		l, err := locks.TryAcquire(context.Background(), "jobs")
+		require.NoError(t, err)
+
+		<-l.Context().Done()
+		require.Equal(t, ErrNotOwner, l.Release(context.Background()))
+	})
+
+	t.Run("should return ErrNotOwner once the lease was taken over", func(t *testing.T) {
+		var updates int32
+		cli := dynamotest.StubClient(t, func(ctx context.Context, op *dyc.Operation) error {
+			if op.Name != "UpdateItem" || atomic.AddInt32(&updates, 1) == 1 {
+				return nil
+			}
+			return awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "taken over", nil)
+		})
+		locks := New(cli, "MyTable", WithKeyNames("PK"), WithLeaseDuration(lease), WithHeartbeat(lease/4))
+
+		l, err := locks.TryAcquire(context.Background(), "jobs")
+		require.NoError(t, err)
+
+		<-l.Context().Done()
+		require.Equal(t, ErrNotOwner, l.Release(context.Background()))
+	})
+}
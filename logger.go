@@ -0,0 +1,119 @@
+package dyc
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Logger receives debug logs of the requests made by the client. *slog.Logger satisfies this interface
+type Logger interface {
+	DebugContext(ctx context.Context, msg string, args ...interface{})
+}
+
+// LogOption allows you to configure request logging
+type LogOption func(l *requestLogger)
+
+// RedactValues replaces the values of logged expressions and keys with ?
+func RedactValues() LogOption {
+	return func(l *requestLogger) {
+		l.redact = true
+	}
+}
+
+// TruncateValues truncates logged values longer than max characters
+func TruncateValues(max int) LogOption {
+	return func(l *requestLogger) {
+		l.truncate = max
+	}
+}
+
+// WithLogger logs every request made by the client once it completes at debug level.
+// expressions are logged with their placeholders resolved e.g #1 = :0 is logged as status = "active"
+//
+// logged attributes:
+//   - operation, table and index
+//   - key_condition, filter, projection, condition and update expressions
+//   - key of single item operations
+//   - duration, retries and error
+func WithLogger(logger Logger, opts ...LogOption) ClientOption {
+	l := &requestLogger{logger: logger}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return func(c *Client) {
+		c.Use(l.middleware)
+	}
+}
+
+type requestLogger struct {
+	logger   Logger
+	redact   bool
+	truncate int
+}
+
+func (l *requestLogger) middleware(next Handler) Handler {
+	return func(ctx context.Context, op *Operation) error {
+		err := next(ctx, op)
+
+		args := []interface{}{"operation", op.Name}
+		if op.Table != "" {
+			args = append(args, "table", op.Table)
+		}
+		if op.Index != "" {
+			args = append(args, "index", op.Index)
+		}
+		for _, rendered := range RenderExpressions(op.Input, l.value) {
+			args = append(args, rendered.Name, rendered.Expression)
+		}
+		if key := inputKey(op.Input); key != nil {
+			args = append(args, "key", renderMap(key, l.value))
+		}
+		args = append(args, "duration", op.Duration, "retries", op.Retries)
+		if err != nil {
+			args = append(args, "error", err)
+		}
+		l.logger.DebugContext(ctx, "dyc request", args...)
+
+		return err
+	}
+}
+
+// value renders a single value respecting the redaction and truncation options
+func (l *requestLogger) value(av *dynamodb.AttributeValue) string {
+	if l.redact {
+		return "?"
+	}
+
+	return l.truncated(renderValue(av))
+}
+
+// truncated cuts rendered after the configured amount of characters, counting runes so multi-byte characters stay intact
+func (l *requestLogger) truncated(rendered string) string {
+	if l.truncate <= 0 {
+		return rendered
+	}
+
+	runes := 0
+	for idx := range rendered {
+		if runes == l.truncate {
+			return rendered[:idx] + "..."
+		}
+		runes++
+	}
+
+	return rendered
+}
+
+// inputKey returns the key of single item operations
+func inputKey(input interface{}) map[string]*dynamodb.AttributeValue {
+	rv := reflect.Indirect(reflect.ValueOf(input))
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	key, _ := fieldValue(rv, "Key").(map[string]*dynamodb.AttributeValue)
+
+	return key
+}
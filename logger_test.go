@@ -0,0 +1,117 @@
+//go:build unit
+// +build unit
+
+package dyc
+
+import (
+	"context"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedLog struct {
+	msg  string
+	args map[string]interface{}
+}
+
+type recordingLogger struct {
+	logs []recordedLog
+}
+
+func (r *recordingLogger) DebugContext(ctx context.Context, msg string, args ...interface{}) {
+	log := recordedLog{msg: msg, args: make(map[string]interface{})}
+	for i := 0; i+1 < len(args); i += 2 {
+		log.args[args[i].(string)] = args[i+1]
+	}
+	r.logs = append(r.logs, log)
+}
+
+func TestWithLogger(t *testing.T) {
+	setup := func(opts ...LogOption) (*Client, *recordingLogger) {
+		logger := &recordingLogger{}
+		cli := NewClient(offlineDB(t), WithoutSchemaDiscovery(), WithLogger(logger, opts...))
+		cli.Use(func(next Handler) Handler {
+			return func(ctx context.Context, op *Operation) error {
+				return nil
+			}
+		})
+
+		return cli, logger
+	}
+
+	t.Run("should log expressions with placeholders resolved", func(t *testing.T) {
+		cli, logger := setup()
+		_, err := cli.Builder().
+			Table("MyTable").
+			Index("GSI1").
+			WhereKey("GSI1PK = ?", "hello").
+			Where("status = ? AND 'data' IN (?, ?)", "active", 1, 2).
+			QueryAll(context.Background())
+		require.NoError(t, err)
+
+		require.Len(t, logger.logs, 1)
+		log := logger.logs[0]
+		require.Equal(t, "dyc request", log.msg)
+		require.Equal(t, "Query", log.args["operation"])
+		require.Equal(t, "MyTable", log.args["table"])
+		require.Equal(t, "GSI1", log.args["index"])
+		require.Equal(t, `(GSI1PK = "hello")`, log.args["key_condition"])
+		require.Equal(t, `(status = "active" AND data IN (1, 2))`, log.args["filter"])
+		require.NotContains(t, log.args, "error")
+	})
+
+	t.Run("should redact values", func(t *testing.T) {
+		cli, logger := setup(RedactValues())
+		_, err := cli.Builder().
+			Table("MyTable").
+			Key("PK", "secret").
+			Update("SET 'Name' = ?", "secret").
+			UpdateItem(context.Background())
+		require.NoError(t, err)
+
+		log := logger.logs[0]
+		require.Equal(t, "SET Name = ?", log.args["update"])
+		require.Equal(t, `{"PK": ?}`, log.args["key"])
+	})
+
+	t.Run("should truncate values", func(t *testing.T) {
+		cli, logger := setup(TruncateValues(5))
+		_, err := cli.Builder().
+			Table("MyTable").
+			Key("PK", "a").
+			Condition("Description = ?", "a very long description").
+			DeleteItem(context.Background())
+		require.NoError(t, err)
+
+		require.Equal(t, `(Description = "a ve...)`, logger.logs[0].args["condition"])
+	})
+
+	t.Run("should truncate multi-byte values on character boundaries", func(t *testing.T) {
+		cli, logger := setup(TruncateValues(3))
+		_, err := cli.Builder().
+			Table("MyTable").
+			Key("PK", "a").
+			Condition("Description = ? AND Title = ?", "héllo", "日本").
+			DeleteItem(context.Background())
+		require.NoError(t, err)
+
+		condition := logger.logs[0].args["condition"].(string)
+		require.True(t, utf8.ValidString(condition))
+		require.Equal(t, `(Description = "hé... AND Title = "日本...)`, condition)
+	})
+}
+
+func TestRenderValue(t *testing.T) {
+	require.Equal(t, `{"B": <3 bytes>, "L": [1, true, null], "M": {"A": "b"}, "NS": <<1, 2>>, "SS": <<"a">>}`,
+		renderValue(&dynamodb.AttributeValue{M: Map{
+			"L":  {L: []*dynamodb.AttributeValue{{N: aws.String("1")}, {BOOL: aws.Bool(true)}, {NULL: aws.Bool(true)}}},
+			"M":  {M: Map{"A": String("b")}},
+			"NS": {NS: aws.StringSlice([]string{"1", "2"})},
+			"SS": {SS: aws.StringSlice([]string{"a"})},
+			"B":  {B: []byte("abc")},
+		}}))
+}
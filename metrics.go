@@ -0,0 +1,95 @@
+package dyc
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Metrics receives measurements of the requests made by the client. implementations must be safe for concurrent use
+type Metrics interface {
+	// ObserveRequest is called once for every request made to dynamodb
+	ObserveRequest(m RequestMetrics)
+	// ObserveUnprocessed is called with the amount of items or keys dynamodb left unprocessed
+	// in a BatchWriteItem or BatchGetItem request, which the client retries
+	ObserveUnprocessed(table, operation string, items int)
+}
+
+// RequestMetrics contains the measurements of a single request made to dynamodb
+type RequestMetrics struct {
+	Table     string
+	Index     string
+	Operation string
+	Duration  time.Duration
+	// Err is the error returned by the request, if any
+	Err error
+	// Throttled is true if the request failed because the table or account exceeded its throughput
+	Throttled bool
+	// Throttles is the amount of attempts dynamodb throttled, including attempts the sdk retried successfully.
+	// use it rather than Throttled to detect hot partitions since most throttles are retried away
+	Throttles int
+	// Retries is the amount of times the sdk retried the request
+	Retries int
+	// Scanned is the amount of items evaluated by a query or scan, before filters were applied
+	Scanned int
+	// Returned is the amount of items returned by a query or scan
+	Returned int
+	// Capacity is the capacity consumed by the request
+	Capacity Capacity
+}
+
+// WithMetrics reports the measurements of every request made by the client to m.
+// consumed capacity is requested in TOTAL mode for requests that don't request it already,
+// the mode is chosen before the request is built so inputs passed to the middleware chain aren't modified
+func WithMetrics(m Metrics) ClientOption {
+	return func(c *Client) {
+		c.metrics = m
+		c.Use(metricsMiddleware(m))
+	}
+}
+
+func metricsMiddleware(m Metrics) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, op *Operation) error {
+			err := next(ctx, op)
+
+			measured := RequestMetrics{
+				Table:     op.Table,
+				Index:     op.Index,
+				Operation: op.Name,
+				Duration:  op.Duration,
+				Err:       err,
+				Throttled: IsThrottled(err),
+				Throttles: op.Throttles,
+				Retries:   op.Retries,
+			}
+			// middleware answering without calling dynamodb (e.g stubs) can still fail with a throttle
+			if measured.Throttled && measured.Throttles == 0 {
+				measured.Throttles = 1
+			}
+			if err == nil {
+				measured.Capacity = op.ConsumedCapacity()
+				switch output := op.Output.(type) {
+				case *dynamodb.QueryOutput:
+					measured.Scanned = int(aws.Int64Value(output.ScannedCount))
+					measured.Returned = int(aws.Int64Value(output.Count))
+				case *dynamodb.ScanOutput:
+					measured.Scanned = int(aws.Int64Value(output.ScannedCount))
+					measured.Returned = int(aws.Int64Value(output.Count))
+				}
+			}
+			m.ObserveRequest(measured)
+
+			return err
+		}
+	}
+}
+
+// observeUnprocessed reports unprocessed items to the metrics of the client
+func (c *Client) observeUnprocessed(table, operation string, items int) {
+	if c.metrics != nil && items > 0 {
+		c.metrics.ObserveUnprocessed(table, operation, items)
+	}
+}
@@ -0,0 +1,156 @@
+//go:build unit
+// +build unit
+
+package dyc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedMetrics struct {
+	mu          sync.Mutex
+	requests    []RequestMetrics
+	unprocessed map[string]int
+}
+
+func (r *recordedMetrics) ObserveRequest(m RequestMetrics) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, m)
+}
+
+func (r *recordedMetrics) ObserveUnprocessed(table, operation string, items int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.unprocessed[table+":"+operation] += items
+}
+
+func TestWithMetrics(t *testing.T) {
+	t.Run("should report requests and unprocessed items", func(t *testing.T) {
+		metrics := &recordedMetrics{unprocessed: make(map[string]int)}
+		cli := NewClient(offlineDB(t), WithMetrics(metrics))
+		calls := 0
+		cli.Use(func(next Handler) Handler {
+			return func(ctx context.Context, op *Operation) error {
+				calls++
+				input := op.Input.(*dynamodb.BatchGetItemInput)
+				require.Equal(t, dynamodb.ReturnConsumedCapacityTotal, aws.StringValue(input.ReturnConsumedCapacity))
+
+				output := op.Output.(*dynamodb.BatchGetItemOutput)
+				output.ConsumedCapacity = []*dynamodb.ConsumedCapacity{{TableName: aws.String("MyTable"), CapacityUnits: aws.Float64(1)}}
+				if calls == 1 {
+					output.UnprocessedKeys = map[string]*dynamodb.KeysAndAttributes{
+						"MyTable": {Keys: Maps{{"PK": String("b")}}},
+					}
+					return nil
+				}
+				return awserr.New(dynamodb.ErrCodeRequestLimitExceeded, "slow down", nil)
+			}
+		})
+
+		err := cli.BatchGetIterator(context.Background(), &dynamodb.BatchGetItemInput{
+			RequestItems: map[string]*dynamodb.KeysAndAttributes{
+				"MyTable": {Keys: Maps{{"PK": String("a")}, {"PK": String("b")}}},
+			},
+		}, func(output *dynamodb.GetItemOutput) error {
+			return nil
+		})
+		require.True(t, IsThrottled(err))
+
+		require.Len(t, metrics.requests, 2)
+		require.Equal(t, "MyTable", metrics.requests[0].Table)
+		require.Equal(t, "BatchGetItem", metrics.requests[0].Operation)
+		require.Equal(t, Capacity{ReadCapacityUnits: 1}, metrics.requests[0].Capacity)
+		require.NoError(t, metrics.requests[0].Err)
+		require.True(t, metrics.requests[1].Throttled)
+		require.Equal(t, 1, metrics.requests[1].Throttles)
+		require.Equal(t, 1, metrics.unprocessed["MyTable:BatchGetItem"])
+	})
+
+	t.Run("should not change the input used as query cache key", func(t *testing.T) {
+		metrics := &recordedMetrics{unprocessed: make(map[string]int)}
+		cli := NewClient(offlineDB(t), WithoutSchemaDiscovery(), WithMetrics(metrics),
+			WithItemCache(NewLRUCache(10), time.Minute, CacheQueries(time.Minute)))
+		queries := 0
+		cli.Use(func(next Handler) Handler {
+			return func(ctx context.Context, op *Operation) error {
+				queries++
+				input := op.Input.(*dynamodb.QueryInput)
+				require.Equal(t, dynamodb.ReturnConsumedCapacityTotal, aws.StringValue(input.ReturnConsumedCapacity))
+				op.Output.(*dynamodb.QueryOutput).Items = Maps{{"PK": String("a")}}
+				return nil
+			}
+		})
+
+		query := func() Maps {
+			results, err := cli.Builder().Table("MyTable").WhereKey("PK = ?", "a").QueryAll(context.Background())
+			require.NoError(t, err)
+			return results
+		}
+		require.Len(t, query(), 1)
+		require.Len(t, query(), 1)
+		require.Equal(t, 1, queries)
+		require.Len(t, metrics.requests, 1)
+	})
+}
+
+func TestWithMetrics_Throttles(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		if attempts <= 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ProvisionedThroughputExceededException","message":"slow down"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"Item":{"PK":{"S":"a"}}}`))
+	}))
+	defer server.Close()
+
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		Retryer: client.DefaultRetryer{
+			NumMaxRetries:    3,
+			MinRetryDelay:    time.Millisecond,
+			MaxRetryDelay:    time.Millisecond,
+			MinThrottleDelay: time.Millisecond,
+			MaxThrottleDelay: time.Millisecond,
+		},
+	})
+	require.NoError(t, err)
+
+	metrics := &recordedMetrics{unprocessed: make(map[string]int)}
+	cli := NewClient(dynamodb.New(sess), WithoutSchemaDiscovery(), WithMetrics(metrics))
+	_, err = cli.Builder().Table("MyTable").Key("PK", "a").GetItem(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, metrics.requests, 1)
+	require.False(t, metrics.requests[0].Throttled)
+	require.Equal(t, 2, metrics.requests[0].Throttles)
+	require.Equal(t, 2, metrics.requests[0].Retries)
+}
+
+func TestIsThrottled(t *testing.T) {
+	require.True(t, IsThrottled(awserr.New(dynamodb.ErrCodeProvisionedThroughputExceededException, "", nil)))
+	require.True(t, IsThrottled(awserr.New("ThrottlingException", "", nil)))
+	require.False(t, IsThrottled(awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "", nil)))
+	require.False(t, IsThrottled(errors.New("boom")))
+	require.False(t, IsThrottled(nil))
+}
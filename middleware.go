@@ -0,0 +1,256 @@
+package dyc
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Operation describes a single call made to dynamodb by the client.
+// paginated operations produce an operation per page and batch operations an operation per chunk
+type Operation struct {
+	// Name is the dynamodb api name of the operation e.g GetItem, Query or BatchWriteItem
+	Name string
+	// Table is the table the operation targets, batch operations spanning multiple tables leave it empty
+	Table string
+	// Index is the index queried or scanned by the operation, if any
+	Index string
+	// Input is the sdk input of the operation e.g *dynamodb.GetItemInput
+	Input interface{}
+	// Output is the sdk output of the operation e.g *dynamodb.GetItemOutput. it is only valid once the call succeeded
+	Output interface{}
+	// Duration is how long the call to dynamodb took including retries
+	Duration time.Duration
+	// Retries is the amount of times the sdk retried the call
+	Retries int
+	// Throttles is the amount of attempts dynamodb throttled, including attempts the sdk retried successfully
+	Throttles int
+
+	req *request.Request
+}
+
+// Handler performs an operation
+type Handler func(ctx context.Context, op *Operation) error
+
+// Middleware wraps a handler, it can inspect or modify the operation before and after calling next,
+// or skip calling next entirely (e.g to inject faults)
+type Middleware func(next Handler) Handler
+
+// Use adds middleware that wraps every dynamodb call made by the client.
+// middleware added first is the outermost
+func (c *Client) Use(middleware ...Middleware) {
+	c.middlewareMu.Lock()
+	defer c.middlewareMu.Unlock()
+
+	c.middleware = append(c.middleware, middleware...)
+}
+
+// Call describes a builder terminal method e.g QueryAll or PutItem, which may make many operations
+type Call struct {
+	// Method is the name of the builder method
+	Method string
+	// Table is the table set on the builder
+	Table string
+	// Index is the index set on the builder, if any
+	Index string
+}
+
+// CallHook is notified when a builder terminal method starts. the returned context is used for every operation
+// made by the method and done is called with the error returned by the method once it completes
+type CallHook func(ctx context.Context, call Call) (_ context.Context, done func(err error))
+
+// OnCall adds hooks that are notified of every builder terminal method run with the client,
+// e.g to start a span covering all pages fetched by QueryAll
+func (c *Client) OnCall(hooks ...CallHook) {
+	c.middlewareMu.Lock()
+	defer c.middlewareMu.Unlock()
+
+	c.callHooks = append(c.callHooks, hooks...)
+}
+
+// startCall notifies the call hooks, the returned function notifies them of completion in reverse order
+func (c *Client) startCall(ctx context.Context, call Call) (context.Context, func(err *error)) {
+	c.middlewareMu.RLock()
+	hooks := c.callHooks
+	c.middlewareMu.RUnlock()
+
+	dones := make([]func(err error), 0, len(hooks))
+	for _, hook := range hooks {
+		var done func(err error)
+		ctx, done = hook(ctx, call)
+		if done != nil {
+			dones = append(dones, done)
+		}
+	}
+
+	return ctx, func(err *error) {
+		for i := len(dones) - 1; i >= 0; i-- {
+			dones[i](*err)
+		}
+	}
+}
+
+// do runs the request through the middleware chain
+func (c *Client) do(ctx context.Context, req *request.Request) error {
+	op := &Operation{
+		Name:   req.Operation.Name,
+		Table:  operationTable(req.Params),
+		Index:  operationIndex(req.Params),
+		Input:  req.Params,
+		Output: req.Data,
+		req:    req,
+	}
+
+	c.middlewareMu.RLock()
+	handler := Handler(send)
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		handler = c.middleware[i](handler)
+	}
+	c.middlewareMu.RUnlock()
+
+	return handler(ctx, op)
+}
+
+// send is the innermost handler which makes the actual call to dynamodb
+func send(ctx context.Context, op *Operation) error {
+	op.req.SetContext(ctx)
+	// retry handlers run after every failed attempt, whether or not the sdk retries it
+	op.req.Handlers.Retry.PushBack(func(r *request.Request) {
+		if IsThrottled(r.Error) {
+			op.Throttles++
+		}
+	})
+	start := time.Now()
+	err := op.req.Send()
+	op.Duration = time.Since(start)
+	op.Retries = op.req.RetryCount
+
+	return err
+}
+
+// operationTable returns the table targeted by an sdk input
+func operationTable(input interface{}) string {
+	rv := reflect.Indirect(reflect.ValueOf(input))
+	if rv.Kind() != reflect.Struct {
+		return ""
+	}
+
+	if field := rv.FieldByName("TableName"); field.IsValid() {
+		if name, ok := field.Interface().(*string); ok {
+			return aws.StringValue(name)
+		}
+	}
+
+	if field := rv.FieldByName("RequestItems"); field.IsValid() && field.Kind() == reflect.Map && field.Len() == 1 {
+		return field.MapKeys()[0].String()
+	}
+
+	return ""
+}
+
+// operationIndex returns the index targeted by an sdk input
+func operationIndex(input interface{}) string {
+	switch input := input.(type) {
+	case *dynamodb.QueryInput:
+		return aws.StringValue(input.IndexName)
+	case *dynamodb.ScanInput:
+		return aws.StringValue(input.IndexName)
+	}
+
+	return ""
+}
+
+// ConsumedCapacity returns the capacity consumed by the operation, it is only reported once the call succeeded
+// and consumed capacity was requested
+func (o *Operation) ConsumedCapacity() Capacity {
+	rv := reflect.Indirect(reflect.ValueOf(o.Output))
+	if rv.Kind() != reflect.Struct {
+		return Capacity{}
+	}
+	field := rv.FieldByName("ConsumedCapacity")
+	if !field.IsValid() {
+		return Capacity{}
+	}
+
+	var consumed []*dynamodb.ConsumedCapacity
+	switch val := field.Interface().(type) {
+	case *dynamodb.ConsumedCapacity:
+		consumed = append(consumed, val)
+	case []*dynamodb.ConsumedCapacity:
+		consumed = val
+	}
+
+	read := o.Name == "GetItem" || o.Name == "BatchGetItem" || o.Name == "Query" || o.Name == "Scan" || o.Name == "TransactGetItems"
+	var total Capacity
+	for _, cc := range consumed {
+		if cc != nil {
+			total.add(toCapacity(read, cc.CapacityUnits, cc.ReadCapacityUnits, cc.WriteCapacityUnits))
+		}
+	}
+
+	return total
+}
+
+func (c *Client) putItem(ctx context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	req, output := c.DynamoDB.PutItemRequest(input)
+	return output, c.do(ctx, req)
+}
+
+func (c *Client) updateItem(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	req, output := c.DynamoDB.UpdateItemRequest(input)
+	return output, c.do(ctx, req)
+}
+
+func (c *Client) deleteItem(ctx context.Context, input *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+	req, output := c.DynamoDB.DeleteItemRequest(input)
+	return output, c.do(ctx, req)
+}
+
+func (c *Client) query(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+	req, output := c.DynamoDB.QueryRequest(input)
+	return output, c.do(ctx, req)
+}
+
+func (c *Client) scan(ctx context.Context, input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+	req, output := c.DynamoDB.ScanRequest(input)
+	return output, c.do(ctx, req)
+}
+
+func (c *Client) batchWriteItem(ctx context.Context, input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+	req, output := c.DynamoDB.BatchWriteItemRequest(input)
+	return output, c.do(ctx, req)
+}
+
+func (c *Client) batchGetItem(ctx context.Context, input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+	req, output := c.DynamoDB.BatchGetItemRequest(input)
+	return output, c.do(ctx, req)
+}
+
+func (c *Client) describeTable(ctx context.Context, input *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+	req, output := c.DynamoDB.DescribeTableRequest(input)
+	return output, c.do(ctx, req)
+}
+
+func (c *Client) describeTimeToLive(ctx context.Context, input *dynamodb.DescribeTimeToLiveInput) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	req, output := c.DynamoDB.DescribeTimeToLiveRequest(input)
+	return output, c.do(ctx, req)
+}
+
+func (c *Client) createTable(ctx context.Context, input *dynamodb.CreateTableInput) (*dynamodb.CreateTableOutput, error) {
+	req, output := c.DynamoDB.CreateTableRequest(input)
+	return output, c.do(ctx, req)
+}
+
+func (c *Client) updateTable(ctx context.Context, input *dynamodb.UpdateTableInput) (*dynamodb.UpdateTableOutput, error) {
+	req, output := c.DynamoDB.UpdateTableRequest(input)
+	return output, c.do(ctx, req)
+}
+
+func (c *Client) updateTimeToLive(ctx context.Context, input *dynamodb.UpdateTimeToLiveInput) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	req, output := c.DynamoDB.UpdateTimeToLiveRequest(input)
+	return output, c.do(ctx, req)
+}
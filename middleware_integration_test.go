@@ -0,0 +1,62 @@
+//go:build integration
+// +build integration
+
+package dyc_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/darwayne/dyc"
+)
+
+func TestClient_Use_Integration(t *testing.T) {
+	type row struct {
+		PK string
+		SK string
+	}
+
+	cli, table := setupClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	var ops []dyc.Operation
+	cli.Use(func(next dyc.Handler) dyc.Handler {
+		return func(ctx context.Context, op *dyc.Operation) error {
+			err := next(ctx, op)
+			mu.Lock()
+			ops = append(ops, *op)
+			mu.Unlock()
+			return err
+		}
+	})
+
+	_, err := cli.BatchPut(ctx, table, row{PK: "a", SK: "1"}, row{PK: "a", SK: "2"}, row{PK: "a", SK: "3"})
+	require.NoError(t, err)
+
+	results, err := cli.Builder().Table(table).WhereKey("PK = ?", "a").Limit(1).QueryAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	_, err = cli.Builder().Table(table).Key("PK", "a", "SK", "1").GetItem(ctx)
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	names := make(map[string]int)
+	for _, op := range ops {
+		names[op.Name]++
+		require.Equal(t, table, op.Table)
+		require.NotNil(t, op.Input)
+		require.NotNil(t, op.Output)
+		require.Greater(t, op.Duration, time.Duration(0))
+	}
+	require.Equal(t, 1, names["BatchWriteItem"])
+	require.GreaterOrEqual(t, names["Query"], 1)
+	require.Equal(t, 1, names["GetItem"])
+}
@@ -0,0 +1,116 @@
+//go:build unit
+// +build unit
+
+package dyc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+func offlineDB(t *testing.T) *dynamodb.DynamoDB {
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String("http://127.0.0.1:1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		MaxRetries:  aws.Int(0),
+	})
+	require.NoError(t, err)
+
+	return dynamodb.New(sess)
+}
+
+func TestClient_Use(t *testing.T) {
+	t.Run("should run middleware in the order it was added", func(t *testing.T) {
+		cli := NewClient(offlineDB(t))
+		var calls []string
+		record := func(name string) Middleware {
+			return func(next Handler) Handler {
+				return func(ctx context.Context, op *Operation) error {
+					calls = append(calls, name+":before")
+					err := next(ctx, op)
+					calls = append(calls, name+":after")
+					return err
+				}
+			}
+		}
+		cli.Use(record("outer"), record("middle"))
+		cli.Use(record("inner"))
+		cli.Use(func(next Handler) Handler {
+			return func(ctx context.Context, op *Operation) error {
+				calls = append(calls, op.Name+":"+op.Table)
+				return nil
+			}
+		})
+
+		_, err := cli.Builder().Table("MyTable").Key("PK", "a", "SK", "b").DeleteItem(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"outer:before", "middle:before", "inner:before",
+			"DeleteItem:MyTable",
+			"inner:after", "middle:after", "outer:after",
+		}, calls)
+	})
+
+	t.Run("should allow middleware to inject faults and outputs", func(t *testing.T) {
+		cli := NewClient(offlineDB(t))
+		fault := errors.New("throttled")
+		cli.Use(func(next Handler) Handler {
+			return func(ctx context.Context, op *Operation) error {
+				switch op.Name {
+				case "GetItem":
+					op.Output.(*dynamodb.GetItemOutput).Item = Map{"PK": String("a"), "Name": String("fake")}
+					return nil
+				default:
+					return fault
+				}
+			}
+		})
+
+		output, err := cli.Builder().Table("MyTable").Key("PK", "a").GetItem(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "fake", aws.StringValue(output.Item["Name"].S))
+
+		_, err = cli.Builder().Table("MyTable").Key("PK", "a").WhereKey("PK = ?", "a").QueryAll(context.Background())
+		require.ErrorIs(t, err, fault)
+	})
+
+	t.Run("should produce an operation per page", func(t *testing.T) {
+		cli := NewClient(offlineDB(t))
+		var pages int
+		cli.Use(func(next Handler) Handler {
+			return func(ctx context.Context, op *Operation) error {
+				pages++
+				output := op.Output.(*dynamodb.ScanOutput)
+				output.Items = Maps{{"PK": String("a")}}
+				if pages < 3 {
+					output.LastEvaluatedKey = Map{"PK": String("a")}
+				}
+				return nil
+			}
+		})
+
+		results, err := cli.Builder().Table("MyTable").WithPrimaryKeys("PK").ScanAll(context.Background())
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		require.Equal(t, 3, pages)
+	})
+}
+
+func TestOperationTable(t *testing.T) {
+	require.Equal(t, "MyTable", operationTable(&dynamodb.GetItemInput{TableName: aws.String("MyTable")}))
+	require.Equal(t, "MyTable", operationTable(&dynamodb.BatchWriteItemInput{
+		RequestItems: map[string][]*dynamodb.WriteRequest{"MyTable": nil},
+	}))
+	require.Empty(t, operationTable(&dynamodb.BatchGetItemInput{
+		RequestItems: map[string]*dynamodb.KeysAndAttributes{"A": nil, "B": nil},
+	}))
+	require.Empty(t, operationTable(nil))
+}
@@ -0,0 +1,12 @@
+package migrate
+
+import "errors"
+
+var (
+	// ErrLocked occurs if another process currently holds the migration lock or the lock was lost while migrating
+	ErrLocked = errors.New("migrations are locked by another process")
+	// ErrDuplicateVersion occurs if multiple migrations are registered with the same version
+	ErrDuplicateVersion = errors.New("duplicate migration version")
+	// ErrInvalidMigration occurs if a migration is missing its version or function
+	ErrInvalidMigration = errors.New("migration requires a positive version and a function")
+)
@@ -0,0 +1,119 @@
+package migrate
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/darwayne/dyc"
+)
+
+// Backfill parallel scans all items matching the builder and writes back every item changed by fn.
+// fn returns the updated item and true if the item should be written.
+// the total amount of items written is returned
+func Backfill(ctx context.Context, b *dyc.Builder, workers int, fn func(item dyc.Map) (dyc.Map, bool, error)) (int64, error) {
+	table, err := builderTable(b)
+	if err != nil {
+		return 0, err
+	}
+
+	cli := b.GetClient()
+	var written int64
+	err = b.ParallelScanIterate(ctx, workers, func(output *dynamodb.ScanOutput) error {
+		requests := make([]*dynamodb.WriteRequest, 0, len(output.Items))
+		for _, item := range output.Items {
+			updated, changed, err := fn(item)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				continue
+			}
+			requests = append(requests, &dynamodb.WriteRequest{
+				PutRequest: &dynamodb.PutRequest{Item: updated},
+			})
+		}
+
+		total, err := cli.BatchWriter(ctx, table, requests...)
+		atomic.AddInt64(&written, int64(total))
+
+		return err
+	}, true)
+
+	return atomic.LoadInt64(&written), err
+}
+
+// RewriteKeys parallel scans all items matching the builder and moves every item returned by fn.
+// fn returns the item with its new keys or nil if the item should be left as is.
+// the new item is written and the old item is deleted if its keys changed.
+// note: fn should return nil for items that have already been rewritten since they may be scanned again.
+// the total amount of items rewritten is returned
+func RewriteKeys(ctx context.Context, b *dyc.Builder, workers int, fn func(item dyc.Map) (dyc.Map, error)) (int64, error) {
+	table, err := builderTable(b)
+	if err != nil {
+		return 0, err
+	}
+
+	cli := b.GetClient()
+	keys, err := cli.TableKeys(ctx, table)
+	if err != nil {
+		return 0, err
+	}
+
+	var rewritten int64
+	err = b.ParallelScanIterate(ctx, workers, func(output *dynamodb.ScanOutput) error {
+		puts := make([]*dynamodb.WriteRequest, 0, len(output.Items))
+		var deletes []*dynamodb.WriteRequest
+		for _, item := range output.Items {
+			// the old key is extracted up front since fn may update the item in place
+			oldKey := cli.ExtractFields(item, keys...)
+			updated, err := fn(item)
+			if err != nil {
+				return err
+			}
+			if updated == nil {
+				continue
+			}
+
+			puts = append(puts, &dynamodb.WriteRequest{
+				PutRequest: &dynamodb.PutRequest{Item: updated},
+			})
+
+			if !reflect.DeepEqual(oldKey, cli.ExtractFields(updated, keys...)) {
+				deletes = append(deletes, &dynamodb.WriteRequest{
+					DeleteRequest: &dynamodb.DeleteRequest{Key: oldKey},
+				})
+			}
+		}
+
+		// the batch writer splits requests into chunks, old items are only deleted once every new item of the page
+		// was written so a failed put never loses an item
+		if _, err := cli.BatchWriter(ctx, table, puts...); err != nil {
+			return err
+		}
+		if _, err := cli.BatchWriter(ctx, table, deletes...); err != nil {
+			return err
+		}
+		atomic.AddInt64(&rewritten, int64(len(puts)))
+
+		return nil
+	}, true)
+
+	return atomic.LoadInt64(&rewritten), err
+}
+
+func builderTable(b *dyc.Builder) (string, error) {
+	if b.GetClient() == nil {
+		return "", dyc.ErrClientNotSet
+	}
+
+	input, err := b.ToScan()
+	if err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(input.TableName), nil
+}
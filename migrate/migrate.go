@@ -0,0 +1,296 @@
+// Package migrate provides versioned data migrations for dynamodb tables built on top of dyc.
+// The versions that ran are recorded in a tracking item, and a lock of the lock package
+// ensures only one process runs migrations at a time.
+package migrate
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/darwayne/dyc"
+	"github.com/darwayne/dyc/internal/coordination"
+	"github.com/darwayne/dyc/lock"
+)
+
+// DefaultID is the value used for every key attribute of the tracking item
+const DefaultID = "dyc#migrations"
+
+// Migration is a single versioned migration
+type Migration struct {
+	// Version must be unique and positive, migrations run in ascending version order
+	Version int64
+	Name    string
+	Up      func(ctx context.Context, cli *dyc.Client) error
+}
+
+// Record describes a migration that has been applied
+type Record struct {
+	Version   int64
+	Name      string
+	AppliedAt time.Time
+}
+
+// state is the tracking item stored in dynamodb
+type state struct {
+	Applied map[string]Record
+}
+
+// Migrator runs registered migrations and records which ones have been applied
+type Migrator struct {
+	cli        *dyc.Client
+	table      string
+	keyNames   []string
+	id         string
+	owner      string
+	lockTTL    time.Duration
+	locks      *lock.Client
+	now        func() time.Time
+	migrations map[int64]Migration
+}
+
+// Option allows you to configure the migrator
+type Option func(m *Migrator)
+
+// WithKeyNames sets the key attribute names of the tracking table.
+// by default they are discovered via the client
+func WithKeyNames(names ...string) Option {
+	return func(m *Migrator) {
+		m.keyNames = names
+	}
+}
+
+// WithID sets the value used for every key attribute of the tracking item
+func WithID(id string) Option {
+	return func(m *Migrator) {
+		m.id = id
+	}
+}
+
+// WithOwner sets the name used to identify the process holding the lock. defaults to the hostname and pid
+func WithOwner(owner string) Option {
+	return func(m *Migrator) {
+		m.owner = owner
+	}
+}
+
+// WithLockTTL sets the lease duration of the migration lock. the lease is renewed by a heartbeat
+// while migrations run, other processes can only take the lock over once it wasn't renewed for the ttl.
+// defaults to 30 seconds
+func WithLockTTL(ttl time.Duration) Option {
+	return func(m *Migrator) {
+		m.lockTTL = ttl
+	}
+}
+
+// New creates a migrator that stores its tracking item in the provided table.
+// the table can be the table being migrated or a separate table
+func New(cli *dyc.Client, table string, opts ...Option) *Migrator {
+	m := &Migrator{
+		cli:        cli,
+		table:      table,
+		id:         DefaultID,
+		lockTTL:    30 * time.Second,
+		now:        time.Now,
+		migrations: make(map[int64]Migration),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	lockOpts := []lock.Option{lock.WithLeaseDuration(m.lockTTL)}
+	if len(m.keyNames) > 0 {
+		lockOpts = append(lockOpts, lock.WithKeyNames(m.keyNames...))
+	}
+	if m.owner != "" {
+		lockOpts = append(lockOpts, lock.WithOwner(m.owner))
+	}
+	m.locks = lock.New(cli, table, lockOpts...)
+
+	return m
+}
+
+// Register adds migrations to the migrator
+func (m *Migrator) Register(migrations ...Migration) error {
+	for _, migration := range migrations {
+		if migration.Version <= 0 || migration.Up == nil {
+			return ErrInvalidMigration
+		}
+		if _, found := m.migrations[migration.Version]; found {
+			return ErrDuplicateVersion
+		}
+		m.migrations[migration.Version] = migration
+	}
+
+	return nil
+}
+
+// Applied returns all migrations that have been applied ordered by version
+func (m *Migrator) Applied(ctx context.Context) ([]Record, error) {
+	current, err := m.state(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]Record, 0, len(current.Applied))
+	for _, record := range current.Applied {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Version < records[j].Version
+	})
+
+	return records, nil
+}
+
+// Pending returns all registered migrations that haven't been applied ordered by version
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	current, err := m.state(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.pending(current.Applied), nil
+}
+
+// Run acquires the migration lock and applies all pending migrations in version order.
+// the context passed to migrations is canceled if the lock is lost, e.g because its lease couldn't be renewed.
+// the versions applied are returned even if a later migration fails
+func (m *Migrator) Run(ctx context.Context) ([]int64, error) {
+	keys, err := m.key(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := m.locks.TryAcquire(ctx, m.id)
+	if err == lock.ErrLockHeld {
+		return nil, ErrLocked
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = l.Release(context.Background())
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-l.Context().Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if err := m.init(ctx, keys); err != nil {
+		return nil, err
+	}
+	current, err := m.state(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []int64
+	for _, migration := range m.pending(current.Applied) {
+		if err := migration.Up(ctx, m.cli); err != nil {
+			return applied, m.lockErr(l, err)
+		}
+		if err := m.record(ctx, l, keys, migration); err != nil {
+			return applied, err
+		}
+		applied = append(applied, migration.Version)
+	}
+
+	return applied, nil
+}
+
+func (m *Migrator) pending(applied map[string]Record) []Migration {
+	var result []Migration
+	for version, migration := range m.migrations {
+		if _, found := applied[versionKey(version)]; !found {
+			result = append(result, migration)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+
+	return result
+}
+
+func (m *Migrator) state(ctx context.Context) (state, error) {
+	var current state
+	keys, err := m.key(ctx)
+	if err != nil {
+		return current, err
+	}
+
+	b, err := m.builder(keys)
+	if err != nil {
+		return current, err
+	}
+	_, err = b.
+		ConsistentRead(true).
+		Result(&current).
+		GetItem(ctx)
+
+	return current, err
+}
+
+// init creates the tracking item if it doesn't exist yet
+func (m *Migrator) init(ctx context.Context, keys []interface{}) error {
+	b, err := m.builder(keys)
+	if err != nil {
+		return err
+	}
+	_, err = b.
+		Update(`SET Applied = if_not_exists(Applied, ?)`, map[string]Record{}).
+		UpdateItem(ctx)
+
+	return err
+}
+
+// record marks the migration as applied, ErrLocked is returned if the lock was lost
+func (m *Migrator) record(ctx context.Context, l *lock.Lock, keys []interface{}, migration Migration) error {
+	if err := l.Context().Err(); err != nil {
+		return ErrLocked
+	}
+
+	b, err := m.builder(keys)
+	if err != nil {
+		return err
+	}
+	_, err = b.
+		Update(`SET 'Applied'.'`+versionKey(migration.Version)+`' = ?`, Record{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: m.now().UTC(),
+		}).
+		UpdateItem(ctx)
+
+	return m.lockErr(l, err)
+}
+
+// lockErr returns ErrLocked instead of err if err was caused by losing the lock
+func (m *Migrator) lockErr(l *lock.Lock, err error) error {
+	if err != nil && l.Context().Err() != nil {
+		return ErrLocked
+	}
+
+	return err
+}
+
+func (m *Migrator) builder(keys []interface{}) (*dyc.Builder, error) {
+	return coordination.Builder(m.cli, m.table, keys)
+}
+
+// key returns the key of the tracking item as alternating key names and values
+func (m *Migrator) key(ctx context.Context) ([]interface{}, error) {
+	return coordination.ResolveKey(ctx, m.cli, m.table, m.keyNames, m.id)
+}
+
+func versionKey(version int64) string {
+	return strconv.FormatInt(version, 10)
+}
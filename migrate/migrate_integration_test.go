@@ -0,0 +1,161 @@
+//go:build integration
+// +build integration
+
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/require"
+
+	"github.com/darwayne/dyc"
+	"github.com/darwayne/dyc/internal/testing/dynamotest"
+)
+
+type row struct {
+	PK    string
+	SK    string
+	Count int
+}
+
+func setup(t *testing.T) (*dyc.Client, string) {
+	t.Helper()
+	t.Parallel()
+	table, db := dynamotest.SetupTestTable(context.Background(), t, "migrate", dynamotest.DefaultSchema())
+
+	return dyc.NewClient(db), table
+}
+
+func testCtx(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestMigrator(t *testing.T) {
+	t.Run("Run", func(t *testing.T) {
+		t.Run("happy path", func(t *testing.T) {
+			cli, table := setup(t)
+			ctx := testCtx(t)
+
+			var ran []int64
+			m := New(cli, table)
+			require.NoError(t, m.Register(
+				Migration{Version: 2, Name: "second", Up: func(ctx context.Context, cli *dyc.Client) error {
+					ran = append(ran, 2)
+					return nil
+				}},
+				Migration{Version: 1, Name: "first", Up: func(ctx context.Context, cli *dyc.Client) error {
+					ran = append(ran, 1)
+					return nil
+				}},
+			))
+
+			applied, err := m.Run(ctx)
+			require.NoError(t, err)
+			require.Equal(t, []int64{1, 2}, applied)
+			require.Equal(t, []int64{1, 2}, ran)
+
+			records, err := m.Applied(ctx)
+			require.NoError(t, err)
+			require.Len(t, records, 2)
+			require.Equal(t, "first", records[0].Name)
+
+			applied, err = m.Run(ctx)
+			require.NoError(t, err)
+			require.Empty(t, applied)
+			require.Equal(t, []int64{1, 2}, ran)
+		})
+
+		t.Run("should respect lock", func(t *testing.T) {
+			cli, table := setup(t)
+			ctx := testCtx(t)
+
+			first := New(cli, table, WithOwner("first"))
+			second := New(cli, table, WithOwner("second"))
+			l, err := first.locks.TryAcquire(ctx, first.id)
+			require.NoError(t, err)
+
+			_, err = second.Run(ctx)
+			require.Equal(t, ErrLocked, err)
+
+			require.NoError(t, l.Release(ctx))
+			_, err = second.Run(ctx)
+			require.NoError(t, err)
+		})
+
+		t.Run("should keep the lock while a migration outlives the lock ttl", func(t *testing.T) {
+			cli, table := setup(t)
+			ctx := testCtx(t)
+
+			first := New(cli, table, WithLockTTL(time.Second))
+			second := New(cli, table, WithLockTTL(time.Second))
+			require.NoError(t, first.Register(Migration{Version: 1, Up: func(ctx context.Context, cli *dyc.Client) error {
+				time.Sleep(2 * time.Second)
+				_, err := second.Run(ctx)
+				require.Equal(t, ErrLocked, err)
+				return ctx.Err()
+			}}))
+
+			applied, err := first.Run(ctx)
+			require.NoError(t, err)
+			require.Equal(t, []int64{1}, applied)
+		})
+	})
+
+	t.Run("Backfill", func(t *testing.T) {
+		t.Run("happy path", func(t *testing.T) {
+			cli, table := setup(t)
+			ctx := testCtx(t)
+			for i := 0; i < 30; i++ {
+				_, err := cli.Builder().Table(table).PutItem(ctx, row{PK: "ROW", SK: fmt.Sprint(i)})
+				require.NoError(t, err)
+			}
+
+			written, err := Backfill(ctx, cli.Builder().Table(table), 4, func(item dyc.Map) (dyc.Map, bool, error) {
+				item["Count"] = dyc.Int(1)
+				return item, true, nil
+			})
+			require.NoError(t, err)
+			require.EqualValues(t, 30, written)
+
+			var rows []row
+			_, err = cli.Builder().Table(table).Where("Count = ?", 1).Result(&rows).ScanAll(ctx)
+			require.NoError(t, err)
+			require.Len(t, rows, 30)
+		})
+	})
+
+	t.Run("RewriteKeys", func(t *testing.T) {
+		t.Run("happy path", func(t *testing.T) {
+			cli, table := setup(t)
+			ctx := testCtx(t)
+			for i := 0; i < 10; i++ {
+				_, err := cli.Builder().Table(table).PutItem(ctx, row{PK: "OLD", SK: fmt.Sprint(i)})
+				require.NoError(t, err)
+			}
+
+			rewritten, err := RewriteKeys(ctx, cli.Builder().Table(table), 2, func(item dyc.Map) (dyc.Map, error) {
+				if aws.StringValue(item["PK"].S) != "OLD" {
+					return nil, nil
+				}
+				item["PK"] = dyc.String("NEW")
+				return item, nil
+			})
+			require.NoError(t, err)
+			require.EqualValues(t, 10, rewritten)
+
+			results, err := cli.Builder().Table(table).PartitionKey("PK", "OLD").QueryAll(ctx)
+			require.NoError(t, err)
+			require.Empty(t, results)
+
+			results, err = cli.Builder().Table(table).PartitionKey("PK", "NEW").QueryAll(ctx)
+			require.NoError(t, err)
+			require.Len(t, results, 10)
+		})
+	})
+}
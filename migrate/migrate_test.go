@@ -0,0 +1,146 @@
+//go:build unit
+// +build unit
+
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/darwayne/dyc"
+	"github.com/darwayne/dyc/internal/testing/dynamotest"
+)
+
+func noop(ctx context.Context, cli *dyc.Client) error {
+	return nil
+}
+
+func TestMigrator_Register(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		m := New(nil, "MyTable")
+		require.NoError(t, m.Register(
+			Migration{Version: 3, Up: noop},
+			Migration{Version: 1, Up: noop},
+			Migration{Version: 2, Up: noop},
+		))
+
+		pending := m.pending(map[string]Record{"2": {Version: 2}})
+		require.Len(t, pending, 2)
+		require.EqualValues(t, 1, pending[0].Version)
+		require.EqualValues(t, 3, pending[1].Version)
+	})
+
+	t.Run("with errors", func(t *testing.T) {
+		t.Run("should reject duplicate versions", func(t *testing.T) {
+			m := New(nil, "MyTable")
+			require.NoError(t, m.Register(Migration{Version: 1, Up: noop}))
+			require.Equal(t, ErrDuplicateVersion, m.Register(Migration{Version: 1, Up: noop}))
+		})
+
+		t.Run("should reject invalid migrations", func(t *testing.T) {
+			m := New(nil, "MyTable")
+			require.Equal(t, ErrInvalidMigration, m.Register(Migration{Version: 0, Up: noop}))
+			require.Equal(t, ErrInvalidMigration, m.Register(Migration{Version: 1}))
+		})
+	})
+}
+
+func TestMigrator_Run(t *testing.T) {
+	t.Run("should cancel migrations once the lock is lost", func(t *testing.T) {
+		const ttl = 200 * time.Millisecond
+		var updates int32
+		cli := dynamotest.StubClient(t, func(ctx context.Context, op *dyc.Operation) error {
+			// the lock is acquired and the tracking item is created, every renewal after that hangs
+			if op.Name != "UpdateItem" || atomic.AddInt32(&updates, 1) <= 2 {
+				return nil
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		m := New(cli, "MyTable", WithKeyNames("PK"), WithLockTTL(ttl))
+		require.NoError(t, m.Register(Migration{Version: 1, Up: func(ctx context.Context, cli *dyc.Client) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(4 * ttl):
+				return nil
+			}
+		}}))
+
+		started := time.Now()
+		applied, err := m.Run(context.Background())
+		require.Equal(t, ErrLocked, err)
+		require.Empty(t, applied)
+		require.Less(t, time.Since(started), 2*ttl)
+	})
+}
+
+func TestRewriteKeys(t *testing.T) {
+	stub := func(t *testing.T, putErr error, deleted *int32) *dyc.Client {
+		return dynamotest.StubClient(t, func(ctx context.Context, op *dyc.Operation) error {
+			switch op.Name {
+			case "DescribeTable":
+				op.Output.(*dynamodb.DescribeTableOutput).Table = &dynamodb.TableDescription{
+					TableName: aws.String("MyTable"),
+					KeySchema: []*dynamodb.KeySchemaElement{
+						{AttributeName: aws.String("PK"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+						{AttributeName: aws.String("SK"), KeyType: aws.String(dynamodb.KeyTypeRange)},
+					},
+				}
+			case "Scan":
+				items := make(dyc.Maps, 0, 30)
+				for i := 0; i < 30; i++ {
+					items = append(items, dyc.Map{"PK": dyc.String("OLD"), "SK": dyc.String(fmt.Sprint(i))})
+				}
+				op.Output.(*dynamodb.ScanOutput).Items = items
+			case "BatchWriteItem":
+				for _, requests := range op.Input.(*dynamodb.BatchWriteItemInput).RequestItems {
+					for _, req := range requests {
+						if req.DeleteRequest != nil {
+							atomic.AddInt32(deleted, 1)
+							continue
+						}
+						if putErr != nil && aws.StringValue(req.PutRequest.Item["SK"].S) == "29" {
+							return putErr
+						}
+					}
+				}
+			}
+			return nil
+		})
+	}
+	rewrite := func(item dyc.Map) (dyc.Map, error) {
+		item["PK"] = dyc.String("NEW")
+		return item, nil
+	}
+
+	t.Run("happy path", func(t *testing.T) {
+		var deleted int32
+		cli := stub(t, nil, &deleted)
+
+		rewritten, err := RewriteKeys(context.Background(), cli.Builder().Table("MyTable"), 1, rewrite)
+		require.NoError(t, err)
+		require.EqualValues(t, 30, rewritten)
+		require.EqualValues(t, 30, atomic.LoadInt32(&deleted))
+	})
+
+	t.Run("should keep the old item when writing the new item fails", func(t *testing.T) {
+		putErr := errors.New("put failed")
+		var deleted int32
+		cli := stub(t, putErr, &deleted)
+
+		rewritten, err := RewriteKeys(context.Background(), cli.Builder().Table("MyTable"), 1, rewrite)
+		require.ErrorIs(t, err, putErr)
+		require.Zero(t, rewritten)
+		require.Zero(t, atomic.LoadInt32(&deleted))
+	})
+}
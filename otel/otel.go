@@ -0,0 +1,276 @@
+// Package otel traces dyc clients with OpenTelemetry.
+//
+// Every builder terminal method (e.g QueryAll or PutItem) produces a span, with a child span for every
+// request it makes to dynamodb, so a QueryAll fetching 40 pages shows up as a single span with 40 children.
+// spans record the table, index, operation, item counts, consumed capacity, retries and the expressions
+// with their values redacted.
+package otel
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/darwayne/dyc"
+)
+
+// InstrumentationName is the name of the tracer used to create spans
+const InstrumentationName = "github.com/darwayne/dyc/otel"
+
+// attribute keys recorded on spans
+const (
+	SystemKey           = attribute.Key("db.system")
+	OperationKey        = attribute.Key("db.operation")
+	TableNamesKey       = attribute.Key("aws.dynamodb.table_names")
+	IndexNameKey        = attribute.Key("aws.dynamodb.index_name")
+	MethodKey           = attribute.Key("dyc.method")
+	ItemsKey            = attribute.Key("dyc.items")
+	RequestsKey         = attribute.Key("dyc.requests")
+	RetriesKey          = attribute.Key("dyc.retries")
+	ReadCapacityKey     = attribute.Key("dyc.consumed_capacity.read")
+	WriteCapacityKey    = attribute.Key("dyc.consumed_capacity.write")
+	KeyConditionKey     = attribute.Key("dyc.expression.key_condition")
+	FilterKey           = attribute.Key("dyc.expression.filter")
+	ProjectionKey       = attribute.Key("dyc.expression.projection")
+	ConditionKey        = attribute.Key("dyc.expression.condition")
+	UpdateExpressionKey = attribute.Key("dyc.expression.update")
+)
+
+type config struct {
+	provider     trace.TracerProvider
+	capacityMode string
+}
+
+// Option allows you to configure tracing
+type Option func(c *config)
+
+// WithTracerProvider sets the provider used to create the tracer. defaults to the global provider
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.provider = provider
+	}
+}
+
+// WithCapacityMode sets the consumed capacity mode requested for traced calls.
+// defaults to dynamodb.ReturnConsumedCapacityTotal, use dynamodb.ReturnConsumedCapacityNone to disable
+func WithCapacityMode(mode string) Option {
+	return func(c *config) {
+		c.capacityMode = mode
+	}
+}
+
+// Instrument adds tracing to every builder terminal method and dynamodb request made by the client
+func Instrument(cli *dyc.Client, opts ...Option) {
+	cfg := config{capacityMode: dynamodb.ReturnConsumedCapacityTotal}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.provider == nil {
+		cfg.provider = otel.GetTracerProvider()
+	}
+
+	t := &tracer{
+		tracer:       cfg.provider.Tracer(InstrumentationName),
+		capacityMode: cfg.capacityMode,
+	}
+	cli.OnCall(t.call)
+	cli.Use(t.middleware)
+}
+
+type tracer struct {
+	tracer       trace.Tracer
+	capacityMode string
+}
+
+type callStateKey struct{}
+
+// callState accumulates the requests made by a builder terminal method, requests may run concurrently
+type callState struct {
+	span trace.Span
+
+	mu          sync.Mutex
+	requests    int
+	items       int
+	retries     int
+	expressions bool
+}
+
+func (t *tracer) call(ctx context.Context, call dyc.Call) (context.Context, func(err error)) {
+	attrs := []attribute.KeyValue{
+		SystemKey.String("dynamodb"),
+		MethodKey.String(call.Method),
+	}
+	if call.Table != "" {
+		attrs = append(attrs, TableNamesKey.StringSlice([]string{call.Table}))
+	}
+	if call.Index != "" {
+		attrs = append(attrs, IndexNameKey.String(call.Index))
+	}
+
+	ctx, span := t.tracer.Start(ctx, "dyc."+call.Method, trace.WithSpanKind(trace.SpanKindInternal), trace.WithAttributes(attrs...))
+	var report *dyc.CapacityReport
+	if t.capacityMode != "" && t.capacityMode != dynamodb.ReturnConsumedCapacityNone {
+		report = dyc.NewCapacityReport(t.capacityMode)
+		ctx = dyc.WithCapacityReport(ctx, report)
+	}
+	state := &callState{span: span}
+	ctx = context.WithValue(ctx, callStateKey{}, state)
+
+	return ctx, func(err error) {
+		state.mu.Lock()
+		span.SetAttributes(
+			RequestsKey.Int(state.requests),
+			ItemsKey.Int(state.items),
+			RetriesKey.Int(state.retries),
+		)
+		state.mu.Unlock()
+		if report != nil {
+			total := report.Total()
+			span.SetAttributes(
+				ReadCapacityKey.Float64(total.ReadCapacityUnits),
+				WriteCapacityKey.Float64(total.WriteCapacityUnits),
+			)
+		}
+		endSpan(span, err)
+	}
+}
+
+func (t *tracer) middleware(next dyc.Handler) dyc.Handler {
+	return func(ctx context.Context, op *dyc.Operation) error {
+		attrs := []attribute.KeyValue{
+			SystemKey.String("dynamodb"),
+			OperationKey.String(op.Name),
+		}
+		if tables := tableNames(op); len(tables) > 0 {
+			attrs = append(attrs, TableNamesKey.StringSlice(tables))
+		}
+		if op.Index != "" {
+			attrs = append(attrs, IndexNameKey.String(op.Index))
+		}
+		expressions := renderExpressions(op.Input)
+		attrs = append(attrs, expressions...)
+
+		ctx, span := t.tracer.Start(ctx, "DynamoDB."+op.Name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+		err := next(ctx, op)
+
+		var items int
+		if err == nil {
+			items = itemCount(op)
+			consumed := op.ConsumedCapacity()
+			span.SetAttributes(
+				ItemsKey.Int(items),
+				ReadCapacityKey.Float64(consumed.ReadCapacityUnits),
+				WriteCapacityKey.Float64(consumed.WriteCapacityUnits),
+			)
+		}
+		span.SetAttributes(RetriesKey.Int(op.Retries))
+		endSpan(span, err)
+
+		if state, ok := ctx.Value(callStateKey{}).(*callState); ok {
+			state.mu.Lock()
+			state.requests++
+			state.items += items
+			state.retries += op.Retries
+			if !state.expressions && len(expressions) > 0 {
+				state.expressions = true
+				state.span.SetAttributes(expressions...)
+			}
+			state.mu.Unlock()
+		}
+
+		return err
+	}
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// tableNames returns the tables targeted by the operation
+func tableNames(op *dyc.Operation) []string {
+	if op.Table != "" {
+		return []string{op.Table}
+	}
+
+	var tables []string
+	switch input := op.Input.(type) {
+	case *dynamodb.BatchGetItemInput:
+		for table := range input.RequestItems {
+			tables = append(tables, table)
+		}
+	case *dynamodb.BatchWriteItemInput:
+		for table := range input.RequestItems {
+			tables = append(tables, table)
+		}
+	}
+
+	return tables
+}
+
+// itemCount returns the amount of items read or written by a successful operation
+func itemCount(op *dyc.Operation) int {
+	switch output := op.Output.(type) {
+	case *dynamodb.GetItemOutput:
+		if len(output.Item) > 0 {
+			return 1
+		}
+	case *dynamodb.QueryOutput:
+		return int(aws.Int64Value(output.Count))
+	case *dynamodb.ScanOutput:
+		return int(aws.Int64Value(output.Count))
+	case *dynamodb.BatchGetItemOutput:
+		var count int
+		for _, items := range output.Responses {
+			count += len(items)
+		}
+		return count
+	case *dynamodb.BatchWriteItemOutput:
+		input, _ := op.Input.(*dynamodb.BatchWriteItemInput)
+		if input == nil {
+			return 0
+		}
+		var count int
+		for _, requests := range input.RequestItems {
+			count += len(requests)
+		}
+		for _, requests := range output.UnprocessedItems {
+			count -= len(requests)
+		}
+		return count
+	case *dynamodb.PutItemOutput, *dynamodb.UpdateItemOutput, *dynamodb.DeleteItemOutput:
+		return 1
+	}
+
+	return 0
+}
+
+// expressionKeys maps the names of rendered expressions to their span attribute
+var expressionKeys = map[string]attribute.Key{
+	"key_condition": KeyConditionKey,
+	"filter":        FilterKey,
+	"projection":    ProjectionKey,
+	"condition":     ConditionKey,
+	"update":        UpdateExpressionKey,
+}
+
+// renderExpressions returns the expressions of the input with attribute names substituted and values redacted
+func renderExpressions(input interface{}) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	for _, rendered := range dyc.RenderExpressions(input, nil) {
+		if key, found := expressionKeys[rendered.Name]; found {
+			attrs = append(attrs, key.String(rendered.Expression))
+		}
+	}
+
+	return attrs
+}
@@ -0,0 +1,49 @@
+//go:build integration
+// +build integration
+
+package otel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/darwayne/dyc"
+	"github.com/darwayne/dyc/internal/testing/dynamotest"
+)
+
+func TestInstrument_Integration(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	table, db := dynamotest.SetupTestTable(ctx, t, "otel", dynamotest.DefaultSchema())
+	cli := dyc.NewClient(db)
+	recorder := tracetest.NewSpanRecorder()
+	Instrument(cli, WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))))
+
+	for i := 0; i < 3; i++ {
+		_, err := cli.Builder().Table(table).PutItem(ctx, dyc.Map{"PK": dyc.String("a"), "SK": dyc.Int(i)})
+		require.NoError(t, err)
+	}
+
+	results, err := cli.Builder().Table(table).WhereKey("PK = ?", "a").QueryAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	spans := recorder.Ended()
+	call := spans[len(spans)-1]
+	require.Equal(t, "dyc.QueryAll", call.Name())
+	for _, attr := range call.Attributes() {
+		switch attr.Key {
+		case ItemsKey:
+			require.EqualValues(t, 3, attr.Value.AsInt64())
+		case ReadCapacityKey:
+			require.Greater(t, attr.Value.AsFloat64(), 0.0)
+		}
+	}
+}
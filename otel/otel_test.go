@@ -0,0 +1,156 @@
+//go:build unit
+// +build unit
+
+package otel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/darwayne/dyc"
+)
+
+// setup returns an instrumented client whose requests are answered by respond instead of dynamodb
+func setup(t *testing.T, respond func(op *dyc.Operation) error) (*dyc.Client, *tracetest.SpanRecorder) {
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String("http://127.0.0.1:1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	})
+	require.NoError(t, err)
+
+	recorder := tracetest.NewSpanRecorder()
+	cli := dyc.NewClient(dynamodb.New(sess), dyc.WithoutSchemaDiscovery())
+	Instrument(cli, WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))))
+	cli.Use(func(next dyc.Handler) dyc.Handler {
+		return func(ctx context.Context, op *dyc.Operation) error {
+			return respond(op)
+		}
+	})
+
+	return cli, recorder
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	result := make(map[attribute.Key]attribute.Value)
+	for _, attr := range span.Attributes() {
+		result[attr.Key] = attr.Value
+	}
+
+	return result
+}
+
+func TestInstrument(t *testing.T) {
+	t.Run("should create a span per page under the builder call", func(t *testing.T) {
+		var pages int
+		cli, recorder := setup(t, func(op *dyc.Operation) error {
+			input := op.Input.(*dynamodb.QueryInput)
+			require.Equal(t, dynamodb.ReturnConsumedCapacityTotal, aws.StringValue(input.ReturnConsumedCapacity))
+
+			pages++
+			output := op.Output.(*dynamodb.QueryOutput)
+			output.Items = dyc.Maps{{"PK": dyc.String("a")}, {"PK": dyc.String("b")}}
+			output.Count = aws.Int64(2)
+			output.ConsumedCapacity = &dynamodb.ConsumedCapacity{TableName: aws.String("MyTable"), CapacityUnits: aws.Float64(0.5)}
+			if pages < 3 {
+				output.LastEvaluatedKey = dyc.Map{"PK": dyc.String("b")}
+			}
+			op.Retries = 1
+			return nil
+		})
+
+		results, err := cli.Builder().
+			Table("MyTable").
+			Index("GSI1").
+			WhereKey("'GSI1PK' = ?", "secret").
+			Where("size = ?", 10).
+			QueryAll(context.Background())
+		require.NoError(t, err)
+		require.Len(t, results, 6)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 4)
+		call := spans[3]
+		require.Equal(t, "dyc.QueryAll", call.Name())
+		for _, page := range spans[:3] {
+			require.Equal(t, "DynamoDB.Query", page.Name())
+			require.Equal(t, call.SpanContext().SpanID(), page.Parent().SpanID())
+
+			attrs := attributes(page)
+			require.Equal(t, []string{"MyTable"}, attrs[TableNamesKey].AsStringSlice())
+			require.Equal(t, "GSI1", attrs[IndexNameKey].AsString())
+			require.Equal(t, "(GSI1PK = ?)", attrs[KeyConditionKey].AsString())
+			require.Equal(t, "(size = ?)", attrs[FilterKey].AsString())
+			require.EqualValues(t, 2, attrs[ItemsKey].AsInt64())
+			require.EqualValues(t, 1, attrs[RetriesKey].AsInt64())
+			require.Equal(t, 0.5, attrs[ReadCapacityKey].AsFloat64())
+		}
+
+		attrs := attributes(call)
+		require.Equal(t, "QueryAll", attrs[MethodKey].AsString())
+		require.Equal(t, "(GSI1PK = ?)", attrs[KeyConditionKey].AsString())
+		require.EqualValues(t, 3, attrs[RequestsKey].AsInt64())
+		require.EqualValues(t, 6, attrs[ItemsKey].AsInt64())
+		require.EqualValues(t, 3, attrs[RetriesKey].AsInt64())
+		require.Equal(t, 1.5, attrs[ReadCapacityKey].AsFloat64())
+	})
+
+	t.Run("should record errors", func(t *testing.T) {
+		fault := errors.New("throttled")
+		cli, recorder := setup(t, func(op *dyc.Operation) error {
+			return fault
+		})
+
+		_, err := cli.Builder().
+			Table("MyTable").
+			Key("PK", "a").
+			Update("SET 'Count' = 'Count' + ?", 1).
+			UpdateItem(context.Background())
+		require.ErrorIs(t, err, fault)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 2)
+		for _, span := range spans {
+			require.Equal(t, codes.Error, span.Status().Code)
+		}
+		require.Equal(t, "SET Count = Count + ?", attributes(spans[0])[UpdateExpressionKey].AsString())
+	})
+
+	t.Run("should trace requests made outside of builder calls", func(t *testing.T) {
+		cli, recorder := setup(t, func(op *dyc.Operation) error {
+			return nil
+		})
+
+		_, err := cli.BatchPut(context.Background(), "MyTable", dyc.Map{"PK": dyc.String("a"), "SK": dyc.String("b")})
+		require.NoError(t, err)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		require.Equal(t, "DynamoDB.BatchWriteItem", spans[0].Name())
+		require.False(t, spans[0].Parent().IsValid())
+		require.EqualValues(t, 1, attributes(spans[0])[ItemsKey].AsInt64())
+	})
+}
+
+func TestRenderExpressions(t *testing.T) {
+	attrs := renderExpressions(&dynamodb.QueryInput{
+		KeyConditionExpression:   aws.String("#1 = :0"),
+		FilterExpression:         aws.String("#2 IN (:1, :10) AND #unknown = :name"),
+		ExpressionAttributeNames: map[string]*string{"#1": aws.String("status"), "#2": aws.String("data")},
+	})
+	require.Equal(t, []attribute.KeyValue{
+		KeyConditionKey.String("status = ?"),
+		FilterKey.String("data IN (?, ?) AND #unknown = ?"),
+	}, attrs)
+}
@@ -0,0 +1,156 @@
+// Package prometheus exports the request metrics of dyc clients to prometheus.
+//
+// Register a collector and pass it to the client:
+//
+//	collector := prometheus.New()
+//	registry.MustRegister(collector)
+//	cli := dyc.NewClient(db, dyc.WithMetrics(collector))
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/darwayne/dyc"
+)
+
+// DefaultNamespace is the namespace of all metrics unless set via WithNamespace
+const DefaultNamespace = "dyc"
+
+var (
+	requestLabels     = []string{"table", "index", "operation"}
+	capacityLabels    = []string{"table", "index", "operation", "type"}
+	unprocessedLabels = []string{"table", "operation"}
+)
+
+type config struct {
+	namespace   string
+	buckets     []float64
+	constLabels prometheus.Labels
+}
+
+// Option allows you to configure the collector
+type Option func(c *config)
+
+// WithNamespace sets the namespace of all metrics
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithDurationBuckets sets the buckets of the request duration histogram in seconds. defaults to prometheus.DefBuckets
+func WithDurationBuckets(buckets ...float64) Option {
+	return func(c *config) {
+		c.buckets = buckets
+	}
+}
+
+// WithConstLabels sets labels added to all metrics e.g the name of the service
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(c *config) {
+		c.constLabels = labels
+	}
+}
+
+// Collector implements dyc.Metrics and prometheus.Collector
+type Collector struct {
+	requests    *prometheus.CounterVec
+	errors      *prometheus.CounterVec
+	throttles   *prometheus.CounterVec
+	retries     *prometheus.CounterVec
+	duration    *prometheus.HistogramVec
+	scanned     *prometheus.CounterVec
+	returned    *prometheus.CounterVec
+	capacity    *prometheus.CounterVec
+	unprocessed *prometheus.CounterVec
+}
+
+var _ dyc.Metrics = (*Collector)(nil)
+var _ prometheus.Collector = (*Collector)(nil)
+
+// New creates a collector, it must be registered with a prometheus registry to be exported
+func New(opts ...Option) *Collector {
+	cfg := config{namespace: DefaultNamespace, buckets: prometheus.DefBuckets}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	counter := func(name, help string, labels []string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        name,
+			Help:        help,
+			ConstLabels: cfg.constLabels,
+		}, labels)
+	}
+
+	return &Collector{
+		requests:  counter("requests_total", "Requests made to dynamodb.", requestLabels),
+		errors:    counter("request_errors_total", "Requests to dynamodb that failed.", requestLabels),
+		throttles: counter("throttled_requests_total", "Attempts dynamodb throttled, including attempts that were retried successfully.", requestLabels),
+		retries:   counter("request_retries_total", "Retries made by the sdk.", requestLabels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   cfg.namespace,
+			Name:        "request_duration_seconds",
+			Help:        "Duration of requests made to dynamodb including retries.",
+			ConstLabels: cfg.constLabels,
+			Buckets:     cfg.buckets,
+		}, requestLabels),
+		scanned:     counter("items_scanned_total", "Items evaluated by queries and scans before filters were applied.", requestLabels),
+		returned:    counter("items_returned_total", "Items returned by queries and scans.", requestLabels),
+		capacity:    counter("consumed_capacity_units_total", "Capacity units consumed by requests.", capacityLabels),
+		unprocessed: counter("unprocessed_items_total", "Items left unprocessed by batch requests, which are retried.", unprocessedLabels),
+	}
+}
+
+// ObserveRequest records the measurements of a single request
+func (c *Collector) ObserveRequest(m dyc.RequestMetrics) {
+	labels := prometheus.Labels{"table": m.Table, "index": m.Index, "operation": m.Operation}
+	c.requests.With(labels).Inc()
+	c.duration.With(labels).Observe(m.Duration.Seconds())
+	if m.Retries > 0 {
+		c.retries.With(labels).Add(float64(m.Retries))
+	}
+	if m.Err != nil {
+		c.errors.With(labels).Inc()
+	}
+	if m.Throttles > 0 {
+		c.throttles.With(labels).Add(float64(m.Throttles))
+	}
+	if m.Operation == "Query" || m.Operation == "Scan" {
+		c.scanned.With(labels).Add(float64(m.Scanned))
+		c.returned.With(labels).Add(float64(m.Returned))
+	}
+	if m.Capacity.ReadCapacityUnits > 0 {
+		c.capacity.WithLabelValues(m.Table, m.Index, m.Operation, "read").Add(m.Capacity.ReadCapacityUnits)
+	}
+	if m.Capacity.WriteCapacityUnits > 0 {
+		c.capacity.WithLabelValues(m.Table, m.Index, m.Operation, "write").Add(m.Capacity.WriteCapacityUnits)
+	}
+}
+
+// ObserveUnprocessed records items left unprocessed by a batch request
+func (c *Collector) ObserveUnprocessed(table, operation string, items int) {
+	c.unprocessed.WithLabelValues(table, operation).Add(float64(items))
+}
+
+func (c *Collector) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.requests, c.errors, c.throttles, c.retries, c.duration,
+		c.scanned, c.returned, c.capacity, c.unprocessed,
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range c.collectors() {
+		collector.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range c.collectors() {
+		collector.Collect(ch)
+	}
+}
@@ -0,0 +1,115 @@
+//go:build unit
+// +build unit
+
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/darwayne/dyc"
+)
+
+// setup returns a client reporting to a new collector whose requests are answered by respond instead of dynamodb
+func setup(t *testing.T, respond func(op *dyc.Operation) error) (*dyc.Client, *Collector) {
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String("http://127.0.0.1:1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	})
+	require.NoError(t, err)
+
+	collector := New()
+	require.NoError(t, prometheus.NewRegistry().Register(collector))
+	cli := dyc.NewClient(dynamodb.New(sess), dyc.WithoutSchemaDiscovery(), dyc.WithMetrics(collector))
+	cli.Use(func(next dyc.Handler) dyc.Handler {
+		return func(ctx context.Context, op *dyc.Operation) error {
+			op.Duration = 10 * time.Millisecond
+			return respond(op)
+		}
+	})
+
+	return cli, collector
+}
+
+func TestCollector(t *testing.T) {
+	t.Run("should record requests, items and capacity", func(t *testing.T) {
+		cli, collector := setup(t, func(op *dyc.Operation) error {
+			input := op.Input.(*dynamodb.QueryInput)
+			require.Equal(t, dynamodb.ReturnConsumedCapacityTotal, aws.StringValue(input.ReturnConsumedCapacity))
+
+			output := op.Output.(*dynamodb.QueryOutput)
+			output.Items = dyc.Maps{{"PK": dyc.String("a")}}
+			output.Count = aws.Int64(1)
+			output.ScannedCount = aws.Int64(10)
+			output.ConsumedCapacity = &dynamodb.ConsumedCapacity{CapacityUnits: aws.Float64(1.5)}
+			op.Retries = 2
+			return nil
+		})
+
+		_, err := cli.Builder().Table("MyTable").Index("GSI1").WhereKey("GSI1PK = ?", "a").Where("Age > ?", 3).QueryAll(context.Background())
+		require.NoError(t, err)
+
+		labels := []string{"MyTable", "GSI1", "Query"}
+		require.Equal(t, 1.0, testutil.ToFloat64(collector.requests.WithLabelValues(labels...)))
+		require.Equal(t, 2.0, testutil.ToFloat64(collector.retries.WithLabelValues(labels...)))
+		require.Equal(t, 10.0, testutil.ToFloat64(collector.scanned.WithLabelValues(labels...)))
+		require.Equal(t, 1.0, testutil.ToFloat64(collector.returned.WithLabelValues(labels...)))
+		require.Equal(t, 1.5, testutil.ToFloat64(collector.capacity.WithLabelValues("MyTable", "GSI1", "Query", "read")))
+		require.Equal(t, 0.0, testutil.ToFloat64(collector.errors.WithLabelValues(labels...)))
+	})
+
+	t.Run("should record errors and throttles", func(t *testing.T) {
+		throttled := awserr.New(dynamodb.ErrCodeProvisionedThroughputExceededException, "slow down", nil)
+		calls := 0
+		cli, collector := setup(t, func(op *dyc.Operation) error {
+			calls++
+			if calls == 1 {
+				return throttled
+			}
+			return errors.New("boom")
+		})
+
+		_, err := cli.Builder().Table("MyTable").Key("PK", "a").GetItem(context.Background())
+		require.ErrorIs(t, err, throttled)
+		_, err = cli.Builder().Table("MyTable").Key("PK", "a").GetItem(context.Background())
+		require.Error(t, err)
+
+		labels := []string{"MyTable", "", "GetItem"}
+		require.Equal(t, 2.0, testutil.ToFloat64(collector.requests.WithLabelValues(labels...)))
+		require.Equal(t, 2.0, testutil.ToFloat64(collector.errors.WithLabelValues(labels...)))
+		require.Equal(t, 1.0, testutil.ToFloat64(collector.throttles.WithLabelValues(labels...)))
+	})
+
+	t.Run("should record unprocessed items", func(t *testing.T) {
+		calls := 0
+		cli, collector := setup(t, func(op *dyc.Operation) error {
+			calls++
+			input := op.Input.(*dynamodb.BatchWriteItemInput)
+			if calls == 1 {
+				op.Output.(*dynamodb.BatchWriteItemOutput).UnprocessedItems = map[string][]*dynamodb.WriteRequest{
+					"MyTable": input.RequestItems["MyTable"][1:],
+				}
+			}
+			return nil
+		})
+
+		written, err := cli.BatchPut(context.Background(), "MyTable",
+			dyc.Map{"PK": dyc.String("a")}, dyc.Map{"PK": dyc.String("b")}, dyc.Map{"PK": dyc.String("c")})
+		require.NoError(t, err)
+		require.Equal(t, 3, written)
+		require.Equal(t, 2.0, testutil.ToFloat64(collector.unprocessed.WithLabelValues("MyTable", "BatchWriteItem")))
+		require.Equal(t, 2.0, testutil.ToFloat64(collector.requests.WithLabelValues("MyTable", "", "BatchWriteItem")))
+	})
+}
@@ -0,0 +1,12 @@
+package queue
+
+import "errors"
+
+var (
+	// ErrReceiptExpired occurs if a message is acked or nacked after its visibility timeout passed and it was claimed again
+	ErrReceiptExpired = errors.New("message receipt expired")
+	// ErrInvalidMax occurs if Claim or DeadLetters are called with a max of zero or less
+	ErrInvalidMax = errors.New("max must be greater than zero")
+	// ErrNotDeadLettered occurs if a message that isn't dead lettered is redriven
+	ErrNotDeadLettered = errors.New("message is not dead lettered")
+)
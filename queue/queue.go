@@ -0,0 +1,388 @@
+// Package queue provides a durable work queue stored in a dynamodb table.
+//
+// Messages are stored as items keyed by queue name and message id. A global secondary index
+// on QueueStatus and VisibleAt is used to find messages that are ready to be claimed.
+// Claiming a message hides it from other consumers for the visibility timeout, messages that aren't
+// acknowledged before the timeout become visible again and are moved to the dead letter status
+// once they've been claimed the maximum amount of times.
+package queue
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+
+	"github.com/darwayne/dyc"
+	"github.com/darwayne/dyc/internal/coordination"
+)
+
+const (
+	// DefaultIndex is the name of the status index unless WithIndex is provided
+	DefaultIndex = "QueueStatusIndex"
+	// StatusAttribute is the partition key of the status index
+	StatusAttribute = "QueueStatus"
+	// VisibleAtAttribute is the sort key of the status index
+	VisibleAtAttribute = "VisibleAt"
+)
+
+const (
+	statusReady = "ready"
+	statusDead  = "dead"
+)
+
+// IndexSpec returns the spec of the status index required by the queue.
+// it can be added to the GlobalIndexes of a dyc.TableSpec passed to EnsureTable
+func IndexSpec(name string) dyc.IndexSpec {
+	return dyc.IndexSpec{
+		Name:         name,
+		PartitionKey: dyc.KeyAttribute{Name: StatusAttribute, Type: dynamodb.ScalarAttributeTypeS},
+		SortKey:      &dyc.KeyAttribute{Name: VisibleAtAttribute, Type: dynamodb.ScalarAttributeTypeN},
+	}
+}
+
+// Message is a message stored in the queue
+type Message struct {
+	ID         string
+	Body       []byte
+	Attempts   int
+	EnqueuedAt time.Time
+	// receipt identifies the claim of the message, acks and nacks with a stale receipt are rejected
+	receipt string
+}
+
+// record is the item stored in dynamodb
+type record struct {
+	ID          string
+	Body        []byte
+	Attempts    int
+	EnqueuedAt  int64
+	QueueStatus string
+	VisibleAt   int64
+	Receipt     string `dynamodbav:",omitempty"`
+}
+
+func (r record) message() *Message {
+	return &Message{
+		ID:         r.ID,
+		Body:       r.Body,
+		Attempts:   r.Attempts,
+		EnqueuedAt: time.Unix(0, r.EnqueuedAt),
+		receipt:    r.Receipt,
+	}
+}
+
+// Queue is a named queue stored in a table
+type Queue struct {
+	cli               *dyc.Client
+	table             string
+	name              string
+	index             string
+	keyNames          []string
+	visibilityTimeout time.Duration
+	maxAttempts       int
+	now               func() time.Time
+}
+
+// Option allows you to configure the queue
+type Option func(q *Queue)
+
+// WithKeyNames sets the key attribute names of the table. by default they are discovered via the client
+func WithKeyNames(names ...string) Option {
+	return func(q *Queue) {
+		q.keyNames = names
+	}
+}
+
+// WithIndex sets the name of the status index
+func WithIndex(index string) Option {
+	return func(q *Queue) {
+		q.index = index
+	}
+}
+
+// WithVisibilityTimeout sets how long a claimed message is hidden from other consumers. defaults to 30 seconds
+func WithVisibilityTimeout(timeout time.Duration) Option {
+	return func(q *Queue) {
+		q.visibilityTimeout = timeout
+	}
+}
+
+// WithMaxAttempts sets how many times a message can be claimed before it is dead lettered. defaults to 5
+func WithMaxAttempts(attempts int) Option {
+	return func(q *Queue) {
+		q.maxAttempts = attempts
+	}
+}
+
+// New creates a queue with the provided name stored in the provided table.
+// multiple queues can share a table
+func New(cli *dyc.Client, table, name string, opts ...Option) *Queue {
+	q := &Queue{
+		cli:               cli,
+		table:             table,
+		name:              name,
+		index:             DefaultIndex,
+		visibilityTimeout: 30 * time.Second,
+		maxAttempts:       5,
+		now:               time.Now,
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	return q
+}
+
+// EnqueueOption allows you to configure an enqueued message
+type EnqueueOption func(r *record)
+
+// WithDelay hides the message from consumers until the delay has passed
+func WithDelay(delay time.Duration) EnqueueOption {
+	return func(r *record) {
+		r.VisibleAt += int64(delay)
+	}
+}
+
+// Enqueue adds a message to the queue and returns its id
+func (q *Queue) Enqueue(ctx context.Context, body []byte, opts ...EnqueueOption) (string, error) {
+	id, err := coordination.NewToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := q.now().UnixNano()
+	r := record{
+		ID:          id,
+		Body:        body,
+		EnqueuedAt:  now,
+		QueueStatus: q.status(statusReady),
+		VisibleAt:   now,
+	}
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	item, err := dynamodbattribute.MarshalMap(r)
+	if err != nil {
+		return "", err
+	}
+	keys, err := q.key(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	for name, val := range coordination.KeyMap(keys) {
+		item[name] = val
+	}
+
+	_, err = q.cli.Builder().Table(q.table).PutItem(ctx, item)
+
+	return id, err
+}
+
+// Claim returns up to max messages that are ready to be processed, hiding them for the visibility timeout.
+// messages that have reached the maximum amount of attempts are dead lettered instead of being returned
+func (q *Queue) Claim(ctx context.Context, max int) ([]*Message, error) {
+	if max <= 0 {
+		return nil, ErrInvalidMax
+	}
+	now := q.now().UnixNano()
+	items, err := q.query(max).
+		WhereKey(`QueueStatus = ? AND VisibleAt <= ?`, q.status(statusReady), now).
+		QueryAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []record
+	if err := dynamodbattribute.UnmarshalListOfMaps(items, &candidates); err != nil {
+		return nil, err
+	}
+
+	messages := make([]*Message, 0, len(candidates))
+	for _, candidate := range candidates {
+		if candidate.Attempts >= q.maxAttempts {
+			if err := q.deadLetter(ctx, candidate, now); err != nil {
+				return messages, err
+			}
+			continue
+		}
+
+		msg, err := q.claim(ctx, candidate, now)
+		if err != nil {
+			return messages, err
+		}
+		if msg != nil {
+			messages = append(messages, msg)
+		}
+	}
+
+	return messages, nil
+}
+
+// claim hides a single message, nil is returned if another consumer claimed it first
+func (q *Queue) claim(ctx context.Context, candidate record, now int64) (*Message, error) {
+	receipt, err := coordination.NewToken()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := q.builder(ctx, candidate.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated record
+	_, err = b.
+		Update(`SET VisibleAt = :visible, Receipt = :receipt ADD Attempts :one`, dyc.Params{
+			"visible": now + int64(q.visibilityTimeout),
+			"receipt": receipt,
+			"one":     1,
+		}).
+		Condition(`QueueStatus = ? AND VisibleAt <= ?`, q.status(statusReady), now).
+		Return(dynamodb.ReturnValueAllNew).
+		Result(&updated).
+		UpdateItem(ctx)
+	if dyc.IsConditionalCheckFailure(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return updated.message(), nil
+}
+
+func (q *Queue) deadLetter(ctx context.Context, candidate record, now int64) error {
+	b, err := q.builder(ctx, candidate.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.
+		Update(`SET QueueStatus = ? REMOVE Receipt`, q.status(statusDead)).
+		Condition(`QueueStatus = ? AND VisibleAt <= ?`, q.status(statusReady), now).
+		UpdateItem(ctx)
+	if dyc.IsConditionalCheckFailure(err) {
+		return nil
+	}
+
+	return err
+}
+
+// Ack removes a processed message from the queue.
+// ErrReceiptExpired is returned if the visibility timeout passed and the message was claimed again
+func (q *Queue) Ack(ctx context.Context, msg *Message) error {
+	b, err := q.builder(ctx, msg.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.
+		Condition(`Receipt = ?`, msg.receipt).
+		DeleteItem(ctx)
+	if dyc.IsConditionalCheckFailure(err) {
+		return ErrReceiptExpired
+	}
+
+	return err
+}
+
+// Nack releases a claimed message so it becomes visible again after the provided delay.
+// ErrReceiptExpired is returned if the visibility timeout passed and the message was claimed again
+func (q *Queue) Nack(ctx context.Context, msg *Message, delay time.Duration) error {
+	b, err := q.builder(ctx, msg.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.
+		Update(`SET VisibleAt = ? REMOVE Receipt`, q.now().Add(delay).UnixNano()).
+		Condition(`Receipt = ?`, msg.receipt).
+		UpdateItem(ctx)
+	if dyc.IsConditionalCheckFailure(err) {
+		return ErrReceiptExpired
+	}
+
+	return err
+}
+
+// DeadLetters returns up to max messages that were dead lettered, oldest first
+func (q *Queue) DeadLetters(ctx context.Context, max int) ([]*Message, error) {
+	if max <= 0 {
+		return nil, ErrInvalidMax
+	}
+	items, err := q.query(max).
+		WhereKey(`QueueStatus = ?`, q.status(statusDead)).
+		QueryAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []record
+	if err := dynamodbattribute.UnmarshalListOfMaps(items, &records); err != nil {
+		return nil, err
+	}
+
+	messages := make([]*Message, 0, len(records))
+	for _, r := range records {
+		messages = append(messages, r.message())
+	}
+
+	return messages, nil
+}
+
+// query returns a builder querying up to max messages from the status index
+func (q *Queue) query(max int) *dyc.Builder {
+	b := q.cli.Builder().
+		Table(q.table).
+		Index(q.index).
+		WithIndexKeys(StatusAttribute, VisibleAtAttribute).
+		Limit(max)
+	if len(q.keyNames) > 0 {
+		b.WithPrimaryKeys(q.keyNames...)
+	}
+
+	return b
+}
+
+// Redrive moves a dead lettered message back to the queue with its attempts reset
+func (q *Queue) Redrive(ctx context.Context, msg *Message) error {
+	b, err := q.builder(ctx, msg.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.
+		Update(`SET QueueStatus = :ready, VisibleAt = :now, Attempts = :zero`, dyc.Params{
+			"ready": q.status(statusReady),
+			"now":   q.now().UnixNano(),
+			"zero":  0,
+		}).
+		Condition(`QueueStatus = ?`, q.status(statusDead)).
+		UpdateItem(ctx)
+	if dyc.IsConditionalCheckFailure(err) {
+		return ErrNotDeadLettered
+	}
+
+	return err
+}
+
+func (q *Queue) status(status string) string {
+	return q.name + "#" + status
+}
+
+func (q *Queue) builder(ctx context.Context, id string) (*dyc.Builder, error) {
+	keys, err := q.key(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return coordination.Builder(q.cli, q.table, keys)
+}
+
+// key returns the key of a message as alternating key names and values
+func (q *Queue) key(ctx context.Context, id string) ([]interface{}, error) {
+	return coordination.ResolveKey(ctx, q.cli, q.table, q.keyNames, "dyc#queue#"+q.name+"#"+id)
+}
@@ -0,0 +1,157 @@
+//go:build integration
+// +build integration
+
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/darwayne/dyc"
+	"github.com/darwayne/dyc/internal/testing/dynamotest"
+)
+
+func setup(t *testing.T) (*dyc.Client, string) {
+	t.Helper()
+	t.Parallel()
+	cli := dyc.NewClient(dynamotest.SetupTestDB(t))
+	table := dynamotest.UniqueTableName(t, "queue")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	_, err := cli.EnsureTable(ctx, dyc.TableSpec{
+		Name:          table,
+		PartitionKey:  dyc.KeyAttribute{Name: "PK", Type: dynamodb.ScalarAttributeTypeS},
+		SortKey:       &dyc.KeyAttribute{Name: "SK", Type: dynamodb.ScalarAttributeTypeS},
+		GlobalIndexes: []dyc.IndexSpec{IndexSpec(DefaultIndex)},
+	})
+	require.NoError(t, err)
+
+	return cli, table
+}
+
+func testCtx(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestQueue(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		cli, table := setup(t)
+		ctx := testCtx(t)
+		q := New(cli, table, "emails")
+
+		id, err := q.Enqueue(ctx, []byte("hello"))
+		require.NoError(t, err)
+		_, err = q.Enqueue(ctx, []byte("later"), WithDelay(time.Hour))
+		require.NoError(t, err)
+
+		messages, err := q.Claim(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		require.Equal(t, id, messages[0].ID)
+		require.Equal(t, []byte("hello"), messages[0].Body)
+		require.Equal(t, 1, messages[0].Attempts)
+
+		again, err := q.Claim(ctx, 10)
+		require.NoError(t, err)
+		require.Empty(t, again)
+
+		require.NoError(t, q.Ack(ctx, messages[0]))
+		require.Equal(t, ErrReceiptExpired, q.Ack(ctx, messages[0]))
+	})
+
+	t.Run("should isolate queues sharing a table", func(t *testing.T) {
+		cli, table := setup(t)
+		ctx := testCtx(t)
+
+		_, err := New(cli, table, "emails").Enqueue(ctx, []byte("hello"))
+		require.NoError(t, err)
+
+		messages, err := New(cli, table, "sms").Claim(ctx, 10)
+		require.NoError(t, err)
+		require.Empty(t, messages)
+	})
+
+	t.Run("Nack", func(t *testing.T) {
+		cli, table := setup(t)
+		ctx := testCtx(t)
+		q := New(cli, table, "emails")
+
+		_, err := q.Enqueue(ctx, []byte("hello"))
+		require.NoError(t, err)
+
+		messages, err := q.Claim(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		require.NoError(t, q.Nack(ctx, messages[0], 0))
+		require.Equal(t, ErrReceiptExpired, q.Nack(ctx, messages[0], 0))
+
+		messages, err = q.Claim(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		require.Equal(t, 2, messages[0].Attempts)
+		require.NoError(t, q.Nack(ctx, messages[0], time.Hour))
+
+		messages, err = q.Claim(ctx, 1)
+		require.NoError(t, err)
+		require.Empty(t, messages)
+	})
+
+	t.Run("should make messages visible again after the visibility timeout", func(t *testing.T) {
+		cli, table := setup(t)
+		ctx := testCtx(t)
+		q := New(cli, table, "emails", WithVisibilityTimeout(200*time.Millisecond))
+
+		_, err := q.Enqueue(ctx, []byte("hello"))
+		require.NoError(t, err)
+
+		first, err := q.Claim(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, first, 1)
+
+		time.Sleep(300 * time.Millisecond)
+		second, err := q.Claim(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, second, 1)
+
+		require.Equal(t, ErrReceiptExpired, q.Ack(ctx, first[0]))
+		require.NoError(t, q.Ack(ctx, second[0]))
+	})
+
+	t.Run("should dead letter messages after max attempts", func(t *testing.T) {
+		cli, table := setup(t)
+		ctx := testCtx(t)
+		q := New(cli, table, "emails", WithMaxAttempts(1))
+
+		_, err := q.Enqueue(ctx, []byte("hello"))
+		require.NoError(t, err)
+
+		messages, err := q.Claim(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		require.NoError(t, q.Nack(ctx, messages[0], 0))
+
+		messages, err = q.Claim(ctx, 1)
+		require.NoError(t, err)
+		require.Empty(t, messages)
+
+		dead, err := q.DeadLetters(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, dead, 1)
+		require.Equal(t, []byte("hello"), dead[0].Body)
+
+		require.NoError(t, q.Redrive(ctx, dead[0]))
+		require.Equal(t, ErrNotDeadLettered, q.Redrive(ctx, dead[0]))
+
+		messages, err = q.Claim(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		require.Equal(t, 1, messages[0].Attempts)
+	})
+}
@@ -0,0 +1,47 @@
+//go:build unit
+// +build unit
+
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexSpec(t *testing.T) {
+	spec := IndexSpec(DefaultIndex)
+	require.Equal(t, DefaultIndex, spec.Name)
+	require.Equal(t, StatusAttribute, spec.PartitionKey.Name)
+	require.Equal(t, dynamodb.ScalarAttributeTypeS, spec.PartitionKey.Type)
+	require.Equal(t, VisibleAtAttribute, spec.SortKey.Name)
+	require.Equal(t, dynamodb.ScalarAttributeTypeN, spec.SortKey.Type)
+}
+
+func TestQueue_key(t *testing.T) {
+	q := New(nil, "MyTable", "emails", WithKeyNames("PK", "SK"))
+	keys, err := q.key(context.Background(), "abc")
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"PK", "dyc#queue#emails#abc", "SK", "dyc#queue#emails#abc"}, keys)
+	require.Equal(t, "emails#ready", q.status(statusReady))
+}
+
+func TestWithDelay(t *testing.T) {
+	r := record{VisibleAt: 100}
+	WithDelay(time.Second)(&r)
+	require.EqualValues(t, 100+int64(time.Second), r.VisibleAt)
+}
+
+func TestQueue_Claim(t *testing.T) {
+	q := New(nil, "MyTable", "emails", WithKeyNames("PK", "SK"))
+	for _, max := range []int{0, -1} {
+		_, err := q.Claim(context.Background(), max)
+		require.Equal(t, ErrInvalidMax, err)
+
+		_, err = q.DeadLetters(context.Background(), max)
+		require.Equal(t, ErrInvalidMax, err)
+	}
+}
@@ -0,0 +1,147 @@
+package dyc
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// expressionFields are the expression fields of sdk inputs in the order they are rendered
+var expressionFields = []struct {
+	field string
+	name  string
+}{
+	{"KeyConditionExpression", "key_condition"},
+	{"FilterExpression", "filter"},
+	{"ProjectionExpression", "projection"},
+	{"ConditionExpression", "condition"},
+	{"UpdateExpression", "update"},
+}
+
+// RenderedExpression is an expression of an sdk input with its placeholders substituted
+type RenderedExpression struct {
+	// Name identifies the expression: key_condition, filter, projection, condition or update
+	Name       string
+	Expression string
+}
+
+var placeholderRegex = regexp.MustCompile(`[#:][A-Za-z0-9_]+`)
+
+// RenderExpressions renders the expressions of an sdk input such as *dynamodb.QueryInput in the order
+// key_condition, filter, projection, condition and update. attribute name placeholders are substituted,
+// value placeholders are rendered via value, or redacted as ? if value is nil
+func RenderExpressions(input interface{}, value func(av *dynamodb.AttributeValue) string) []RenderedExpression {
+	rv := reflect.Indirect(reflect.ValueOf(input))
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	names, _ := fieldValue(rv, "ExpressionAttributeNames").(map[string]*string)
+	values, _ := fieldValue(rv, "ExpressionAttributeValues").(map[string]*dynamodb.AttributeValue)
+	var result []RenderedExpression
+	for _, f := range expressionFields {
+		expr, _ := fieldValue(rv, f.field).(*string)
+		if aws.StringValue(expr) == "" {
+			continue
+		}
+		result = append(result, RenderedExpression{
+			Name:       f.name,
+			Expression: renderExpression(*expr, names, values, value),
+		})
+	}
+
+	return result
+}
+
+func fieldValue(rv reflect.Value, name string) interface{} {
+	field := rv.FieldByName(name)
+	if !field.IsValid() {
+		return nil
+	}
+
+	return field.Interface()
+}
+
+// renderExpression substitutes the name placeholders of expr (#1 -> status) and renders value placeholders via value,
+// value placeholders are redacted as ? if value is nil. other placeholders without a matching name or value are left as is
+func renderExpression(expr string, names map[string]*string, values map[string]*dynamodb.AttributeValue, value func(av *dynamodb.AttributeValue) string) string {
+	return placeholderRegex.ReplaceAllStringFunc(expr, func(placeholder string) string {
+		if placeholder[0] == '#' {
+			if name, found := names[placeholder]; found {
+				return aws.StringValue(name)
+			}
+			return placeholder
+		}
+		if value == nil {
+			return "?"
+		}
+		if av, found := values[placeholder]; found {
+			return value(av)
+		}
+
+		return placeholder
+	})
+}
+
+// renderValue renders an attribute value as a literal e.g "hello", 10, ["a", "b"] or {"Name": "hello"}
+func renderValue(av *dynamodb.AttributeValue) string {
+	switch {
+	case av == nil:
+		return "null"
+	case av.S != nil:
+		return strconv.Quote(*av.S)
+	case av.N != nil:
+		return *av.N
+	case av.BOOL != nil:
+		return strconv.FormatBool(*av.BOOL)
+	case av.NULL != nil:
+		return "null"
+	case av.B != nil:
+		return "<" + strconv.Itoa(len(av.B)) + " bytes>"
+	case av.SS != nil:
+		parts := make([]string, 0, len(av.SS))
+		for _, s := range av.SS {
+			parts = append(parts, strconv.Quote(aws.StringValue(s)))
+		}
+		return "<<" + strings.Join(parts, ", ") + ">>"
+	case av.NS != nil:
+		return "<<" + strings.Join(aws.StringValueSlice(av.NS), ", ") + ">>"
+	case av.BS != nil:
+		parts := make([]string, 0, len(av.BS))
+		for _, b := range av.BS {
+			parts = append(parts, "<"+strconv.Itoa(len(b))+" bytes>")
+		}
+		return "<<" + strings.Join(parts, ", ") + ">>"
+	case av.L != nil:
+		parts := make([]string, 0, len(av.L))
+		for _, v := range av.L {
+			parts = append(parts, renderValue(v))
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case av.M != nil:
+		return renderMap(av.M, renderValue)
+	}
+
+	return "null"
+}
+
+// renderMap renders an item or key with its attributes sorted by name, values are rendered via value
+func renderMap(m map[string]*dynamodb.AttributeValue, value func(av *dynamodb.AttributeValue) string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, strconv.Quote(k)+": "+value(m[k]))
+	}
+
+	return "{" + strings.Join(parts, ", ") + "}"
+}
@@ -0,0 +1,40 @@
+//go:build unit
+// +build unit
+
+package dyc
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderExpressions(t *testing.T) {
+	input := &dynamodb.UpdateItemInput{
+		UpdateExpression:          aws.String("SET #1 = :0"),
+		ConditionExpression:       aws.String("#2 IN (:1, :10) AND #unknown = :missing"),
+		ExpressionAttributeNames:  map[string]*string{"#1": aws.String("status"), "#2": aws.String("data")},
+		ExpressionAttributeValues: Map{":0": String("done"), ":1": Int(1), ":10": Int(10)},
+	}
+
+	t.Run("should render values", func(t *testing.T) {
+		require.Equal(t, []RenderedExpression{
+			{Name: "condition", Expression: "data IN (1, 10) AND #unknown = :missing"},
+			{Name: "update", Expression: `SET status = "done"`},
+		}, RenderExpressions(input, renderValue))
+	})
+
+	t.Run("should redact values without a value renderer", func(t *testing.T) {
+		require.Equal(t, []RenderedExpression{
+			{Name: "condition", Expression: "data IN (?, ?) AND #unknown = ?"},
+			{Name: "update", Expression: "SET status = ?"},
+		}, RenderExpressions(input, nil))
+	})
+
+	t.Run("should ignore inputs without expressions", func(t *testing.T) {
+		require.Empty(t, RenderExpressions(&dynamodb.DescribeTableInput{}, nil))
+		require.Empty(t, RenderExpressions(nil, nil))
+	})
+}
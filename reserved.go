@@ -0,0 +1,76 @@
+package dyc
+
+import (
+	_ "embed"
+	"strings"
+	"text/scanner"
+	"unicode"
+)
+
+//go:embed reserved_words.txt
+var rawReservedWords string
+
+// reservedWords contains all dynamodb reserved words
+// see: https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/ReservedWords.html
+var reservedWords = toWordSet(strings.Fields(rawReservedWords)...)
+
+// expressionKeywords are words that make up the expression syntax itself and should never be aliased
+var expressionKeywords = toWordSet("AND", "OR", "NOT", "BETWEEN", "IN", "SET", "REMOVE", "ADD", "DELETE")
+
+// expressionFunctions are functions supported in dynamodb expressions
+var expressionFunctions = toWordSet(
+	"attribute_exists", "attribute_not_exists", "attribute_type", "begins_with",
+	"contains", "size", "if_not_exists", "list_append",
+)
+
+func toWordSet(words ...string) map[string]struct{} {
+	result := make(map[string]struct{}, len(words))
+	for _, word := range words {
+		result[strings.ToUpper(word)] = struct{}{}
+	}
+
+	return result
+}
+
+// IsReservedWord returns true if the provided word is a dynamodb reserved word
+func IsReservedWord(word string) bool {
+	_, found := reservedWords[strings.ToUpper(word)]
+	return found
+}
+
+// needsAlias determines if an unquoted identifier must be replaced by an expression attribute name.
+// next is the first non whitespace rune following the identifier so e.g size (tags) is still a function call
+func needsAlias(ident string, next rune) bool {
+	upper := strings.ToUpper(ident)
+	if _, found := expressionKeywords[upper]; found {
+		return false
+	}
+	if _, found := expressionFunctions[upper]; found && next == '(' {
+		return false
+	}
+	if _, found := reservedWords[upper]; found {
+		return true
+	}
+
+	for idx, ch := range ident {
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z':
+		case idx > 0 && (ch == '_' || (ch >= '0' && ch <= '9')):
+		default:
+			return true
+		}
+	}
+
+	return false
+}
+
+// nextNonSpace returns the first rune of s that isn't whitespace or scanner.EOF if there is none
+func nextNonSpace(s string) rune {
+	for _, ch := range s {
+		if !unicode.IsSpace(ch) {
+			return ch
+		}
+	}
+
+	return scanner.EOF
+}
@@ -0,0 +1,177 @@
+package dyc
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// TableSchema contains the key attributes of a table and its secondary indexes
+type TableSchema struct {
+	// Name of the table
+	Name string
+	// Keys contains the partition key followed by the sort key (if the table has one)
+	Keys []string
+	// Indexes contains the keys of every global and local secondary index keyed by index name
+	Indexes map[string][]string
+	// ItemCount is the approximate amount of items in the table when it was described.
+	// dynamodb updates this value roughly every six hours
+	ItemCount int64
+	// TableSizeBytes is the approximate size of the table when it was described, updated as often as ItemCount
+	TableSizeBytes int64
+}
+
+// IndexKeys returns the keys of the provided index or nil if the index doesn't exist
+func (t *TableSchema) IndexKeys(index string) []string {
+	return t.Indexes[index]
+}
+
+// NewTableSchema creates a table schema from a dynamodb table description
+func NewTableSchema(desc *dynamodb.TableDescription) *TableSchema {
+	schema := &TableSchema{
+		Name:           aws.StringValue(desc.TableName),
+		Keys:           keySchemaToKeys(desc.KeySchema),
+		Indexes:        make(map[string][]string, len(desc.GlobalSecondaryIndexes)+len(desc.LocalSecondaryIndexes)),
+		ItemCount:      aws.Int64Value(desc.ItemCount),
+		TableSizeBytes: aws.Int64Value(desc.TableSizeBytes),
+	}
+
+	for _, idx := range desc.GlobalSecondaryIndexes {
+		schema.Indexes[aws.StringValue(idx.IndexName)] = keySchemaToKeys(idx.KeySchema)
+	}
+	for _, idx := range desc.LocalSecondaryIndexes {
+		schema.Indexes[aws.StringValue(idx.IndexName)] = keySchemaToKeys(idx.KeySchema)
+	}
+
+	return schema
+}
+
+// keySchemaToKeys returns the key names with the partition key first
+func keySchemaToKeys(keySchema []*dynamodb.KeySchemaElement) []string {
+	result := make([]string, 0, len(keySchema))
+	for _, elem := range keySchema {
+		if aws.StringValue(elem.KeyType) == dynamodb.KeyTypeHash {
+			result = append([]string{aws.StringValue(elem.AttributeName)}, result...)
+			continue
+		}
+		result = append(result, aws.StringValue(elem.AttributeName))
+	}
+
+	return result
+}
+
+// TableSchema returns the schema of the provided table.
+// DescribeTable is only called the first time a table is requested, subsequent calls are served from cache
+func (c *Client) TableSchema(ctx context.Context, table string) (*TableSchema, error) {
+	return c.schemas.get(ctx, table, func(ctx context.Context) (*TableSchema, error) {
+		out, err := c.describeTable(ctx, &dynamodb.DescribeTableInput{
+			TableName: aws.String(table),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return NewTableSchema(out.Table), nil
+	})
+}
+
+// TableKeys returns the primary keys of the provided table, partition key first.
+// PK,SK is returned if schema discovery is disabled or the caller isn't allowed to call DescribeTable
+func (c *Client) TableKeys(ctx context.Context, table string) ([]string, error) {
+	return c.schemaKeys(ctx, table, []string{"PK", "SK"})
+}
+
+// schemaKeys returns the primary keys of the provided table. the fallback keys are returned if schema discovery is
+// disabled or the caller isn't allowed to call DescribeTable so callers without the permission keep working
+func (c *Client) schemaKeys(ctx context.Context, table string, fallback []string) ([]string, error) {
+	if c.disableSchemaDiscovery {
+		return fallback, nil
+	}
+
+	schema, err := c.TableSchema(ctx, table)
+	if isAccessDenied(err) {
+		return fallback, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.Keys, nil
+}
+
+// InvalidateTableSchema removes the cached schema for the provided table
+func (c *Client) InvalidateTableSchema(table string) {
+	c.schemas.delete(table)
+}
+
+type schemaEntry struct {
+	ready  chan struct{}
+	schema *TableSchema
+	err    error
+}
+
+// schemaCache ensures DescribeTable is called once per table even with concurrent callers.
+// the zero value is ready to use
+type schemaCache struct {
+	mu      sync.Mutex
+	entries map[string]*schemaEntry
+}
+
+func (s *schemaCache) get(ctx context.Context, table string, describe func(ctx context.Context) (*TableSchema, error)) (*TableSchema, error) {
+	s.mu.Lock()
+	if s.entries == nil {
+		s.entries = make(map[string]*schemaEntry)
+	}
+	entry, found := s.entries[table]
+	if !found {
+		entry = &schemaEntry{ready: make(chan struct{})}
+		s.entries[table] = entry
+	}
+	s.mu.Unlock()
+
+	if found {
+		select {
+		case <-entry.ready:
+			return entry.schema, entry.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	entry.schema, entry.err = describe(ctx)
+	if entry.err != nil && !isAccessDenied(entry.err) {
+		// failures aren't cached so the next caller can try again. access denied errors are cached
+		// so callers falling back to configured keys don't call DescribeTable for every request
+		s.mu.Lock()
+		delete(s.entries, table)
+		s.mu.Unlock()
+	}
+	close(entry.ready)
+
+	return entry.schema, entry.err
+}
+
+// cached returns the schema of the table if it was already described, without waiting on pending calls
+func (s *schemaCache) cached(table string) (*TableSchema, bool) {
+	s.mu.Lock()
+	entry, found := s.entries[table]
+	s.mu.Unlock()
+	if !found {
+		return nil, false
+	}
+
+	select {
+	case <-entry.ready:
+		return entry.schema, entry.err == nil
+	default:
+		return nil, false
+	}
+}
+
+func (s *schemaCache) delete(table string) {
+	s.mu.Lock()
+	delete(s.entries, table)
+	s.mu.Unlock()
+}
@@ -0,0 +1,130 @@
+//go:build unit
+// +build unit
+
+package dyc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTableSchema(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		schema := NewTableSchema(&dynamodb.TableDescription{
+			TableName: aws.String("MyTable"),
+			KeySchema: []*dynamodb.KeySchemaElement{
+				{AttributeName: aws.String("SK"), KeyType: aws.String("RANGE")},
+				{AttributeName: aws.String("PK"), KeyType: aws.String("HASH")},
+			},
+			GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndexDescription{
+				{
+					IndexName: aws.String("GSI1"),
+					KeySchema: []*dynamodb.KeySchemaElement{
+						{AttributeName: aws.String("GSI1PK"), KeyType: aws.String("HASH")},
+						{AttributeName: aws.String("GSI1SK"), KeyType: aws.String("RANGE")},
+					},
+				},
+			},
+			LocalSecondaryIndexes: []*dynamodb.LocalSecondaryIndexDescription{
+				{
+					IndexName: aws.String("LSI1"),
+					KeySchema: []*dynamodb.KeySchemaElement{
+						{AttributeName: aws.String("PK"), KeyType: aws.String("HASH")},
+						{AttributeName: aws.String("LSI1SK"), KeyType: aws.String("RANGE")},
+					},
+				},
+			},
+		})
+
+		require.Equal(t, "MyTable", schema.Name)
+		require.Equal(t, []string{"PK", "SK"}, schema.Keys)
+		require.Equal(t, []string{"GSI1PK", "GSI1SK"}, schema.IndexKeys("GSI1"))
+		require.Equal(t, []string{"PK", "LSI1SK"}, schema.IndexKeys("LSI1"))
+		require.Empty(t, schema.IndexKeys("missing"))
+	})
+}
+
+func TestSchemaCache(t *testing.T) {
+	t.Run("should only describe once", func(t *testing.T) {
+		var cache schemaCache
+		var calls int64
+		describe := func(ctx context.Context) (*TableSchema, error) {
+			atomic.AddInt64(&calls, 1)
+			return &TableSchema{Name: "MyTable", Keys: []string{"PK"}}, nil
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				schema, err := cache.get(context.Background(), "MyTable", describe)
+				require.NoError(t, err)
+				require.Equal(t, []string{"PK"}, schema.Keys)
+			}()
+		}
+		wg.Wait()
+
+		require.EqualValues(t, 1, calls)
+	})
+
+	t.Run("should not cache failures", func(t *testing.T) {
+		var cache schemaCache
+		_, err := cache.get(context.Background(), "MyTable", func(ctx context.Context) (*TableSchema, error) {
+			return nil, errors.New("boom")
+		})
+		require.Error(t, err)
+
+		schema, err := cache.get(context.Background(), "MyTable", func(ctx context.Context) (*TableSchema, error) {
+			return &TableSchema{Name: "MyTable"}, nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "MyTable", schema.Name)
+	})
+}
+
+func TestBuilder_TableKeys(t *testing.T) {
+	t.Run("should fall back to the configured keys when DescribeTable is denied", func(t *testing.T) {
+		var describes int64
+		cli := NewClient(offlineDB(t))
+		cli.Use(func(next Handler) Handler {
+			return func(ctx context.Context, op *Operation) error {
+				atomic.AddInt64(&describes, 1)
+				return awserr.New("AccessDeniedException", "not authorized to perform: dynamodb:DescribeTable", nil)
+			}
+		})
+
+		keys, err := cli.Builder().Table("MyTable").tableKeys(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []string{"PK", "SK"}, keys)
+
+		keys, err = cli.TableKeys(context.Background(), "MyTable")
+		require.NoError(t, err)
+		require.Equal(t, []string{"PK", "SK"}, keys)
+		require.EqualValues(t, 1, describes)
+
+		keys, err = cli.Builder().Table("MyTable").WithPrimaryKeys("ID").tableKeys(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []string{"ID"}, keys)
+	})
+
+	t.Run("should return other DescribeTable errors", func(t *testing.T) {
+		cli := NewClient(offlineDB(t))
+		cli.Use(func(next Handler) Handler {
+			return func(ctx context.Context, op *Operation) error {
+				return awserr.New(dynamodb.ErrCodeResourceNotFoundException, "table not found", nil)
+			}
+		})
+
+		_, err := cli.Builder().Table("MyTable").tableKeys(context.Background())
+		require.True(t, isResourceNotFound(err))
+	})
+}
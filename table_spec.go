@@ -0,0 +1,771 @@
+package dyc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/pkg/errors"
+)
+
+// tableStatusPollInterval is how often table status is checked while waiting for a table to become active
+var tableStatusPollInterval = 2 * time.Second
+
+// KeyAttribute describes a key attribute of a table or index
+type KeyAttribute struct {
+	Name string
+	// Type is the dynamodb scalar type of the attribute (S, N or B)
+	Type string
+}
+
+// IndexSpec describes a global or local secondary index
+type IndexSpec struct {
+	Name         string
+	PartitionKey KeyAttribute
+	SortKey      *KeyAttribute
+	// ProjectionType defaults to ALL
+	ProjectionType   string
+	NonKeyAttributes []string
+	// Throughput is only used for global indexes on provisioned tables, the table throughput is used if not set
+	Throughput *dynamodb.ProvisionedThroughput
+}
+
+// TableSpec declares the desired state of a table
+type TableSpec struct {
+	Name          string
+	PartitionKey  KeyAttribute
+	SortKey       *KeyAttribute
+	GlobalIndexes []IndexSpec
+	// LocalIndexes can only be set when the table is created
+	LocalIndexes []IndexSpec
+	// BillingMode defaults to PROVISIONED if Throughput is set and PAY_PER_REQUEST otherwise.
+	// PROVISIONED requires Throughput to be set
+	BillingMode string
+	Throughput  *dynamodb.ProvisionedThroughput
+	// TTLAttribute enables time to live on the provided attribute when set
+	TTLAttribute string
+	// StreamViewType enables streams with the provided view type when set (e.g NEW_AND_OLD_IMAGES)
+	StreamViewType string
+}
+
+// Table change types reported by EnsureTable
+const (
+	ChangeCreateTable       = "CREATE_TABLE"
+	ChangeCreateIndex       = "CREATE_INDEX"
+	ChangeDeleteIndex       = "DELETE_INDEX"
+	ChangeUpdateBillingMode = "UPDATE_BILLING_MODE"
+	ChangeUpdateThroughput  = "UPDATE_THROUGHPUT"
+	ChangeUpdateStream      = "UPDATE_STREAM"
+	ChangeUpdateTTL         = "UPDATE_TTL"
+)
+
+// TableChange is a single change needed to make a table match its spec
+type TableChange struct {
+	Type string
+	// Target is the table or index the change applies to
+	Target string
+	Detail string
+}
+
+// String returns a human readable version of the change
+func (t TableChange) String() string {
+	return fmt.Sprintf("%s %s: %s", t.Type, t.Target, t.Detail)
+}
+
+// TableDiff contains all changes needed to make a table match its spec
+type TableDiff struct {
+	Table   string
+	Changes []TableChange
+}
+
+// Empty returns true if the table already matches its spec
+func (t *TableDiff) Empty() bool {
+	return len(t.Changes) == 0
+}
+
+// String returns a human readable version of the diff
+func (t *TableDiff) String() string {
+	if t.Empty() {
+		return t.Table + ": up to date"
+	}
+
+	lines := make([]string, 0, len(t.Changes)+1)
+	lines = append(lines, t.Table+":")
+	for _, change := range t.Changes {
+		lines = append(lines, "  "+change.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// EnsureTableOption allows you to configure EnsureTable behavior
+type EnsureTableOption func(opts *ensureTableOptions)
+
+type ensureTableOptions struct {
+	dryRun bool
+}
+
+// DryRun only reports the changes EnsureTable would make without applying them
+func DryRun() EnsureTableOption {
+	return func(opts *ensureTableOptions) {
+		opts.dryRun = true
+	}
+}
+
+// EnsureTable makes the table match the provided spec.
+// missing tables are created, global indexes are added or removed to match the spec
+// (indexes with changed keys or projections are recreated)
+// and billing mode, provisioned throughput, ttl and stream settings are updated.
+// dynamodb allows a single ttl change per table roughly every hour, switching ttl to another attribute
+// therefore disables ttl and returns ErrTTLSwitchPending, ttl is enabled on the new attribute by a later run. EnsureTable waits for the table
+// and all of its indexes to be ACTIVE before returning.
+// The returned diff contains every change that was (or in dry run mode would be) applied
+func (c *Client) EnsureTable(ctx context.Context, spec TableSpec, opts ...EnsureTableOption) (*TableDiff, error) {
+	var options ensureTableOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+
+	desc, ttl, err := c.describeTableState(ctx, spec.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := spec.diff(desc, ttl)
+	if err != nil || options.dryRun || diff.Empty() {
+		return diff, err
+	}
+
+	defer c.InvalidateTableSchema(spec.Name)
+	for _, change := range diff.Changes {
+		if err := c.applyTableChange(ctx, spec, desc, ttl, change); err != nil {
+			return diff, err
+		}
+	}
+
+	return diff, nil
+}
+
+// describeTableState returns the table description and ttl settings. nil values are returned if the table doesn't exist
+func (c *Client) describeTableState(ctx context.Context, table string) (*dynamodb.TableDescription, *dynamodb.TimeToLiveDescription, error) {
+	out, err := c.describeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(table),
+	})
+	if isResourceNotFound(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ttl, err := c.describeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{
+		TableName: aws.String(table),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return out.Table, ttl.TimeToLiveDescription, nil
+}
+
+func (c *Client) applyTableChange(ctx context.Context, spec TableSpec, desc *dynamodb.TableDescription, ttl *dynamodb.TimeToLiveDescription, change TableChange) error {
+	switch change.Type {
+	case ChangeCreateTable:
+		if _, err := c.createTable(ctx, spec.createTableInput()); err != nil {
+			return err
+		}
+	case ChangeCreateIndex:
+		idx := spec.globalIndex(change.Target)
+		_, err := c.updateTable(ctx, &dynamodb.UpdateTableInput{
+			TableName:            aws.String(spec.Name),
+			AttributeDefinitions: spec.attributeDefinitions(),
+			GlobalSecondaryIndexUpdates: []*dynamodb.GlobalSecondaryIndexUpdate{
+				{Create: &dynamodb.CreateGlobalSecondaryIndexAction{
+					IndexName:             aws.String(idx.Name),
+					KeySchema:             idx.keySchema(),
+					Projection:            idx.projection(),
+					ProvisionedThroughput: spec.indexThroughput(idx),
+				}},
+			},
+		})
+		if err != nil {
+			return err
+		}
+	case ChangeDeleteIndex:
+		_, err := c.updateTable(ctx, &dynamodb.UpdateTableInput{
+			TableName: aws.String(spec.Name),
+			GlobalSecondaryIndexUpdates: []*dynamodb.GlobalSecondaryIndexUpdate{
+				{Delete: &dynamodb.DeleteGlobalSecondaryIndexAction{IndexName: aws.String(change.Target)}},
+			},
+		})
+		if err != nil {
+			return err
+		}
+	case ChangeUpdateBillingMode:
+		if err := c.updateBillingMode(ctx, spec); err != nil {
+			return err
+		}
+	case ChangeUpdateThroughput:
+		input := &dynamodb.UpdateTableInput{TableName: aws.String(spec.Name)}
+		if idx := spec.globalIndex(change.Target); idx != nil {
+			input.GlobalSecondaryIndexUpdates = []*dynamodb.GlobalSecondaryIndexUpdate{
+				{Update: &dynamodb.UpdateGlobalSecondaryIndexAction{
+					IndexName:             aws.String(idx.Name),
+					ProvisionedThroughput: spec.indexThroughput(idx),
+				}},
+			}
+		} else {
+			input.ProvisionedThroughput = spec.Throughput
+		}
+		if _, err := c.updateTable(ctx, input); err != nil {
+			return err
+		}
+	case ChangeUpdateStream:
+		if err := c.updateStream(ctx, spec, desc); err != nil {
+			return err
+		}
+	case ChangeUpdateTTL:
+		if err := c.updateTTL(ctx, spec, ttl); err != nil {
+			return err
+		}
+	}
+
+	return c.waitForActiveTable(ctx, spec.Name)
+}
+
+func (c *Client) updateStream(ctx context.Context, spec TableSpec, desc *dynamodb.TableDescription) error {
+	current := streamViewType(desc)
+	if current != "" {
+		_, err := c.updateTable(ctx, &dynamodb.UpdateTableInput{
+			TableName:           aws.String(spec.Name),
+			StreamSpecification: &dynamodb.StreamSpecification{StreamEnabled: aws.Bool(false)},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if spec.StreamViewType == "" {
+		return nil
+	}
+
+	if err := c.waitForActiveTable(ctx, spec.Name); err != nil {
+		return err
+	}
+
+	_, err := c.updateTable(ctx, &dynamodb.UpdateTableInput{
+		TableName:           aws.String(spec.Name),
+		StreamSpecification: spec.streamSpecification(),
+	})
+
+	return err
+}
+
+// updateBillingMode switches the billing mode of the table. switching to provisioned requires throughput for the table
+// and every global index on the table, the table is described again since index deletions applied before this change
+// must be finished and are no longer part of the table
+func (c *Client) updateBillingMode(ctx context.Context, spec TableSpec) error {
+	input := &dynamodb.UpdateTableInput{
+		TableName:   aws.String(spec.Name),
+		BillingMode: aws.String(spec.billingMode()),
+	}
+	if spec.billingMode() == dynamodb.BillingModeProvisioned {
+		out, err := c.describeTable(ctx, &dynamodb.DescribeTableInput{
+			TableName: aws.String(spec.Name),
+		})
+		if err != nil {
+			return err
+		}
+
+		input.ProvisionedThroughput = spec.Throughput
+		for _, existing := range out.Table.GlobalSecondaryIndexes {
+			throughput := spec.Throughput
+			if idx := spec.globalIndex(aws.StringValue(existing.IndexName)); idx != nil {
+				throughput = spec.indexThroughput(idx)
+			}
+			input.GlobalSecondaryIndexUpdates = append(input.GlobalSecondaryIndexUpdates, &dynamodb.GlobalSecondaryIndexUpdate{
+				Update: &dynamodb.UpdateGlobalSecondaryIndexAction{
+					IndexName:             existing.IndexName,
+					ProvisionedThroughput: throughput,
+				},
+			})
+		}
+	}
+
+	_, err := c.updateTable(ctx, input)
+
+	return err
+}
+
+// updateTTL enables or disables ttl to match the spec. dynamodb rejects a ttl change made within about an hour of
+// the previous one, so switching ttl to another attribute only disables the current attribute and
+// returns ErrTTLSwitchPending. ttl is enabled on the new attribute once EnsureTable runs again after the cooldown
+func (c *Client) updateTTL(ctx context.Context, spec TableSpec, ttl *dynamodb.TimeToLiveDescription) error {
+	if current := ttlAttribute(ttl); current != "" {
+		if err := c.setTTL(ctx, spec.Name, current, false); err != nil {
+			return err
+		}
+		if spec.TTLAttribute != "" {
+			return errors.Wrapf(ErrTTLSwitchPending, "disabled ttl on %s, run again in an hour to enable it on %s", current, spec.TTLAttribute)
+		}
+
+		return nil
+	}
+
+	if spec.TTLAttribute == "" {
+		return nil
+	}
+
+	return c.setTTL(ctx, spec.Name, spec.TTLAttribute, true)
+}
+
+func (c *Client) setTTL(ctx context.Context, table, attr string, enabled bool) error {
+	_, err := c.updateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(table),
+		TimeToLiveSpecification: &dynamodb.TimeToLiveSpecification{
+			AttributeName: aws.String(attr),
+			Enabled:       aws.Bool(enabled),
+		},
+	})
+
+	return err
+}
+
+// waitForActiveTable waits until the table and all of its global indexes are ACTIVE
+func (c *Client) waitForActiveTable(ctx context.Context, table string) error {
+	ticker := time.NewTicker(tableStatusPollInterval)
+	defer ticker.Stop()
+
+	for {
+		out, err := c.describeTable(ctx, &dynamodb.DescribeTableInput{
+			TableName: aws.String(table),
+		})
+		if err != nil {
+			return err
+		}
+		if isTableActive(out.Table) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func isTableActive(desc *dynamodb.TableDescription) bool {
+	if aws.StringValue(desc.TableStatus) != dynamodb.TableStatusActive {
+		return false
+	}
+	for _, idx := range desc.GlobalSecondaryIndexes {
+		if aws.StringValue(idx.IndexStatus) != dynamodb.IndexStatusActive {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isResourceNotFound(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == dynamodb.ErrCodeResourceNotFoundException
+}
+
+func (s TableSpec) validate() error {
+	if s.Name == "" || s.PartitionKey.Name == "" {
+		return ErrInvalidTableSpec
+	}
+	if s.billingMode() == dynamodb.BillingModeProvisioned && s.Throughput == nil {
+		return ErrInvalidTableSpec
+	}
+
+	types := make(map[string]string)
+	for _, attr := range s.keyAttributes() {
+		if attr.Name == "" || attr.Type == "" {
+			return ErrInvalidTableSpec
+		}
+		if existing, found := types[attr.Name]; found && existing != attr.Type {
+			return ErrInvalidTableSpec
+		}
+		types[attr.Name] = attr.Type
+	}
+
+	return nil
+}
+
+// diff compares the spec to the current table state
+func (s TableSpec) diff(desc *dynamodb.TableDescription, ttl *dynamodb.TimeToLiveDescription) (*TableDiff, error) {
+	diff := &TableDiff{Table: s.Name}
+	if desc == nil {
+		diff.Changes = append(diff.Changes, TableChange{
+			Type: ChangeCreateTable, Target: s.Name, Detail: "table does not exist",
+		})
+		if s.TTLAttribute != "" {
+			diff.Changes = append(diff.Changes, TableChange{
+				Type: ChangeUpdateTTL, Target: s.Name, Detail: "enable ttl on " + s.TTLAttribute,
+			})
+		}
+
+		return diff, nil
+	}
+
+	if !keysEqual(keySchemaToKeys(desc.KeySchema), s.keys()) {
+		return diff, ErrKeySchemaMismatch
+	}
+
+	current := make(map[string][]string, len(desc.LocalSecondaryIndexes))
+	for _, idx := range desc.LocalSecondaryIndexes {
+		current[aws.StringValue(idx.IndexName)] = keySchemaToKeys(idx.KeySchema)
+	}
+	if len(current) != len(s.LocalIndexes) {
+		return diff, ErrLocalIndexMismatch
+	}
+	for _, idx := range s.LocalIndexes {
+		if keys, found := current[idx.Name]; !found || !keysEqual(keys, idx.keys()) {
+			return diff, ErrLocalIndexMismatch
+		}
+	}
+
+	current = make(map[string][]string, len(desc.GlobalSecondaryIndexes))
+	projections := make(map[string]string, len(desc.GlobalSecondaryIndexes))
+	throughputs := make(map[string]*dynamodb.ProvisionedThroughputDescription, len(desc.GlobalSecondaryIndexes))
+	for _, idx := range desc.GlobalSecondaryIndexes {
+		current[aws.StringValue(idx.IndexName)] = keySchemaToKeys(idx.KeySchema)
+		projections[aws.StringValue(idx.IndexName)] = describeProjection(idx.Projection)
+		throughputs[aws.StringValue(idx.IndexName)] = idx.ProvisionedThroughput
+	}
+
+	// indexes are deleted first so billing mode changes only have to account for the remaining indexes,
+	// new indexes are created last so they are created with the new billing mode
+	var deletes, creates, updates []TableChange
+	desired := make(map[string]struct{}, len(s.GlobalIndexes))
+	for i, idx := range s.GlobalIndexes {
+		desired[idx.Name] = struct{}{}
+		keys, found := current[idx.Name]
+		projection := describeProjection(idx.projection())
+		switch {
+		case found && !keysEqual(keys, idx.keys()):
+			deletes = append(deletes, TableChange{
+				Type: ChangeDeleteIndex, Target: idx.Name, Detail: "key schema changed from " + strings.Join(keys, ","),
+			})
+		case found && projections[idx.Name] != projection:
+			// dynamodb can't update the projection of an index, it has to be recreated
+			deletes = append(deletes, TableChange{
+				Type: ChangeDeleteIndex, Target: idx.Name, Detail: "projection changed from " + projections[idx.Name],
+			})
+		case found:
+			if change, changed := s.throughputChange(idx.Name, desc, throughputs[idx.Name], s.indexThroughput(&s.GlobalIndexes[i])); changed {
+				updates = append(updates, change)
+			}
+			continue
+		}
+		creates = append(creates, TableChange{
+			Type: ChangeCreateIndex, Target: idx.Name, Detail: "create index on " + strings.Join(idx.keys(), ","),
+		})
+	}
+
+	var removed []string
+	for name := range current {
+		if _, found := desired[name]; !found {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		deletes = append(deletes, TableChange{
+			Type: ChangeDeleteIndex, Target: name, Detail: "index not in spec",
+		})
+	}
+
+	diff.Changes = append(diff.Changes, deletes...)
+	if mode := currentBillingMode(desc); mode != s.billingMode() {
+		diff.Changes = append(diff.Changes, TableChange{
+			Type: ChangeUpdateBillingMode, Target: s.Name, Detail: mode + " -> " + s.billingMode(),
+		})
+	} else if change, changed := s.throughputChange(s.Name, desc, desc.ProvisionedThroughput, s.Throughput); changed {
+		diff.Changes = append(diff.Changes, change)
+	}
+	diff.Changes = append(diff.Changes, updates...)
+	diff.Changes = append(diff.Changes, creates...)
+
+	if view := streamViewType(desc); view != s.StreamViewType {
+		diff.Changes = append(diff.Changes, TableChange{
+			Type: ChangeUpdateStream, Target: s.Name, Detail: describeSetting(view) + " -> " + describeSetting(s.StreamViewType),
+		})
+	}
+
+	if attr := ttlAttribute(ttl); attr != s.TTLAttribute {
+		detail := describeSetting(attr) + " -> " + describeSetting(s.TTLAttribute)
+		if attr != "" && s.TTLAttribute != "" {
+			detail += " (disables " + attr + " now, " + s.TTLAttribute + " is enabled by a run after the one hour ttl cooldown)"
+		}
+		diff.Changes = append(diff.Changes, TableChange{
+			Type: ChangeUpdateTTL, Target: s.Name, Detail: detail,
+		})
+	}
+
+	return diff, nil
+}
+
+// throughputChange compares the provisioned throughput of the table or an index to the desired throughput.
+// throughput is only compared if the table already uses provisioned billing and the spec keeps it that way
+func (s TableSpec) throughputChange(target string, desc *dynamodb.TableDescription, current *dynamodb.ProvisionedThroughputDescription, desired *dynamodb.ProvisionedThroughput) (TableChange, bool) {
+	if desired == nil || s.billingMode() != dynamodb.BillingModeProvisioned || currentBillingMode(desc) != dynamodb.BillingModeProvisioned {
+		return TableChange{}, false
+	}
+
+	var read, write int64
+	if current != nil {
+		read, write = aws.Int64Value(current.ReadCapacityUnits), aws.Int64Value(current.WriteCapacityUnits)
+	}
+	if read == aws.Int64Value(desired.ReadCapacityUnits) && write == aws.Int64Value(desired.WriteCapacityUnits) {
+		return TableChange{}, false
+	}
+
+	return TableChange{
+		Type:   ChangeUpdateThroughput,
+		Target: target,
+		Detail: fmt.Sprintf("%d/%d -> %d/%d read/write capacity", read, write,
+			aws.Int64Value(desired.ReadCapacityUnits), aws.Int64Value(desired.WriteCapacityUnits)),
+	}, true
+}
+
+func (s TableSpec) createTableInput() *dynamodb.CreateTableInput {
+	input := &dynamodb.CreateTableInput{
+		TableName:            aws.String(s.Name),
+		AttributeDefinitions: s.attributeDefinitions(),
+		KeySchema:            toKeySchema(s.PartitionKey, s.SortKey),
+		BillingMode:          aws.String(s.billingMode()),
+		StreamSpecification:  s.streamSpecification(),
+	}
+	if s.billingMode() == dynamodb.BillingModeProvisioned {
+		input.ProvisionedThroughput = s.Throughput
+	}
+
+	for _, idx := range s.GlobalIndexes {
+		idx := idx
+		input.GlobalSecondaryIndexes = append(input.GlobalSecondaryIndexes, &dynamodb.GlobalSecondaryIndex{
+			IndexName:             aws.String(idx.Name),
+			KeySchema:             idx.keySchema(),
+			Projection:            idx.projection(),
+			ProvisionedThroughput: s.indexThroughput(&idx),
+		})
+	}
+
+	for _, idx := range s.LocalIndexes {
+		input.LocalSecondaryIndexes = append(input.LocalSecondaryIndexes, &dynamodb.LocalSecondaryIndex{
+			IndexName:  aws.String(idx.Name),
+			KeySchema:  idx.keySchema(),
+			Projection: idx.projection(),
+		})
+	}
+
+	return input
+}
+
+func (s TableSpec) keys() []string {
+	return keyNames(s.PartitionKey, s.SortKey)
+}
+
+func (s TableSpec) keyAttributes() []KeyAttribute {
+	result := []KeyAttribute{s.PartitionKey}
+	if s.SortKey != nil {
+		result = append(result, *s.SortKey)
+	}
+
+	for _, indexes := range [][]IndexSpec{s.GlobalIndexes, s.LocalIndexes} {
+		for _, idx := range indexes {
+			result = append(result, idx.PartitionKey)
+			if idx.SortKey != nil {
+				result = append(result, *idx.SortKey)
+			}
+		}
+	}
+
+	return result
+}
+
+// attributeDefinitions returns the definitions of every key attribute used by the table and its indexes
+func (s TableSpec) attributeDefinitions() []*dynamodb.AttributeDefinition {
+	seen := make(map[string]struct{})
+	var result []*dynamodb.AttributeDefinition
+	for _, attr := range s.keyAttributes() {
+		if _, found := seen[attr.Name]; found {
+			continue
+		}
+		seen[attr.Name] = struct{}{}
+		result = append(result, &dynamodb.AttributeDefinition{
+			AttributeName: aws.String(attr.Name),
+			AttributeType: aws.String(attr.Type),
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return *result[i].AttributeName < *result[j].AttributeName
+	})
+
+	return result
+}
+
+func (s TableSpec) billingMode() string {
+	if s.BillingMode != "" {
+		return s.BillingMode
+	}
+	if s.Throughput != nil {
+		return dynamodb.BillingModeProvisioned
+	}
+
+	return dynamodb.BillingModePayPerRequest
+}
+
+func (s TableSpec) indexThroughput(idx *IndexSpec) *dynamodb.ProvisionedThroughput {
+	if s.billingMode() != dynamodb.BillingModeProvisioned {
+		return nil
+	}
+	if idx.Throughput != nil {
+		return idx.Throughput
+	}
+
+	return s.Throughput
+}
+
+func (s TableSpec) streamSpecification() *dynamodb.StreamSpecification {
+	if s.StreamViewType == "" {
+		return nil
+	}
+
+	return &dynamodb.StreamSpecification{
+		StreamEnabled:  aws.Bool(true),
+		StreamViewType: aws.String(s.StreamViewType),
+	}
+}
+
+func (s TableSpec) globalIndex(name string) *IndexSpec {
+	for i := range s.GlobalIndexes {
+		if s.GlobalIndexes[i].Name == name {
+			return &s.GlobalIndexes[i]
+		}
+	}
+
+	return nil
+}
+
+func (i IndexSpec) keys() []string {
+	return keyNames(i.PartitionKey, i.SortKey)
+}
+
+func (i IndexSpec) keySchema() []*dynamodb.KeySchemaElement {
+	return toKeySchema(i.PartitionKey, i.SortKey)
+}
+
+func (i IndexSpec) projection() *dynamodb.Projection {
+	projectionType := i.ProjectionType
+	if projectionType == "" {
+		projectionType = dynamodb.ProjectionTypeAll
+	}
+
+	projection := &dynamodb.Projection{ProjectionType: aws.String(projectionType)}
+	if len(i.NonKeyAttributes) > 0 {
+		projection.NonKeyAttributes = aws.StringSlice(i.NonKeyAttributes)
+	}
+
+	return projection
+}
+
+// describeProjection returns a comparable description of a projection e.g INCLUDE(Age,Name).
+// missing projections are treated as ALL
+func describeProjection(projection *dynamodb.Projection) string {
+	if projection == nil || projection.ProjectionType == nil {
+		return dynamodb.ProjectionTypeAll
+	}
+	if aws.StringValue(projection.ProjectionType) != dynamodb.ProjectionTypeInclude {
+		return aws.StringValue(projection.ProjectionType)
+	}
+
+	attrs := aws.StringValueSlice(projection.NonKeyAttributes)
+	sort.Strings(attrs)
+
+	return dynamodb.ProjectionTypeInclude + "(" + strings.Join(attrs, ",") + ")"
+}
+
+func toKeySchema(partitionKey KeyAttribute, sortKey *KeyAttribute) []*dynamodb.KeySchemaElement {
+	result := []*dynamodb.KeySchemaElement{
+		{AttributeName: aws.String(partitionKey.Name), KeyType: aws.String(dynamodb.KeyTypeHash)},
+	}
+	if sortKey != nil {
+		result = append(result, &dynamodb.KeySchemaElement{
+			AttributeName: aws.String(sortKey.Name), KeyType: aws.String(dynamodb.KeyTypeRange),
+		})
+	}
+
+	return result
+}
+
+func keyNames(partitionKey KeyAttribute, sortKey *KeyAttribute) []string {
+	if sortKey == nil {
+		return []string{partitionKey.Name}
+	}
+
+	return []string{partitionKey.Name, sortKey.Name}
+}
+
+func keysEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func currentBillingMode(desc *dynamodb.TableDescription) string {
+	if desc.BillingModeSummary != nil && desc.BillingModeSummary.BillingMode != nil {
+		return *desc.BillingModeSummary.BillingMode
+	}
+
+	return dynamodb.BillingModeProvisioned
+}
+
+func streamViewType(desc *dynamodb.TableDescription) string {
+	if desc == nil || desc.StreamSpecification == nil || !aws.BoolValue(desc.StreamSpecification.StreamEnabled) {
+		return ""
+	}
+
+	return aws.StringValue(desc.StreamSpecification.StreamViewType)
+}
+
+func ttlAttribute(ttl *dynamodb.TimeToLiveDescription) string {
+	if ttl == nil {
+		return ""
+	}
+
+	switch aws.StringValue(ttl.TimeToLiveStatus) {
+	case dynamodb.TimeToLiveStatusEnabled, dynamodb.TimeToLiveStatusEnabling:
+		return aws.StringValue(ttl.AttributeName)
+	}
+
+	return ""
+}
+
+func describeSetting(val string) string {
+	if val == "" {
+		return "disabled"
+	}
+
+	return val
+}
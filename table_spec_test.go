@@ -0,0 +1,308 @@
+//go:build unit
+// +build unit
+
+package dyc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+func testTableSpec() TableSpec {
+	return TableSpec{
+		Name:         "MyTable",
+		PartitionKey: KeyAttribute{Name: "PK", Type: "S"},
+		SortKey:      &KeyAttribute{Name: "SK", Type: "S"},
+		GlobalIndexes: []IndexSpec{
+			{
+				Name:         "GSI1",
+				PartitionKey: KeyAttribute{Name: "GSI1PK", Type: "S"},
+				SortKey:      &KeyAttribute{Name: "SK", Type: "S"},
+			},
+		},
+		TTLAttribute: "ExpiresAt",
+	}
+}
+
+func testTableDescription() *dynamodb.TableDescription {
+	return &dynamodb.TableDescription{
+		TableName: aws.String("MyTable"),
+		KeySchema: toKeySchema(KeyAttribute{Name: "PK"}, &KeyAttribute{Name: "SK"}),
+		BillingModeSummary: &dynamodb.BillingModeSummary{
+			BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		},
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndexDescription{
+			{
+				IndexName: aws.String("GSI1"),
+				KeySchema: toKeySchema(KeyAttribute{Name: "GSI1PK"}, &KeyAttribute{Name: "SK"}),
+			},
+		},
+	}
+}
+
+func enabledTTL(attr string) *dynamodb.TimeToLiveDescription {
+	return &dynamodb.TimeToLiveDescription{
+		AttributeName:    aws.String(attr),
+		TimeToLiveStatus: aws.String(dynamodb.TimeToLiveStatusEnabled),
+	}
+}
+
+func changeTypes(diff *TableDiff) []string {
+	var result []string
+	for _, change := range diff.Changes {
+		result = append(result, change.Type+" "+change.Target)
+	}
+
+	return result
+}
+
+func TestTableSpec_Diff(t *testing.T) {
+	t.Run("should create missing tables", func(t *testing.T) {
+		diff, err := testTableSpec().diff(nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"CREATE_TABLE MyTable", "UPDATE_TTL MyTable"}, changeTypes(diff))
+	})
+
+	t.Run("should be empty when table matches", func(t *testing.T) {
+		diff, err := testTableSpec().diff(testTableDescription(), enabledTTL("ExpiresAt"))
+		require.NoError(t, err)
+		require.True(t, diff.Empty())
+	})
+
+	t.Run("should add and remove global indexes", func(t *testing.T) {
+		spec := testTableSpec()
+		spec.GlobalIndexes = []IndexSpec{
+			{Name: "GSI2", PartitionKey: KeyAttribute{Name: "GSI2PK", Type: "S"}},
+		}
+
+		diff, err := spec.diff(testTableDescription(), enabledTTL("ExpiresAt"))
+		require.NoError(t, err)
+		require.Equal(t, []string{"DELETE_INDEX GSI1", "CREATE_INDEX GSI2"}, changeTypes(diff))
+	})
+
+	t.Run("should recreate indexes with changed keys", func(t *testing.T) {
+		spec := testTableSpec()
+		spec.GlobalIndexes[0].SortKey = nil
+
+		diff, err := spec.diff(testTableDescription(), enabledTTL("ExpiresAt"))
+		require.NoError(t, err)
+		require.Equal(t, []string{"DELETE_INDEX GSI1", "CREATE_INDEX GSI1"}, changeTypes(diff))
+	})
+
+	t.Run("should recreate indexes with changed projections", func(t *testing.T) {
+		spec := testTableSpec()
+		spec.GlobalIndexes[0].ProjectionType = dynamodb.ProjectionTypeInclude
+		spec.GlobalIndexes[0].NonKeyAttributes = []string{"Name", "Age"}
+
+		diff, err := spec.diff(testTableDescription(), enabledTTL("ExpiresAt"))
+		require.NoError(t, err)
+		require.Equal(t, []string{"DELETE_INDEX GSI1", "CREATE_INDEX GSI1"}, changeTypes(diff))
+
+		desc := testTableDescription()
+		desc.GlobalSecondaryIndexes[0].Projection = &dynamodb.Projection{
+			ProjectionType:   aws.String(dynamodb.ProjectionTypeInclude),
+			NonKeyAttributes: aws.StringSlice([]string{"Age", "Name"}),
+		}
+		diff, err = spec.diff(desc, enabledTTL("ExpiresAt"))
+		require.NoError(t, err)
+		require.True(t, diff.Empty(), diff.String())
+	})
+
+	t.Run("should update settings", func(t *testing.T) {
+		spec := testTableSpec()
+		spec.Throughput = &dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(5),
+			WriteCapacityUnits: aws.Int64(5),
+		}
+		spec.StreamViewType = dynamodb.StreamViewTypeNewImage
+
+		diff, err := spec.diff(testTableDescription(), nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"UPDATE_BILLING_MODE MyTable", "UPDATE_STREAM MyTable", "UPDATE_TTL MyTable",
+		}, changeTypes(diff))
+	})
+
+	t.Run("should switch billing mode after deleting and before creating indexes", func(t *testing.T) {
+		spec := testTableSpec()
+		spec.Throughput = &dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(5),
+			WriteCapacityUnits: aws.Int64(5),
+		}
+		spec.GlobalIndexes = []IndexSpec{
+			{Name: "GSI2", PartitionKey: KeyAttribute{Name: "GSI2PK", Type: "S"}},
+		}
+
+		diff, err := spec.diff(testTableDescription(), enabledTTL("ExpiresAt"))
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"DELETE_INDEX GSI1", "UPDATE_BILLING_MODE MyTable", "CREATE_INDEX GSI2",
+		}, changeTypes(diff))
+	})
+
+	t.Run("should update provisioned throughput", func(t *testing.T) {
+		throughput := func(read, write int64) *dynamodb.ProvisionedThroughputDescription {
+			return &dynamodb.ProvisionedThroughputDescription{
+				ReadCapacityUnits:  aws.Int64(read),
+				WriteCapacityUnits: aws.Int64(write),
+			}
+		}
+		desc := testTableDescription()
+		desc.BillingModeSummary = nil
+		desc.ProvisionedThroughput = throughput(5, 5)
+		desc.GlobalSecondaryIndexes[0].ProvisionedThroughput = throughput(5, 5)
+
+		spec := testTableSpec()
+		spec.Throughput = &dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(5),
+			WriteCapacityUnits: aws.Int64(5),
+		}
+		diff, err := spec.diff(desc, enabledTTL("ExpiresAt"))
+		require.NoError(t, err)
+		require.True(t, diff.Empty(), diff.String())
+
+		spec.Throughput = &dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(10),
+			WriteCapacityUnits: aws.Int64(5),
+		}
+		spec.GlobalIndexes[0].Throughput = &dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(5),
+			WriteCapacityUnits: aws.Int64(5),
+		}
+		diff, err = spec.diff(desc, enabledTTL("ExpiresAt"))
+		require.NoError(t, err)
+		require.Equal(t, []string{"UPDATE_THROUGHPUT MyTable"}, changeTypes(diff))
+		require.Equal(t, "5/5 -> 10/5 read/write capacity", diff.Changes[0].Detail)
+
+		spec.GlobalIndexes[0].Throughput = &dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(5),
+			WriteCapacityUnits: aws.Int64(20),
+		}
+		diff, err = spec.diff(desc, enabledTTL("ExpiresAt"))
+		require.NoError(t, err)
+		require.Equal(t, []string{"UPDATE_THROUGHPUT MyTable", "UPDATE_THROUGHPUT GSI1"}, changeTypes(diff))
+	})
+
+	t.Run("with errors", func(t *testing.T) {
+		t.Run("should reject key schema changes", func(t *testing.T) {
+			spec := testTableSpec()
+			spec.SortKey = nil
+
+			_, err := spec.diff(testTableDescription(), nil)
+			require.Equal(t, ErrKeySchemaMismatch, err)
+		})
+
+		t.Run("should reject local index changes", func(t *testing.T) {
+			spec := testTableSpec()
+			spec.LocalIndexes = []IndexSpec{
+				{
+					Name:         "LSI1",
+					PartitionKey: KeyAttribute{Name: "PK", Type: "S"},
+					SortKey:      &KeyAttribute{Name: "LSI1SK", Type: "N"},
+				},
+			}
+
+			_, err := spec.diff(testTableDescription(), nil)
+			require.Equal(t, ErrLocalIndexMismatch, err)
+		})
+	})
+}
+
+func TestTableSpec_Validate(t *testing.T) {
+	require.NoError(t, testTableSpec().validate())
+
+	spec := testTableSpec()
+	spec.GlobalIndexes[0].SortKey = &KeyAttribute{Name: "SK", Type: "N"}
+	require.Equal(t, ErrInvalidTableSpec, spec.validate())
+
+	require.Equal(t, ErrInvalidTableSpec, TableSpec{Name: "MyTable"}.validate())
+
+	spec = testTableSpec()
+	spec.BillingMode = dynamodb.BillingModeProvisioned
+	require.Equal(t, ErrInvalidTableSpec, spec.validate())
+}
+
+func TestClient_UpdateTTL(t *testing.T) {
+	var updates []string
+	cli := stubClient(t, func(op *Operation) error {
+		spec := op.Input.(*dynamodb.UpdateTimeToLiveInput).TimeToLiveSpecification
+		updates = append(updates, fmt.Sprintf("%s %t", aws.StringValue(spec.AttributeName), aws.BoolValue(spec.Enabled)))
+		return nil
+	})
+
+	t.Run("should only disable the old attribute when switching attributes", func(t *testing.T) {
+		updates = nil
+		err := cli.updateTTL(context.Background(), testTableSpec(), enabledTTL("OldExpiresAt"))
+		require.ErrorIs(t, err, ErrTTLSwitchPending)
+		require.Equal(t, []string{"OldExpiresAt false"}, updates)
+
+		updates = nil
+		require.NoError(t, cli.updateTTL(context.Background(), testTableSpec(), nil))
+		require.Equal(t, []string{"ExpiresAt true"}, updates)
+	})
+
+	t.Run("should disable ttl", func(t *testing.T) {
+		updates = nil
+		spec := testTableSpec()
+		spec.TTLAttribute = ""
+		require.NoError(t, cli.updateTTL(context.Background(), spec, enabledTTL("ExpiresAt")))
+		require.Equal(t, []string{"ExpiresAt false"}, updates)
+	})
+}
+
+func TestClient_UpdateBillingMode(t *testing.T) {
+	var input *dynamodb.UpdateTableInput
+	cli := stubClient(t, func(op *Operation) error {
+		switch in := op.Input.(type) {
+		case *dynamodb.DescribeTableInput:
+			// GSI1 is still on the table but no longer part of the spec
+			desc := testTableDescription()
+			desc.GlobalSecondaryIndexes = append(desc.GlobalSecondaryIndexes, &dynamodb.GlobalSecondaryIndexDescription{
+				IndexName: aws.String("GSI2"),
+			})
+			op.Output.(*dynamodb.DescribeTableOutput).Table = desc
+		case *dynamodb.UpdateTableInput:
+			input = in
+		}
+		return nil
+	})
+
+	spec := testTableSpec()
+	spec.Throughput = &dynamodb.ProvisionedThroughput{
+		ReadCapacityUnits:  aws.Int64(5),
+		WriteCapacityUnits: aws.Int64(5),
+	}
+	spec.GlobalIndexes = []IndexSpec{{
+		Name:         "GSI2",
+		PartitionKey: KeyAttribute{Name: "GSI2PK", Type: "S"},
+		Throughput: &dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(1),
+			WriteCapacityUnits: aws.Int64(1),
+		},
+	}}
+	require.NoError(t, cli.updateBillingMode(context.Background(), spec))
+	require.Equal(t, dynamodb.BillingModeProvisioned, aws.StringValue(input.BillingMode))
+	require.Equal(t, spec.Throughput, input.ProvisionedThroughput)
+	require.Len(t, input.GlobalSecondaryIndexUpdates, 2)
+	require.Equal(t, "GSI1", aws.StringValue(input.GlobalSecondaryIndexUpdates[0].Update.IndexName))
+	require.Equal(t, spec.Throughput, input.GlobalSecondaryIndexUpdates[0].Update.ProvisionedThroughput)
+	require.Equal(t, "GSI2", aws.StringValue(input.GlobalSecondaryIndexUpdates[1].Update.IndexName))
+	require.Equal(t, spec.GlobalIndexes[0].Throughput, input.GlobalSecondaryIndexUpdates[1].Update.ProvisionedThroughput)
+}
+
+func TestTableSpec_CreateTableInput(t *testing.T) {
+	input := testTableSpec().createTableInput()
+
+	require.Equal(t, dynamodb.BillingModePayPerRequest, *input.BillingMode)
+	require.Nil(t, input.ProvisionedThroughput)
+	require.Len(t, input.AttributeDefinitions, 3)
+	require.Len(t, input.GlobalSecondaryIndexes, 1)
+	require.Nil(t, input.GlobalSecondaryIndexes[0].ProvisionedThroughput)
+	require.Equal(t, dynamodb.ProjectionTypeAll, *input.GlobalSecondaryIndexes[0].Projection.ProjectionType)
+	require.NoError(t, input.Validate())
+}
@@ -10,6 +10,10 @@ import (
 type Map = map[string]*dynamodb.AttributeValue
 type Maps = []Map
 
+// Params holds values for named placeholders used in builder expressions
+// e.g Where("'status' = :status AND owner = :owner", Params{"status": "open", "owner": id})
+type Params map[string]interface{}
+
 // StringSet converts an array of strings to a string set type
 func StringSet(arr ...string) *dynamodb.AttributeValue {
 	return &dynamodb.AttributeValue{
@@ -17,6 +21,30 @@ func StringSet(arr ...string) *dynamodb.AttributeValue {
 	}
 }
 
+// IntSet converts an array of integers to a number set type.
+// dynamodb rejects sets with duplicate members and doesn't preserve their order, use IntList for ordered values
+func IntSet(arr ...int) *dynamodb.AttributeValue {
+	set := make([]*string, 0, len(arr))
+	for _, num := range arr {
+		set = append(set, aws.String(strconv.FormatInt(int64(num), 10)))
+	}
+	return &dynamodb.AttributeValue{
+		NS: set,
+	}
+}
+
+// FloatSet converts an array of floats to a number set type.
+// dynamodb rejects sets with duplicate members and doesn't preserve their order
+func FloatSet(arr ...float64) *dynamodb.AttributeValue {
+	set := make([]*string, 0, len(arr))
+	for _, num := range arr {
+		set = append(set, aws.String(strconv.FormatFloat(num, 'f', -1, 64)))
+	}
+	return &dynamodb.AttributeValue{
+		NS: set,
+	}
+}
+
 // StringList converts an array of strings to a string list type
 func StringList(arr ...string) *dynamodb.AttributeValue {
 	list := make([]*dynamodb.AttributeValue, 0, len(arr))